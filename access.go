@@ -0,0 +1,83 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"time"
+)
+
+// AccessGrant is returned by GrantAccess.
+type AccessGrant struct {
+	// GroupID is the ID of the etherpad group mapped to the external group.
+	GroupID string
+
+	// AuthorID is the ID of the etherpad author mapped to the external user.
+	AuthorID string
+
+	// Session is the new session of the author in the group, use
+	// SessionCookie to pass it to the browser.
+	Session *Session
+}
+
+// GrantAccess gives an external user (for example from a single sign-on
+// system) access to the group pads of an external group for the duration ttl:
+// It maps the external group to a group (createGroupIfNotExistsFor), the
+// external user to an author with the given name (createAuthorIfNotExistsFor)
+// and creates a session of the author in the group.
+// EtherpadLite.MapperCache is used if set. It is safe to call GrantAccess
+// concurrently for the same user, each call creates its own session.
+func (pad *EtherpadLite) GrantAccess(ctx context.Context, externalUserID, externalGroupID, name string, ttl time.Duration) (*AccessGrant, error) {
+	groupID, err := pad.CreateGroupIDFor(ctx, externalGroupID)
+	if err != nil {
+		return nil, err
+	}
+	authorID, err := pad.EnsureAuthorID(ctx, externalUserID, name)
+	if err != nil {
+		return nil, err
+	}
+	session, err := pad.CreateSessionFor(ctx, groupID, authorID, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &AccessGrant{GroupID: groupID, AuthorID: authorID, Session: session}, nil
+}
+
+// RevokeAccess deletes all sessions of the external user in the external
+// group, see GrantAccess. If the user or group were never mapped they are
+// created by the mapping calls (and have no sessions).
+// If some sessions could not be deleted a *DeleteSessionsError is returned.
+func (pad *EtherpadLite) RevokeAccess(ctx context.Context, externalUserID, externalGroupID string) error {
+	groupID, err := pad.CreateGroupIDFor(ctx, externalGroupID)
+	if err != nil {
+		return err
+	}
+	authorID, err := pad.EnsureAuthorID(ctx, externalUserID, "")
+	if err != nil {
+		return err
+	}
+	sessions, err := pad.ListAuthorSessions(ctx, authorID)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for id, session := range sessions {
+		if session.GroupID == groupID {
+			ids = append(ids, id)
+		}
+	}
+	_, err = pad.deleteSessions(ctx, ids, DefaultConcurrency)
+	return err
+}
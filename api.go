@@ -0,0 +1,246 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// API contains all methods of EtherpadLite, use it instead of *EtherpadLite
+// to be able to replace the client in tests (for example with the mock
+// package). *EtherpadLite and *Pool implement API.
+// New methods of EtherpadLite are added to API as well.
+type API interface {
+	// raw API methods
+	BuildRequest(ctx context.Context, method string, params map[string]interface{}) (*http.Request, error)
+	DecodeResponse(r io.Reader) (*Response, error)
+	Call(ctx context.Context, method string, params map[string]interface{}) (*Response, error)
+	CreateGroup(ctx context.Context) (*Response, error)
+	CreateGroupIfNotExistsFor(ctx context.Context, groupMapper interface{}) (*Response, error)
+	DeleteGroup(ctx context.Context, groupID interface{}) (*Response, error)
+	ListPads(ctx context.Context, groupID interface{}) (*Response, error)
+	CreateGroupPad(ctx context.Context, groupID, padName, text interface{}) (*Response, error)
+	ListAllGroups(ctx context.Context) (*Response, error)
+	CreateAuthor(ctx context.Context, name interface{}) (*Response, error)
+	CreateAuthorIfNotExistsFor(ctx context.Context, authorMapper, name interface{}) (*Response, error)
+	ListPadsOfAuthor(ctx context.Context, authorID interface{}) (*Response, error)
+	GetAuthorName(ctx context.Context, authorID interface{}) (*Response, error)
+	CreateSession(ctx context.Context, groupID, authorID, validUntil interface{}) (*Response, error)
+	DeleteSession(ctx context.Context, sessionID interface{}) (*Response, error)
+	GetSessionInfo(ctx context.Context, sessionID interface{}) (*Response, error)
+	ListSessionsOfGroup(ctx context.Context, groupID interface{}) (*Response, error)
+	ListSessionsOfAuthor(ctx context.Context, authorID interface{}) (*Response, error)
+	GetText(ctx context.Context, padID, rev interface{}) (*Response, error)
+	SetText(ctx context.Context, padID, text interface{}) (*Response, error)
+	SetTextAs(ctx context.Context, padID, text, authorID interface{}) (*Response, error)
+	AppendText(ctx context.Context, padID, text interface{}) (*Response, error)
+	AppendTextAs(ctx context.Context, padID, text, authorID interface{}) (*Response, error)
+	GetHTML(ctx context.Context, padID, rev interface{}) (*Response, error)
+	SetHTML(ctx context.Context, padID, html interface{}) (*Response, error)
+	SetHTMLAs(ctx context.Context, padID, html, authorID interface{}) (*Response, error)
+	GetAttributePool(ctx context.Context, padID interface{}) (*Response, error)
+	GetRevisionChangeset(ctx context.Context, padID, rev interface{}) (*Response, error)
+	CreateDiffHTML(ctx context.Context, padID, startRev, endRev interface{}) (*Response, error)
+	RestoreRevision(ctx context.Context, padID, rev interface{}) (*Response, error)
+	RestoreRevisionAs(ctx context.Context, padID, rev, authorID interface{}) (*Response, error)
+	GetChatHistory(ctx context.Context, padID, start, end interface{}) (*Response, error)
+	GetChatHead(ctx context.Context, padID interface{}) (*Response, error)
+	CreatePad(ctx context.Context, padID, text interface{}) (*Response, error)
+	CreatePadAs(ctx context.Context, padID, text, authorID interface{}) (*Response, error)
+	GetRevisionsCount(ctx context.Context, padID interface{}) (*Response, error)
+	GetSavedRevisionsCount(ctx context.Context, padID interface{}) (*Response, error)
+	ListSavedRevisions(ctx context.Context, padID interface{}) (*Response, error)
+	SaveRevision(ctx context.Context, padID, rev interface{}) (*Response, error)
+	PadUsersCount(ctx context.Context, padID interface{}) (*Response, error)
+	PadUsers(ctx context.Context, padID interface{}) (*Response, error)
+	DeletePad(ctx context.Context, padID interface{}) (*Response, error)
+	CopyPad(ctx context.Context, sourceID, destinationID, force interface{}) (*Response, error)
+	MovePad(ctx context.Context, sourceID, destinationID, force interface{}) (*Response, error)
+	CopyPadWithoutHistory(ctx context.Context, sourceID, destinationID, force interface{}) (*Response, error)
+	GetReadOnlyID(ctx context.Context, padID interface{}) (*Response, error)
+	GetPadID(ctx context.Context, readOnlyID interface{}) (*Response, error)
+	SetPublicStatus(ctx context.Context, padID, publicStatus interface{}) (*Response, error)
+	GetPublicStatus(ctx context.Context, padID interface{}) (*Response, error)
+	SetPassword(ctx context.Context, padID, password interface{}) (*Response, error)
+	IsPasswordProtected(ctx context.Context, padID interface{}) (*Response, error)
+	ListAuthorsOfPad(ctx context.Context, padID interface{}) (*Response, error)
+	GetLastEdited(ctx context.Context, padID interface{}) (*Response, error)
+	SendClientsMessage(ctx context.Context, padID, msg interface{}) (*Response, error)
+	CheckToken(ctx context.Context) (*Response, error)
+	ListAllPads(ctx context.Context) (*Response, error)
+	GetStats(ctx context.Context) (*Response, error)
+
+	// typed wrappers
+	GetTextContent(ctx context.Context, padID string, rev ...int) (string, error)
+	GetHTMLContent(ctx context.Context, padID string, rev ...int) (string, error)
+	ListAllPadIDs(ctx context.Context) ([]string, error)
+	ListGroupPadIDs(ctx context.Context, groupID string) ([]string, error)
+	ListGroupPadNames(ctx context.Context, groupID string) ([]string, error)
+	CreateGroupID(ctx context.Context) (string, error)
+	CreateGroupIDFor(ctx context.Context, mapper string) (string, error)
+	CreateAuthorID(ctx context.Context, name string) (string, error)
+	EnsureAuthorID(ctx context.Context, mapper, name string) (string, error)
+	RevisionsCount(ctx context.Context, padID string) (int, error)
+	SavedRevisionsCount(ctx context.Context, padID string) (int, error)
+	SavedRevisions(ctx context.Context, padID string) ([]int, error)
+	AuthorsOfPad(ctx context.Context, padID string) ([]string, error)
+	AuthorName(ctx context.Context, authorID string) (string, error)
+	AuthorsOfPadWithNames(ctx context.Context, padID string) (map[string]string, error)
+	LastEdited(ctx context.Context, padID string) (time.Time, error)
+	ReadOnlyID(ctx context.Context, padID string) (string, error)
+	PadIDFromReadOnly(ctx context.Context, readOnlyID string) (string, error)
+	PublicStatus(ctx context.Context, padID string) (bool, error)
+	PasswordProtected(ctx context.Context, padID string) (bool, error)
+	AllGroupIDs(ctx context.Context) ([]string, error)
+	PadIDsOfAuthor(ctx context.Context, authorID string) ([]string, error)
+	DiffHTML(ctx context.Context, padID string, startRev, endRev int) (*PadDiff, error)
+	StatsTyped(ctx context.Context) (*Stats, error)
+
+	// configuration
+	Validate() error
+	Verify(ctx context.Context) error
+
+	// API versions
+	Supports(method string) bool
+
+	// sessions
+	CreateSessionTyped(ctx context.Context, groupID, authorID string, validUntil time.Time) (*Session, error)
+	CreateSessionFor(ctx context.Context, groupID, authorID string, d time.Duration) (*Session, error)
+	GetSession(ctx context.Context, sessionID string) (*Session, error)
+	ListGroupSessions(ctx context.Context, groupID string) (map[string]Session, error)
+	ListAuthorSessions(ctx context.Context, authorID string) (map[string]Session, error)
+
+	// session cleanup
+	CleanupExpiredSessions(ctx context.Context, groupID string, before time.Time, opts ...CleanupOptions) (int, error)
+	CleanupExpiredAuthorSessions(ctx context.Context, authorID string, before time.Time, opts ...CleanupOptions) (int, error)
+	DeleteAllSessionsOfAuthor(ctx context.Context, authorID string) (int, error)
+	DeleteAllSessionsOfGroup(ctx context.Context, groupID string) (int, error)
+
+	// access helpers
+	GrantAccess(ctx context.Context, externalUserID, externalGroupID, name string, ttl time.Duration) (*AccessGrant, error)
+	RevokeAccess(ctx context.Context, externalUserID, externalGroupID string) error
+
+	// pad users
+	PadUsersList(ctx context.Context, padID string) ([]PadUser, error)
+	PadUsersCountInt(ctx context.Context, padID string) (int, error)
+
+	// chat
+	ChatHistory(ctx context.Context, padID string, start, end int) ([]ChatMessage, error)
+	FullChatHistory(ctx context.Context, padID string) ([]ChatMessage, error)
+	ChatHead(ctx context.Context, padID string) (int, error)
+	TailChat(ctx context.Context, padID string, interval time.Duration) (<-chan ChatMessage, <-chan error)
+	ChatHistoryPages(ctx context.Context, padID string, pageSize int) *ChatPager
+	ForEachChatMessage(ctx context.Context, padID string, pageSize int, fn func(msg ChatMessage) error) error
+
+	// attribute pools
+	AttributePool(ctx context.Context, padID string) (*AttributePool, error)
+
+	// changesets
+	RevisionChangeset(ctx context.Context, padID string, rev int) (string, error)
+
+	// revisions
+	RevisionTexts(ctx context.Context, padID string, fromRev, toRev int) (*RevisionIterator, error)
+	ForEachRevision(ctx context.Context, padID string, fromRev, toRev int, fn func(rev int, text string) error) error
+
+	// diffs
+	DiffRevisions(ctx context.Context, padID string, revA, revB int) (*TextDiff, error)
+
+	// pads
+	PadExists(ctx context.Context, padID string) (bool, error)
+	EnsurePad(ctx context.Context, padID, initialText string) (created bool, err error)
+	EnsureGroupPad(ctx context.Context, groupID, padName, text string) (padID string, created bool, err error)
+	MovePadSafe(ctx context.Context, sourceID, destinationID string, overwrite bool) error
+	RenamePad(ctx context.Context, padID, newName string) (string, error)
+
+	// text
+	AppendTextChunked(ctx context.Context, padID, text string, chunkSize int, progress ...func(offset, total int)) error
+	EnsureTextAction(ctx context.Context, padID, text string, opts ...EnsureTextOptions) (TextAction, error)
+	EnsureText(ctx context.Context, padID, text string, opts ...EnsureTextOptions) (changed bool, err error)
+
+	// HTML import
+	SetHTMLFromFile(ctx context.Context, padID, path string, opts HTMLImportOptions) error
+
+	// groups
+	DeleteGroupCascade(ctx context.Context, groupID string, opts ...CascadeOptions) error
+	CloneGroup(ctx context.Context, sourceGroupID string, opts ...CloneOptions) (string, error)
+
+	// pad iteration
+	Pads(ctx context.Context, opts PadsOptions) (<-chan PadInfo, error)
+	ListPadsModifiedSince(ctx context.Context, since time.Time, concurrency int) ([]PadInfo, error)
+
+	// bulk operations
+	GetTexts(ctx context.Context, padIDs []string, concurrency int) (texts map[string]string, errs map[string]error)
+
+	// pad statistics
+	PadStats(ctx context.Context, padID string) (*PadContentStats, error)
+	PadStatsBatch(ctx context.Context, padIDs []string, concurrency int) (stats map[string]*PadContentStats, errs map[string]error)
+
+	// cleanup
+	PurgeOldPads(ctx context.Context, olderThan time.Duration, opts PurgeOptions) (*PurgeReport, error)
+	DeletePadsMatching(ctx context.Context, re *regexp.Regexp, opts ...DeleteMatchingOptions) ([]string, error)
+
+	// reports
+	PadReport(ctx context.Context, padID string) (*PadReport, error)
+	ServerReport(ctx context.Context, concurrency int) (*ServerReport, error)
+
+	// snapshots
+	SnapshotPad(ctx context.Context, padID string) (*Snapshot, error)
+	RestoreSnapshot(ctx context.Context, snapshot *Snapshot) error
+
+	// export
+	ExportPad(ctx context.Context, padID string, format ExportFormat, w io.Writer) error
+	DetectExportFormats(ctx context.Context) ([]ExportFormat, error)
+
+	// import
+	ImportPad(ctx context.Context, padID string, filename string, r io.Reader) error
+
+	// URLs
+	PadURL(padID string, options ...PadURLOptions) (string, error)
+	ReadOnlyURL(ctx context.Context, padID string, options ...PadURLOptions) (string, error)
+	TimesliderURL(padID string, rev int) (string, error)
+
+	// backups
+	BackupAllPads(ctx context.Context, dest string, opts BackupOptions) (*BackupReport, error)
+
+	// restore
+	RestorePads(ctx context.Context, src string, opts RestoreOptions) (*RestoreReport, error)
+
+	// health checks
+	HealthCheck(ctx context.Context) (*Health, error)
+	MonitorHealth(ctx context.Context, interval time.Duration, fn func(old, current *Health, err error))
+}
+
+// make sure that EtherpadLite and Pool implement all methods of API
+var (
+	_ API = (*EtherpadLite)(nil)
+	_ API = (*Pool)(nil)
+)
+
+// callAPI calls the method on api and returns an EtherpadError if the return
+// code is not EverythingOk, like callChecked.
+func callAPI(ctx context.Context, api API, method string, params map[string]interface{}) (*Response, error) {
+	resp, err := api.Call(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := ResponseToError(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+)
+
+// AttributePool is the attribute pool of a pad. Attributes are key value
+// pairs, for example ("author", "a.xyz") or ("bold", "true"), changesets
+// reference them by their number.
+type AttributePool struct {
+	// NumToAttrib maps the number of an attribute to the attribute as
+	// (key, value) pair.
+	NumToAttrib map[int][2]string `json:"numToAttrib"`
+
+	// AttribToNum maps "key,value" to the number of the attribute.
+	AttribToNum map[string]int `json:"attribToNum"`
+
+	// NextNum is the number the next attribute added to the pool gets.
+	NextNum int `json:"nextNum"`
+}
+
+// Attrib returns the attribute with the given number, ok is false if there is
+// no such attribute.
+func (p *AttributePool) Attrib(num int) (key, value string, ok bool) {
+	attrib, ok := p.NumToAttrib[num]
+	if !ok {
+		return "", "", false
+	}
+	return attrib[0], attrib[1], true
+}
+
+// AttributePool returns the attribute pool of a pad.
+func (pad *EtherpadLite) AttributePool(ctx context.Context, padID string) (*AttributePool, error) {
+	resp, err := pad.callChecked(ctx, "getAttributePool", map[string]interface{}{"padID": padID})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := resp.field("pool"); err != nil {
+		return nil, err
+	}
+	var data struct {
+		Pool AttributePool `json:"pool"`
+	}
+	if err := resp.DecodeData(&data); err != nil {
+		return nil, err
+	}
+	return &data.Pool, nil
+}
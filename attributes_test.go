@@ -0,0 +1,80 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// capturedPool is the response of getAttributePool of an etherpad 1.8 pad
+// with two authors, bold text and a list.
+const capturedPool = `{"code":0,"message":"ok","data":{"pool":{"numToAttrib":{"0":["author","a.7HvDhNBMHBWknbZH"],"1":["insertorder","first"],"2":["bold","true"],"3":["author","a.WmZMYnUXgBPQfIrW"],"4":["list","bullet1"],"5":["lmkr","1"],"6":["start","1"]},"attribToNum":{"author,a.7HvDhNBMHBWknbZH":0,"insertorder,first":1,"bold,true":2,"author,a.WmZMYnUXgBPQfIrW":3,"list,bullet1":4,"lmkr,1":5,"start,1":6},"nextNum":7}}}`
+
+// serveBody returns a client for a server answering all requests with body.
+func serveBody(t *testing.T, body string) *EtherpadLite {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	pad := NewEtherpadLite("key")
+	pad.BaseURL = server.URL + "/api"
+	return pad
+}
+
+func TestAttributePool(t *testing.T) {
+	pad := serveBody(t, capturedPool)
+	pool, err := pad.AttributePool(context.Background(), "pad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.NextNum != 7 {
+		t.Errorf("expected nextNum 7, got %d", pool.NextNum)
+	}
+	if len(pool.NumToAttrib) != 7 || len(pool.AttribToNum) != 7 {
+		t.Errorf("expected 7 attributes, got %v and %v", pool.NumToAttrib, pool.AttribToNum)
+	}
+	for attrib, num := range pool.AttribToNum {
+		key, value, ok := pool.Attrib(num)
+		if !ok {
+			t.Errorf("attribute %d is missing", num)
+			continue
+		}
+		if key+","+value != attrib {
+			t.Errorf("expected attribute %d to be %s, got %s,%s", num, attrib, key, value)
+		}
+	}
+	key, value, ok := pool.Attrib(3)
+	if !ok || key != "author" || value != "a.WmZMYnUXgBPQfIrW" {
+		t.Errorf("expected attribute 3 to be author a.WmZMYnUXgBPQfIrW, got %q %q %v", key, value, ok)
+	}
+	if _, _, ok := pool.Attrib(7); ok {
+		t.Error("expected attribute 7 not to exist")
+	}
+}
+
+func TestAttributePoolMissing(t *testing.T) {
+	pad := serveBody(t, `{"code":0,"message":"ok","data":{}}`)
+	_, err := pad.AttributePool(context.Background(), "pad")
+	var missing *MissingFieldError
+	if !errors.As(err, &missing) || missing.Key != "pool" {
+		t.Errorf("expected a MissingFieldError for pool, got %v", err)
+	}
+}
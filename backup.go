@@ -0,0 +1,297 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BackupManifestName is the name of the manifest file in a backup.
+const BackupManifestName = "manifest.json"
+
+// BackupEntry describes a pad in a backup.
+type BackupEntry struct {
+	// PadID is the ID of the pad.
+	PadID string `json:"padID"`
+
+	// File is the path of the pad content, relative to the backup root
+	// (always with "/" as separator).
+	File string `json:"file"`
+
+	// ChatFile is the path of the chat history (a JSON array of chat
+	// messages), relative to the backup root. It is empty if the chat was
+	// not included.
+	ChatFile string `json:"chatFile,omitempty"`
+
+	// LastEdited is the time the pad was last edited.
+	LastEdited time.Time `json:"lastEdited"`
+
+	// Revisions is the number of revisions of the pad.
+	Revisions int `json:"revisions"`
+
+	// ReadOnlyID is the read-only ID of the pad.
+	ReadOnlyID string `json:"readOnlyID"`
+}
+
+// BackupManifest is the content of the manifest file of a backup.
+type BackupManifest struct {
+	// Created is the time the backup was started.
+	Created time.Time `json:"created"`
+
+	// Format is the format of the pad files.
+	Format ExportFormat `json:"format"`
+
+	// Pads contains an entry for each pad that was backed up successfully.
+	Pads []BackupEntry `json:"pads"`
+}
+
+// BackupOptions are the options for BackupAllPads.
+type BackupOptions struct {
+	// Format is the format of the pad files. ExportText (the default) uses the
+	// API, all other formats use the export endpoint (see ExportPad).
+	// ExportEtherpad includes the full history of the pads.
+	Format ExportFormat
+
+	// Archive writes a gzip compressed tar archive to dest instead of a
+	// directory tree.
+	Archive bool
+
+	// IncludeChat includes the chat history of each pad.
+	IncludeChat bool
+
+	// Concurrency is the maximal number of pads backed up concurrently,
+	// DefaultConcurrency if <= 0.
+	Concurrency int
+}
+
+// BackupReport is returned by BackupAllPads.
+type BackupReport struct {
+	// Manifest is the manifest written to the backup.
+	Manifest *BackupManifest
+
+	// Failed maps the IDs of the pads that could not be backed up to the
+	// error.
+	Failed map[string]error
+}
+
+// PadFilename encodes a pad ID into a string that is safe to use as a file
+// name on all platforms: ASCII letters, digits, "-" and "_" are kept, all
+// other bytes (including "." and the "$" of group pads) are encoded as "%XX".
+// Note that pad IDs that differ only in case are mapped to names that collide
+// on case-insensitive file systems.
+func PadFilename(padID string) string {
+	var b strings.Builder
+	for i := 0; i < len(padID); i++ {
+		c := padID[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '_':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// backupWriter writes the files of a backup, it must be safe for concurrent
+// use.
+type backupWriter interface {
+	writeFile(name string, data []byte) error
+	close() error
+}
+
+// dirBackupWriter writes a backup to a directory.
+type dirBackupWriter struct {
+	root string
+}
+
+func (w *dirBackupWriter) writeFile(name string, data []byte) error {
+	p := filepath.Join(w.root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0644)
+}
+
+func (w *dirBackupWriter) close() error {
+	return nil
+}
+
+// tarBackupWriter writes a backup to a gzip compressed tar archive.
+type tarBackupWriter struct {
+	mutex sync.Mutex
+	file  *os.File
+	gz    *gzip.Writer
+	tw    *tar.Writer
+	clock Clock
+}
+
+func (w *tarBackupWriter) writeFile(name string, data []byte) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: w.clock.Now(),
+	}
+	if err := w.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+func (w *tarBackupWriter) close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	err := w.tw.Close()
+	if gzErr := w.gz.Close(); err == nil {
+		err = gzErr
+	}
+	if fileErr := w.file.Close(); err == nil {
+		err = fileErr
+	}
+	return err
+}
+
+// newBackupWriter creates the directory or archive dest, clock is used for
+// the modification times in the archive.
+func newBackupWriter(dest string, archive bool, clock Clock) (backupWriter, error) {
+	if !archive {
+		if err := os.MkdirAll(dest, 0755); err != nil {
+			return nil, err
+		}
+		return &dirBackupWriter{root: dest}, nil
+	}
+	file, err := os.Create(dest)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(file)
+	return &tarBackupWriter{file: file, gz: gz, tw: tar.NewWriter(gz), clock: clock}, nil
+}
+
+// backupPad writes the content (and chat) of a pad.
+func (pad *EtherpadLite) backupPad(ctx context.Context, w backupWriter, padID string, opts *BackupOptions) (*BackupEntry, error) {
+	info := PadInfo{ID: padID}
+	infoOpts := PadsOptions{LastEdited: true, Revisions: true, ReadOnlyID: true}
+	if err := infoOpts.fetch(ctx, pad, &info); err != nil {
+		return nil, err
+	}
+	var content bytes.Buffer
+	if opts.Format == ExportText {
+		text, err := pad.GetTextContent(ctx, padID)
+		if err != nil {
+			return nil, err
+		}
+		content.WriteString(text)
+	} else if err := pad.ExportPad(ctx, padID, opts.Format, &content); err != nil {
+		return nil, err
+	}
+	name := PadFilename(padID)
+	entry := &BackupEntry{
+		PadID:      padID,
+		File:       path.Join("pads", name+"."+string(opts.Format)),
+		LastEdited: info.LastEdited,
+		Revisions:  info.Revisions,
+		ReadOnlyID: info.ReadOnlyID,
+	}
+	if opts.IncludeChat {
+		messages, err := pad.FullChatHistory(ctx, padID)
+		if err != nil {
+			return nil, err
+		}
+		chat, err := json.Marshal(messages)
+		if err != nil {
+			return nil, err
+		}
+		entry.ChatFile = path.Join("chat", name+".json")
+		if err := w.writeFile(entry.ChatFile, chat); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.writeFile(entry.File, content.Bytes()); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// BackupAllPads writes all pads to dest, either a directory or (if
+// opts.Archive is true) a gzip compressed tar archive.
+// The pad files are written to "pads/", the chat histories to "chat/" and
+// the manifest (see BackupManifest) mapping the pad IDs to the files to
+// "manifest.json". The file names are created with PadFilename.
+//
+// Pads that can't be backed up are reported in BackupReport.Failed and
+// don't abort the backup. An error is returned if the pads can't be listed,
+// the backup can't be written or ctx is cancelled (in this case the pads
+// processed so far are in the manifest).
+func (pad *EtherpadLite) BackupAllPads(ctx context.Context, dest string, opts BackupOptions) (*BackupReport, error) {
+	if opts.Format == "" {
+		opts.Format = ExportText
+	}
+	padIDs, err := pad.ListAllPadIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w, err := newBackupWriter(dest, opts.Archive, pad.clock())
+	if err != nil {
+		return nil, err
+	}
+	report := &BackupReport{
+		Manifest: &BackupManifest{Created: pad.clock().Now(), Format: opts.Format, Pads: []BackupEntry{}},
+		Failed:   make(map[string]error),
+	}
+	var mutex sync.Mutex
+	entries := make([]*BackupEntry, len(padIDs))
+	ctxErr := forEach(ctx, len(padIDs), opts.Concurrency, func(ctx context.Context, i int) error {
+		entry, err := pad.backupPad(ctx, w, padIDs[i], &opts)
+		if err != nil {
+			mutex.Lock()
+			report.Failed[padIDs[i]] = err
+			mutex.Unlock()
+		}
+		entries[i] = entry
+		return nil
+	})
+	for _, entry := range entries {
+		if entry != nil {
+			report.Manifest.Pads = append(report.Manifest.Pads, *entry)
+		}
+	}
+	manifest, err := json.MarshalIndent(report.Manifest, "", "  ")
+	if err == nil {
+		err = w.writeFile(BackupManifestName, manifest)
+	}
+	if closeErr := w.close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return report, fmt.Errorf("writing backup %s: %w", dest, err)
+	}
+	return report, ctxErr
+}
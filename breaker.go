@@ -0,0 +1,120 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultCircuitBreakerTimeout is the time the circuit breaker stays open if
+// EtherpadLite.CircuitBreakerTimeout is not set.
+const defaultCircuitBreakerTimeout = 30 * time.Second
+
+// ErrCircuitOpen is returned if the circuit breaker is open, i.e. the request
+// was not sent because too many of the previous requests failed.
+// See EtherpadLite.CircuitBreakerThreshold.
+var ErrCircuitOpen = errors.New("circuit breaker is open: etherpad backend failed too often")
+
+// circuitBreaker implements a simple circuit breaker. It is closed as long
+// as the number of consecutive failures is below the threshold.
+// Once the threshold is reached it is open until openUntil, after that
+// exactly one probe request is allowed (half-open). The result of the probe
+// decides if the breaker gets closed or opened again.
+//
+// The zero value is a closed breaker, it is safe for concurrent use.
+type circuitBreaker struct {
+	mutex     sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow reports whether a request may be sent.
+func (b *circuitBreaker) allow(threshold int, now time.Time) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.failures < threshold {
+		return true
+	}
+	if now.Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// success records a successful request and closes the breaker.
+func (b *circuitBreaker) success() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+// failure records a failed request and opens the breaker if the threshold is
+// reached.
+func (b *circuitBreaker) failure(threshold int, openFor time.Duration, now time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.failures++
+	b.probing = false
+	if b.failures >= threshold {
+		b.openUntil = now.Add(openFor)
+	}
+}
+
+// abort is called if a request neither failed nor succeeded, for example
+// because the caller cancelled the context. It allows another probe.
+func (b *circuitBreaker) abort() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.probing = false
+}
+
+// doRequest sends req with the client of pad, takes care of the retries (see
+// doRetry) and of the circuit breaker.
+// If an error is returned the response is always nil, otherwise the caller
+// must close the body of the response.
+func (pad *EtherpadLite) doRequest(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	threshold := pad.CircuitBreakerThreshold
+	if threshold <= 0 {
+		return pad.doRetry(ctx, req)
+	}
+	if !pad.breaker.allow(threshold, pad.clock().Now()) {
+		return nil, ErrCircuitOpen
+	}
+	resp, err := pad.doRetry(ctx, req)
+	switch {
+	case ctx.Err() != nil:
+		// not the fault of the backend
+		pad.breaker.abort()
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		openFor := pad.CircuitBreakerTimeout
+		if openFor <= 0 {
+			openFor = defaultCircuitBreakerTimeout
+		}
+		pad.breaker.failure(threshold, openFor, pad.clock().Now())
+	default:
+		pad.breaker.success()
+	}
+	return resp, err
+}
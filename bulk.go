@@ -0,0 +1,55 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"sync"
+)
+
+// GetTexts returns the texts of many pads, using at most concurrency
+// concurrent requests (DefaultConcurrency if <= 0).
+// texts maps the pad IDs to the texts of the pads that could be fetched,
+// errs maps the pad IDs to the error for the pads that could not be fetched.
+// Each pad ID is in exactly one of the maps: If ctx is cancelled no new
+// requests are started and the pads that were not fetched are in errs with
+// ctx.Err() as error.
+func (pad *EtherpadLite) GetTexts(ctx context.Context, padIDs []string, concurrency int) (texts map[string]string, errs map[string]error) {
+	texts = make(map[string]string, len(padIDs))
+	errs = make(map[string]error)
+	var mutex sync.Mutex
+	ctxErr := forEach(ctx, len(padIDs), concurrency, func(ctx context.Context, i int) error {
+		text, err := pad.GetTextContent(ctx, padIDs[i])
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil {
+			errs[padIDs[i]] = err
+		} else {
+			texts[padIDs[i]] = text
+		}
+		return nil
+	})
+	if ctxErr != nil {
+		for _, id := range padIDs {
+			if _, done := texts[id]; done {
+				continue
+			}
+			if _, done := errs[id]; !done {
+				errs[id] = ctxErr
+			}
+		}
+	}
+	return texts, errs
+}
@@ -0,0 +1,101 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// benchmarkLatency is the latency of the server used in the benchmarks.
+const benchmarkLatency = time.Millisecond
+
+// slowServer returns a client for a server that answers every request after
+// latency: getRevisionsCount returns 1000 and getText returns "padID@rev".
+func slowServer(tb testing.TB, latency time.Duration) *EtherpadLite {
+	tb.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(latency)
+		if strings.HasSuffix(r.URL.Path, "/getRevisionsCount") {
+			io.WriteString(w, `{"code": 0, "message": "ok", "data": {"revisions": 1000}}`)
+			return
+		}
+		query := r.URL.Query()
+		fmt.Fprintf(w, `{"code": 0, "message": "ok", "data": {"text": "%s@%s"}}`, query.Get("padID"), query.Get("rev"))
+	}))
+	tb.Cleanup(server.Close)
+	pad := NewEtherpadLite("key")
+	pad.BaseURL = server.URL + "/api"
+	pad.Client = server.Client()
+	return pad
+}
+
+func TestGetTexts(t *testing.T) {
+	pad := slowServer(t, 0)
+	padIDs := make([]string, 50)
+	for i := range padIDs {
+		padIDs[i] = fmt.Sprintf("pad-%d", i)
+	}
+	texts, errs := pad.GetTexts(context.Background(), padIDs, 4)
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors, got %v", errs)
+	}
+	for _, id := range padIDs {
+		if texts[id] != id+"@" {
+			t.Errorf("expected text %q for %s, got %q", id+"@", id, texts[id])
+		}
+	}
+}
+
+func TestGetTextsCancelled(t *testing.T) {
+	pad := slowServer(t, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	padIDs := []string{"a", "b", "c"}
+	texts, errs := pad.GetTexts(ctx, padIDs, 2)
+	if len(texts)+len(errs) != len(padIDs) {
+		t.Errorf("expected every pad in exactly one map, got %v and %v", texts, errs)
+	}
+	if len(texts) != 0 {
+		t.Errorf("expected no texts for a cancelled context, got %v", texts)
+	}
+}
+
+// BenchmarkGetTexts compares the throughput of GetTexts for different
+// concurrency levels against a server with a latency of 1ms, concurrency 1
+// corresponds to sequential getText calls.
+func BenchmarkGetTexts(b *testing.B) {
+	padIDs := make([]string, 100)
+	for i := range padIDs {
+		padIDs[i] = fmt.Sprintf("pad-%d", i)
+	}
+	for _, concurrency := range []int{1, 2, 4, DefaultConcurrency, 16, 32} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			pad := slowServer(b, benchmarkLatency)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, errs := pad.GetTexts(context.Background(), padIDs, concurrency); len(errs) != 0 {
+					b.Fatal(errs)
+				}
+			}
+		})
+	}
+}
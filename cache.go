@@ -0,0 +1,144 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"sync"
+	"time"
+)
+
+// mapperKind distinguishes author and group mappers in the cache.
+type mapperKind int
+
+const (
+	authorMapper mapperKind = iota
+	groupMapper
+)
+
+// mapperKey is the key of an entry in the MapperCache.
+type mapperKey struct {
+	kind   mapperKind
+	mapper string
+}
+
+// mapperEntry is an entry in the MapperCache.
+type mapperEntry struct {
+	id      string
+	expires time.Time
+}
+
+// MapperCache caches the IDs returned by createAuthorIfNotExistsFor and
+// createGroupIfNotExistsFor, the mapping from a mapper to an ID never changes
+// (unless the author or group is deleted).
+// Set EtherpadLite.MapperCache to use it in EnsureAuthorID and
+// CreateGroupIDFor.
+// The zero value is a cache without expiry and size limit, use
+// NewMapperCache to set them.
+//
+// Note that createAuthorIfNotExistsFor also updates the name of the author,
+// this doesn't happen for cached authors.
+//
+// A MapperCache is safe for concurrent use.
+type MapperCache struct {
+	// Clock is used to expire entries, RealClock if nil. It must be set
+	// before the cache is used.
+	Clock Clock
+
+	ttl        time.Duration
+	maxEntries int
+
+	mutex   sync.Mutex
+	entries map[mapperKey]mapperEntry
+}
+
+// NewMapperCache returns a new cache. Entries expire after ttl (never if
+// ttl <= 0) and the cache holds at most maxEntries entries (no limit if
+// maxEntries <= 0), if it's full the entry that expires first is evicted.
+func NewMapperCache(ttl time.Duration, maxEntries int) *MapperCache {
+	return &MapperCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[mapperKey]mapperEntry),
+	}
+}
+
+// get returns the cached ID for the mapper.
+func (c *MapperCache) get(kind mapperKind, mapper string) (string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	key := mapperKey{kind: kind, mapper: mapper}
+	entry, has := c.entries[key]
+	if !has {
+		return "", false
+	}
+	if !entry.expires.IsZero() && !clockOrReal(c.Clock).Now().Before(entry.expires) {
+		delete(c.entries, key)
+		return "", false
+	}
+	return entry.id, true
+}
+
+// put adds the ID for the mapper to the cache.
+func (c *MapperCache) put(kind mapperKind, mapper, id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[mapperKey]mapperEntry)
+	}
+	key := mapperKey{kind: kind, mapper: mapper}
+	if _, has := c.entries[key]; !has && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+	entry := mapperEntry{id: id}
+	if c.ttl > 0 {
+		entry.expires = clockOrReal(c.Clock).Now().Add(c.ttl)
+	}
+	c.entries[key] = entry
+}
+
+// evict removes the entry that expires first (or an arbitrary entry if
+// entries don't expire). It must be called with the mutex locked.
+func (c *MapperCache) evict() {
+	var (
+		victim      mapperKey
+		victimEntry mapperEntry
+		found       bool
+	)
+	for key, entry := range c.entries {
+		if !found || entry.expires.Before(victimEntry.expires) {
+			victim, victimEntry, found = key, entry, true
+		}
+	}
+	if found {
+		delete(c.entries, victim)
+	}
+}
+
+// Invalidate removes the cached author and group IDs of mapper, for example
+// after the author or group has been deleted.
+func (c *MapperCache) Invalidate(mapper string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, mapperKey{kind: authorMapper, mapper: mapper})
+	delete(c.entries, mapperKey{kind: groupMapper, mapper: mapper})
+}
+
+// Len returns the number of cached entries (including expired entries that
+// have not been removed yet).
+func (c *MapperCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.entries)
+}
@@ -0,0 +1,130 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+)
+
+func TestMapperCache(t *testing.T) {
+	server, pad := newTestClient(t)
+	pad.MapperCache = etherpadlite.NewMapperCache(time.Hour, 0)
+	ctx := context.Background()
+	var authorID, groupID string
+	for i := 0; i < 5; i++ {
+		id, err := pad.EnsureAuthorID(ctx, "user", "Alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authorID != "" && id != authorID {
+			t.Errorf("expected cached author ID %s, got %s", authorID, id)
+		}
+		authorID = id
+		if id, err = pad.CreateGroupIDFor(ctx, "user"); err != nil {
+			t.Fatal(err)
+		}
+		if groupID != "" && id != groupID {
+			t.Errorf("expected cached group ID %s, got %s", groupID, id)
+		}
+		groupID = id
+	}
+	if n := len(server.RequestsFor("createAuthorIfNotExistsFor")); n != 1 {
+		t.Errorf("expected one createAuthorIfNotExistsFor request, got %d", n)
+	}
+	if n := len(server.RequestsFor("createGroupIfNotExistsFor")); n != 1 {
+		t.Errorf("expected one createGroupIfNotExistsFor request, got %d", n)
+	}
+
+	pad.MapperCache.Invalidate("user")
+	if pad.MapperCache.Len() != 0 {
+		t.Errorf("expected an empty cache after Invalidate, got %d entries", pad.MapperCache.Len())
+	}
+	if _, err := pad.EnsureAuthorID(ctx, "user", "Alice"); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(server.RequestsFor("createAuthorIfNotExistsFor")); n != 2 {
+		t.Errorf("expected a request after Invalidate, got %d requests", n)
+	}
+}
+
+func TestMapperCacheExpiry(t *testing.T) {
+	server, pad := newTestClient(t)
+	clock := etherpadtest.NewFakeClock(time.Now())
+	pad.MapperCache = etherpadlite.NewMapperCache(time.Minute, 0)
+	pad.MapperCache.Clock = clock
+	ctx := context.Background()
+	lookup := func() {
+		t.Helper()
+		if _, err := pad.EnsureAuthorID(ctx, "user", "Alice"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lookup()
+	clock.Advance(59 * time.Second)
+	lookup()
+	if n := len(server.RequestsFor("createAuthorIfNotExistsFor")); n != 1 {
+		t.Errorf("expected one request before the entry expired, got %d", n)
+	}
+	clock.Advance(time.Second)
+	lookup()
+	if n := len(server.RequestsFor("createAuthorIfNotExistsFor")); n != 2 {
+		t.Errorf("expected a request after the entry expired, got %d requests", n)
+	}
+}
+
+func TestMapperCacheMaxEntries(t *testing.T) {
+	server, pad := newTestClient(t)
+	pad.MapperCache = etherpadlite.NewMapperCache(0, 2)
+	ctx := context.Background()
+	for _, mapper := range []string{"a", "b", "c", "c", "c"} {
+		if _, err := pad.EnsureAuthorID(ctx, mapper, mapper); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := pad.MapperCache.Len(); n != 2 {
+		t.Errorf("expected 2 cached entries, got %d", n)
+	}
+	if n := len(server.RequestsFor("createAuthorIfNotExistsFor")); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+}
+
+func TestMapperCacheZeroValue(t *testing.T) {
+	server, pad := newTestClient(t)
+	pad.MapperCache = &etherpadlite.MapperCache{}
+	ctx := context.Background()
+	first, err := pad.EnsureAuthorID(ctx, "user", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := pad.EnsureAuthorID(ctx, "user", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Errorf("expected cached author ID %s, got %s", first, second)
+	}
+	if n := len(server.RequestsFor("createAuthorIfNotExistsFor")); n != 1 {
+		t.Errorf("expected 1 request, got %d", n)
+	}
+	if n := pad.MapperCache.Len(); n != 1 {
+		t.Errorf("expected 1 cached entry, got %d", n)
+	}
+}
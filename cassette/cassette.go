@@ -0,0 +1,271 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cassette provides a http.RoundTripper that records requests to an
+// etherpad server and replays them later, so tests can run without network
+// access.
+//
+// In Record mode all requests are sent to the server and the interactions
+// are written to a JSON file (the cassette) by Stop. The API key is
+// redacted. Cassettes are always JSON, other formats such as YAML are not
+// supported. In Replay mode the responses are served from the cassette:
+//
+//	transport, err := cassette.New("testdata/pads.json", cassette.Replay, nil)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer transport.Stop()
+//	pad := etherpadlite.NewEtherpadLite("key")
+//	pad.Client = &http.Client{Transport: transport}
+//
+// Requests are matched on the HTTP method, the path and the query parameters
+// (independent of their order, the value of the API key is ignored). If the
+// same request was recorded multiple times the responses are replayed in the
+// recorded order. A request without a (remaining) recorded response fails
+// with an error wrapping ErrNoInteraction.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// Mode is the mode of a Transport.
+type Mode int
+
+const (
+	// Replay serves the responses from the cassette.
+	Replay Mode = iota
+
+	// Record sends the requests to the server and records them.
+	Record
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Replay:
+		return "replay"
+	case Record:
+		return "record"
+	default:
+		return fmt.Sprintf("Mode(%d)", int(m))
+	}
+}
+
+// ErrNoInteraction is returned in Replay mode if no recorded response matches
+// a request.
+var ErrNoInteraction = errors.New("no recorded interaction matches the request")
+
+// Request is a recorded request.
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+
+	// Query is the normalized query (parameters sorted by key) with the API
+	// key redacted.
+	Query string `json:"query"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Interaction is a recorded request with its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Load reads a cassette from a file.
+func Load(path string) (*Cassette, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(content, &c); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to a file.
+func (c *Cassette) Save(path string) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	// keep query strings and HTML readable
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// requestOf returns the recorded form of req.
+func requestOf(req *http.Request) Request {
+	query := req.URL.Query()
+	for key := range query {
+		if strings.EqualFold(key, "apikey") {
+			query.Set(key, etherpadlite.Redacted)
+		}
+	}
+	return Request{Method: req.Method, Path: req.URL.EscapedPath(), Query: query.Encode()}
+}
+
+// Transport is a recording or replaying http.RoundTripper, see the package
+// documentation. It is safe for concurrent use.
+type Transport struct {
+	// Mode is the mode of the transport.
+	Mode Mode
+
+	// Path is the path of the cassette file.
+	Path string
+
+	// Base is used to send requests in Record mode,
+	// http.DefaultTransport if nil.
+	Base http.RoundTripper
+
+	mutex    sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// New returns a new transport. In Replay mode the cassette is loaded from
+// path, in Record mode it is written to path by Stop.
+func New(path string, mode Mode, base http.RoundTripper) (*Transport, error) {
+	t := &Transport{Mode: mode, Path: path, Base: base, cassette: &Cassette{}}
+	if mode == Replay {
+		c, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		t.cassette = c
+		t.used = make([]bool, len(c.Interactions))
+	}
+	return t, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Mode == Record {
+		return t.record(req)
+	}
+	return t.replay(req)
+}
+
+// record sends req and records the interaction.
+func (t *Transport) record(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	interaction := Interaction{
+		Request:  requestOf(req),
+		Response: Response{Status: resp.StatusCode, Header: resp.Header.Clone(), Body: string(body)},
+	}
+	t.mutex.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, interaction)
+	t.mutex.Unlock()
+	return resp, nil
+}
+
+// replay returns the first unused recorded response matching req.
+func (t *Transport) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	recorded := requestOf(req)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for i, interaction := range t.cassette.Interactions {
+		if t.used[i] || interaction.Request != recorded {
+			continue
+		}
+		t.used[i] = true
+		r := interaction.Response
+		header := r.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+			StatusCode:    r.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader([]byte(r.Body))),
+			ContentLength: int64(len(r.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s?%s", ErrNoInteraction, recorded.Method, recorded.Path, recorded.Query)
+}
+
+// Unused returns the recorded interactions that were not replayed yet, tests
+// can use it to check that all expected requests were sent. In Record mode
+// it returns nil.
+func (t *Transport) Unused() []Interaction {
+	if t.Mode != Replay {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var res []Interaction
+	for i, interaction := range t.cassette.Interactions {
+		if !t.used[i] {
+			res = append(res, interaction)
+		}
+	}
+	return res
+}
+
+// Stop writes the cassette to Path in Record mode, in Replay mode it does
+// nothing.
+func (t *Transport) Stop() error {
+	if t.Mode != Record {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.cassette.Save(t.Path)
+}
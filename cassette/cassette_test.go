@@ -0,0 +1,210 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassette
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+const secretKey = "s3cr3t"
+
+// countingServer returns a server that answers each API call with the number
+// of calls of the method so far.
+func countingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	var (
+		mutex sync.Mutex
+		calls = make(map[string]int)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		calls[r.URL.Path]++
+		n := calls[r.URL.Path]
+		mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"code":0,"message":"ok","data":{"revisions":%d,"padID":%q}}`, n, r.URL.Query().Get("padID"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// revisions calls getRevisionsCount.
+func revisions(t *testing.T, pad *etherpadlite.EtherpadLite, padID string) int {
+	t.Helper()
+	n, err := pad.RevisionsCount(context.Background(), padID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRequestOf(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://host/api/1/getText?rev=2&apikey="+secretKey+"&padID=a%20b&APIKEY=other", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Request{Method: http.MethodGet, Path: "/api/1/getText", Query: "APIKEY=REDACTED&apikey=REDACTED&padID=a+b&rev=2"}
+	if got := requestOf(req); got != expected {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	server := countingServer(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, err := New(path, Record, server.Client().Transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pad := etherpadlite.NewEtherpadLite(secretKey)
+	pad.BaseURL = server.URL + "/api"
+	pad.Client = &http.Client{Transport: recorder}
+	for i := 1; i <= 2; i++ {
+		if n := revisions(t, pad, "pad"); n != i {
+			t.Fatalf("expected %d from the server, got %d", i, n)
+		}
+	}
+	revisions(t, pad, "other")
+	if err := recorder.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), secretKey) {
+		t.Errorf("the API key was recorded:\n%s", content)
+	}
+	server.Close()
+
+	// replay without a server and with a different API key
+	player, err := New(path, Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pad = etherpadlite.NewEtherpadLite("another key")
+	pad.BaseURL = server.URL + "/api"
+	pad.Client = &http.Client{Transport: player}
+	if n := len(player.Unused()); n != 3 {
+		t.Errorf("expected 3 unused interactions, got %d", n)
+	}
+	if n := revisions(t, pad, "other"); n != 3 {
+		t.Errorf("expected the recorded response 3 for pad other, got %d", n)
+	}
+	// identical requests are replayed in the recorded order
+	for i := 1; i <= 2; i++ {
+		if n := revisions(t, pad, "pad"); n != i {
+			t.Errorf("expected the recorded response %d, got %d", i, n)
+		}
+	}
+	if unused := player.Unused(); len(unused) != 0 {
+		t.Errorf("expected all interactions to be used, got %v", unused)
+	}
+	// requests without a remaining interaction fail
+	_, err = pad.RevisionsCount(context.Background(), "pad")
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("expected ErrNoInteraction for a request that was replayed already, got %v", err)
+	}
+	_, err = pad.GetTextContent(context.Background(), "pad")
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("expected ErrNoInteraction for a request that was never recorded, got %v", err)
+	}
+	if err := player.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if replayed, err := os.ReadFile(path); err != nil || string(replayed) != string(content) {
+		t.Errorf("expected Stop not to change the cassette in Replay mode (%v)", err)
+	}
+}
+
+func TestReplayQueryOrder(t *testing.T) {
+	c := &Cassette{Interactions: []Interaction{{
+		Request:  Request{Method: http.MethodGet, Path: "/api/1/getText", Query: "apikey=REDACTED&padID=pad&rev=1"},
+		Response: Response{Status: http.StatusOK, Body: "recorded"},
+	}}}
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	player, err := New(path, Replay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range []string{"rev=2&padID=pad&apikey=x", "padID=other&rev=1&apikey=x"} {
+		req, _ := http.NewRequest(http.MethodGet, "http://host/api/1/getText?"+query, nil)
+		if _, err := player.RoundTrip(req); !errors.Is(err, ErrNoInteraction) {
+			t.Errorf("%s: expected ErrNoInteraction, got %v", query, err)
+		}
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://host/api/1/getText?rev=1&apikey=x&padID=pad", nil)
+	resp, err := player.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ContentLength != int64(len("recorded")) {
+		t.Errorf("unexpected response %+v", resp)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	c := &Cassette{Interactions: []Interaction{
+		{
+			Request: Request{Method: http.MethodGet, Path: "/api/1.2.13/getText", Query: "apikey=REDACTED&padID=a%26b"},
+			Response: Response{
+				Status: http.StatusOK,
+				Header: http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+				Body:   `{"code":0,"message":"ok","data":{"text":"<b>&amp; ä\n"}}`,
+			},
+		},
+		{
+			Request:  Request{Method: http.MethodPost, Path: "/p/pad/import", Query: ""},
+			Response: Response{Status: http.StatusInternalServerError, Body: ""},
+		},
+	}}
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, c) {
+		t.Errorf("expected %+v, got %+v", c, loaded)
+	}
+
+	if err := os.WriteFile(path, []byte("interactions: []"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(path, Replay, nil); err == nil {
+		t.Error("expected an error for an invalid cassette")
+	}
+	if _, err := New(filepath.Join(t.TempDir(), "missing.json"), Replay, nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist for a missing cassette, got %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ChangesetOpType is the type of an operation in a changeset.
+type ChangesetOpType byte
+
+const (
+	// OpInsert inserts characters from the char bank.
+	OpInsert ChangesetOpType = '+'
+
+	// OpRemove removes characters.
+	OpRemove ChangesetOpType = '-'
+
+	// OpKeep keeps characters (possibly changing their attributes).
+	OpKeep ChangesetOpType = '='
+)
+
+// ChangesetOp is a single operation of a changeset.
+type ChangesetOp struct {
+	Type ChangesetOpType
+
+	// Chars is the number of characters the operation applies to.
+	Chars int
+
+	// Lines is the number of newlines among these characters.
+	Lines int
+
+	// Attribs are the numbers of the attributes (see AttributePool) applied
+	// by the operation.
+	Attribs []int
+}
+
+// Changeset is a parsed etherpad changeset, see
+// https://github.com/ether/etherpad-lite/blob/develop/doc/easysync/easysync-notes.txt
+//
+// Note that etherpad counts characters in UTF-16 code units, so all lengths
+// are given in UTF-16 code units as well.
+type Changeset struct {
+	// OldLen is the length of the document before the changeset is applied.
+	OldLen int
+
+	// NewLen is the length of the document after the changeset is applied.
+	NewLen int
+
+	// Ops are the operations of the changeset.
+	Ops []ChangesetOp
+
+	// CharBank contains the inserted characters.
+	CharBank string
+}
+
+// parseBase36 parses a non-negative number in base 36 as used by changesets.
+func parseBase36(s string) (int, error) {
+	n, err := strconv.ParseInt(s, 36, 0)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number %q in changeset", s)
+	}
+	return int(n), nil
+}
+
+// formatBase36 formats n in base 36.
+func formatBase36(n int) string {
+	return strconv.FormatInt(int64(n), 36)
+}
+
+// isBase36 returns true if b is a base 36 digit (lower case as etherpad uses).
+func isBase36(b byte) bool {
+	return ('0' <= b && b <= '9') || ('a' <= b && b <= 'z')
+}
+
+// readBase36 reads a base 36 number from s starting at pos, it returns the
+// number and the position after it.
+func readBase36(s string, pos int) (int, int, error) {
+	end := pos
+	for end < len(s) && isBase36(s[end]) {
+		end++
+	}
+	if end == pos {
+		return 0, pos, fmt.Errorf("expected number at position %d in changeset", pos)
+	}
+	n, err := parseBase36(s[pos:end])
+	return n, end, err
+}
+
+// ParseChangeset parses a changeset as returned by getRevisionChangeset, for
+// example "Z:1>6b|5+6b$Welcome to Etherpad!...".
+// It checks that the lengths of the operations are consistent with the header
+// and with the char bank.
+func ParseChangeset(cs string) (*Changeset, error) {
+	if !strings.HasPrefix(cs, "Z:") {
+		return nil, fmt.Errorf("changeset %q doesn't start with Z:", cs)
+	}
+	dollar := strings.IndexByte(cs, '$')
+	if dollar < 0 {
+		return nil, fmt.Errorf("changeset %q has no char bank", cs)
+	}
+	header, charBank := cs[:dollar], cs[dollar+1:]
+	res := &Changeset{CharBank: charBank}
+	oldLen, pos, err := readBase36(header, 2)
+	if err != nil {
+		return nil, err
+	}
+	res.OldLen = oldLen
+	if pos >= len(header) || (header[pos] != '>' && header[pos] != '<') {
+		return nil, fmt.Errorf("expected > or < at position %d in changeset", pos)
+	}
+	sign := header[pos]
+	diff, pos, err := readBase36(header, pos+1)
+	if err != nil {
+		return nil, err
+	}
+	if sign == '>' {
+		res.NewLen = oldLen + diff
+	} else {
+		res.NewLen = oldLen - diff
+	}
+	var op ChangesetOp
+	computedLen, inserted := oldLen, 0
+	for pos < len(header) {
+		opChar := header[pos]
+		n, next, err := readBase36(header, pos+1)
+		if err != nil {
+			return nil, err
+		}
+		pos = next
+		switch opChar {
+		case '*':
+			op.Attribs = append(op.Attribs, n)
+		case '|':
+			op.Lines = n
+		case '+', '-', '=':
+			op.Type = ChangesetOpType(opChar)
+			op.Chars = n
+			switch op.Type {
+			case OpInsert:
+				computedLen += n
+				inserted += n
+			case OpRemove:
+				computedLen -= n
+			}
+			res.Ops = append(res.Ops, op)
+			op = ChangesetOp{}
+		default:
+			return nil, fmt.Errorf("unknown operation %q in changeset", opChar)
+		}
+	}
+	if op.Lines != 0 || len(op.Attribs) != 0 {
+		return nil, fmt.Errorf("changeset ends with an incomplete operation")
+	}
+	if computedLen != res.NewLen {
+		return nil, fmt.Errorf("changeset operations result in length %d, header says %d", computedLen, res.NewLen)
+	}
+	if bankLen := len(utf16.Encode([]rune(charBank))); bankLen != inserted {
+		return nil, fmt.Errorf("changeset inserts %d characters but char bank has %d", inserted, bankLen)
+	}
+	return res, nil
+}
+
+// String returns the changeset in etherpad's packed format, it is the inverse
+// of ParseChangeset.
+func (c *Changeset) String() string {
+	var b strings.Builder
+	b.WriteString("Z:")
+	b.WriteString(formatBase36(c.OldLen))
+	if c.NewLen >= c.OldLen {
+		b.WriteByte('>')
+		b.WriteString(formatBase36(c.NewLen - c.OldLen))
+	} else {
+		b.WriteByte('<')
+		b.WriteString(formatBase36(c.OldLen - c.NewLen))
+	}
+	for _, op := range c.Ops {
+		for _, attrib := range op.Attribs {
+			b.WriteByte('*')
+			b.WriteString(formatBase36(attrib))
+		}
+		if op.Lines > 0 {
+			b.WriteByte('|')
+			b.WriteString(formatBase36(op.Lines))
+		}
+		b.WriteByte(byte(op.Type))
+		b.WriteString(formatBase36(op.Chars))
+	}
+	b.WriteByte('$')
+	b.WriteString(c.CharBank)
+	return b.String()
+}
+
+// RevisionChangeset returns the changeset of a revision of a pad in
+// etherpad's packed format, it can be parsed with ParseChangeset.
+func (pad *EtherpadLite) RevisionChangeset(ctx context.Context, padID string, rev int) (string, error) {
+	resp, err := pad.callChecked(ctx, "getRevisionChangeset", map[string]interface{}{"padID": padID, "rev": rev})
+	if err != nil {
+		return "", err
+	}
+	var cs string
+	if err := resp.DecodeData(&cs); err != nil {
+		return "", fmt.Errorf("invalid changeset in response: %w", err)
+	}
+	return cs, nil
+}
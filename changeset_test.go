@@ -0,0 +1,152 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseChangeset(t *testing.T) {
+	tests := []struct {
+		name     string
+		cs       string
+		expected Changeset
+	}{
+		{
+			"type into empty pad",
+			"Z:1>5*0+5$Hello",
+			Changeset{OldLen: 1, NewLen: 6, Ops: []ChangesetOp{
+				{Type: OpInsert, Chars: 5, Attribs: []int{0}},
+			}, CharBank: "Hello"},
+		},
+		{
+			"append at end of line",
+			"Z:6>1=5*0+1$!",
+			Changeset{OldLen: 6, NewLen: 7, Ops: []ChangesetOp{
+				{Type: OpKeep, Chars: 5},
+				{Type: OpInsert, Chars: 1, Attribs: []int{0}},
+			}, CharBank: "!"},
+		},
+		{
+			"delete",
+			"Z:7<2=3-2$",
+			Changeset{OldLen: 7, NewLen: 5, Ops: []ChangesetOp{
+				{Type: OpKeep, Chars: 3},
+				{Type: OpRemove, Chars: 2},
+			}},
+		},
+		{
+			"insert lines",
+			"Z:1>b*0|2+8*0+3$foo\nbar\nbaz",
+			Changeset{OldLen: 1, NewLen: 12, Ops: []ChangesetOp{
+				{Type: OpInsert, Chars: 8, Lines: 2, Attribs: []int{0}},
+				{Type: OpInsert, Chars: 3, Attribs: []int{0}},
+			}, CharBank: "foo\nbar\nbaz"},
+		},
+		{
+			"make bold",
+			"Z:c>0=2*1*2=5$",
+			Changeset{OldLen: 12, NewLen: 12, Ops: []ChangesetOp{
+				{Type: OpKeep, Chars: 2},
+				{Type: OpKeep, Chars: 5, Attribs: []int{1, 2}},
+			}},
+		},
+		{
+			"keep lines then insert",
+			"Z:k>3|2=a=2*0+3$abc",
+			Changeset{OldLen: 20, NewLen: 23, Ops: []ChangesetOp{
+				{Type: OpKeep, Chars: 10, Lines: 2},
+				{Type: OpKeep, Chars: 2},
+				{Type: OpInsert, Chars: 3, Attribs: []int{0}},
+			}, CharBank: "abc"},
+		},
+		{
+			// etherpad counts UTF-16 code units, the emoji has two
+			"surrogate pair",
+			"Z:1>3*0+3$a😀",
+			Changeset{OldLen: 1, NewLen: 4, Ops: []ChangesetOp{
+				{Type: OpInsert, Chars: 3, Attribs: []int{0}},
+			}, CharBank: "a😀"},
+		},
+		{
+			"clear pad",
+			"Z:6c<6b|5-6b$",
+			Changeset{OldLen: 228, NewLen: 1, Ops: []ChangesetOp{
+				{Type: OpRemove, Chars: 227, Lines: 5},
+			}},
+		},
+		{
+			"attribute above 9",
+			"Z:3>0*a*1b=2$",
+			Changeset{OldLen: 3, NewLen: 3, Ops: []ChangesetOp{
+				{Type: OpKeep, Chars: 2, Attribs: []int{10, 47}},
+			}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cs, err := ParseChangeset(test.cs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*cs, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, *cs)
+			}
+			if got := cs.String(); got != test.cs {
+				t.Errorf("expected round trip to return %q, got %q", test.cs, got)
+			}
+		})
+	}
+}
+
+func TestParseChangesetInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cs   string
+	}{
+		{"empty", ""},
+		{"no prefix", "1>5+5$Hello"},
+		{"no char bank", "Z:1>5+5"},
+		{"no sign", "Z:15+5$Hello"},
+		{"no length", "Z:>5+5$Hello"},
+		{"wrong new length", "Z:1>4+5$Hello"},
+		{"char bank too short", "Z:1>5+5$Hell"},
+		{"char bank too long", "Z:1>5+5$Hello!"},
+		{"unknown operation", "Z:1>5?1+5$Hello"},
+		{"operation without number", "Z:1>5+$Hello"},
+		{"incomplete operation", "Z:1>5+5*0$Hello"},
+		{"upper case number", "Z:1>5+5=A$Hello"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if cs, err := ParseChangeset(test.cs); err == nil {
+				t.Errorf("expected an error parsing %q, got %+v", test.cs, cs)
+			}
+		})
+	}
+}
+
+func TestRevisionChangeset(t *testing.T) {
+	pad := serveBody(t, `{"code":0,"message":"ok","data":"Z:1>5*0+5$Hello"}`)
+	cs, err := pad.RevisionChangeset(context.Background(), "pad", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs != "Z:1>5*0+5$Hello" {
+		t.Errorf("expected changeset Z:1>5*0+5$Hello, got %q", cs)
+	}
+}
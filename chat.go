@@ -0,0 +1,323 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ChatMessage is a message in the chat of a pad.
+type ChatMessage struct {
+	Text     string
+	AuthorID string
+	Time     time.Time
+
+	// UserName is the name of the author, empty for anonymous users.
+	UserName string
+}
+
+// chatMessageJSON is the JSON representation of a chat message.
+type chatMessageJSON struct {
+	Text     string      `json:"text"`
+	UserID   string      `json:"userId"`
+	Time     json.Number `json:"time"`
+	UserName *string     `json:"userName"`
+}
+
+// UnmarshalJSON decodes a chat message as returned by getChatHistory.
+// The time is given in milliseconds since the Unix epoch.
+func (m *ChatMessage) UnmarshalJSON(data []byte) error {
+	var msg chatMessageJSON
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	*m = ChatMessage{Text: msg.Text, AuthorID: msg.UserID}
+	if msg.UserName != nil {
+		m.UserName = *msg.UserName
+	}
+	if msg.Time != "" {
+		millis, err := AsInt64(msg.Time)
+		if err != nil {
+			return fmt.Errorf("invalid time of chat message: %w", err)
+		}
+		m.Time = millisToTime(millis)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the message in the format returned by getChatHistory,
+// so the result can be decoded by UnmarshalJSON.
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	msg := chatMessageJSON{
+		Text:   m.Text,
+		UserID: m.AuthorID,
+		Time:   json.Number(strconv.FormatInt(m.Time.UnixNano()/int64(time.Millisecond), 10)),
+	}
+	if m.UserName != "" {
+		msg.UserName = &m.UserName
+	}
+	return json.Marshal(msg)
+}
+
+// chatHistory calls getChatHistory with the given parameters and decodes the
+// messages.
+func (pad *EtherpadLite) chatHistory(ctx context.Context, params map[string]interface{}) ([]ChatMessage, error) {
+	resp, err := pad.callChecked(ctx, "getChatHistory", params)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := resp.field("messages"); err != nil {
+		return nil, err
+	}
+	var data struct {
+		Messages []ChatMessage `json:"messages"`
+	}
+	if err := resp.DecodeData(&data); err != nil {
+		return nil, err
+	}
+	if data.Messages == nil {
+		return []ChatMessage{}, nil
+	}
+	return data.Messages, nil
+}
+
+// ChatHistory returns the chat messages of a pad from start to end
+// (both inclusive).
+func (pad *EtherpadLite) ChatHistory(ctx context.Context, padID string, start, end int) ([]ChatMessage, error) {
+	return pad.chatHistory(ctx, map[string]interface{}{"padID": padID, "start": start, "end": end})
+}
+
+// FullChatHistory returns all chat messages of a pad.
+func (pad *EtherpadLite) FullChatHistory(ctx context.Context, padID string) ([]ChatMessage, error) {
+	return pad.chatHistory(ctx, map[string]interface{}{"padID": padID})
+}
+
+// ChatHead returns the index of the last chat message of a pad, -1 if there
+// are no messages.
+func (pad *EtherpadLite) ChatHead(ctx context.Context, padID string) (int, error) {
+	resp, err := pad.callChecked(ctx, "getChatHead", map[string]interface{}{"padID": padID})
+	if err != nil {
+		return 0, err
+	}
+	return resp.getInt("chatHead")
+}
+
+// chatMessageKey identifies a chat message independent of its index.
+type chatMessageKey struct {
+	text     string
+	authorID string
+	time     int64
+}
+
+func (m *ChatMessage) key() chatMessageKey {
+	return chatMessageKey{text: m.Text, authorID: m.AuthorID, time: m.Time.UnixNano()}
+}
+
+// TailChat polls the chat of a pad every interval and sends new messages (in
+// order) on the returned message channel, messages that existed when TailChat
+// was called are not sent.
+// Only the new range of messages is fetched once getChatHead advances,
+// together with the last message that was already sent. If the head goes
+// backwards or this message changed (the pad was deleted and recreated)
+// tailing restarts at the first message. A recreated pad is only detected
+// once it has more messages than before and its message at the old head
+// differs in text, author or time from the one sent.
+//
+// Errors (for example network errors) don't stop tailing, they are sent on the
+// error channel. The error channel has a buffer of one, if the previous error
+// has not been received new errors are dropped.
+// Both channels are closed once ctx is done.
+func (pad *EtherpadLite) TailChat(ctx context.Context, padID string, interval time.Duration) (<-chan ChatMessage, <-chan error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	messages := make(chan ChatMessage)
+	errs := make(chan error, 1)
+	sendErr := func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+	go func() {
+		defer close(messages)
+		defer close(errs)
+		ticker := pad.clock().NewTicker(interval)
+		defer ticker.Stop()
+		// next is the index of the next message to send, -1 until the head
+		// was read the first time. last is the message at next-1 if it was
+		// sent.
+		next := -1
+		var last *chatMessageKey
+		for {
+			head, err := pad.ChatHead(ctx, padID)
+			if err == nil && next >= 0 && head < next-1 {
+				// the pad was recreated
+				next, last = 0, nil
+			}
+			switch {
+			case err != nil:
+				if ctx.Err() == nil {
+					sendErr(err)
+				}
+			case next < 0:
+				next = head + 1
+			case head >= next:
+				history, err := pad.newChatMessages(ctx, padID, next, head, last)
+				if err != nil {
+					if ctx.Err() == nil {
+						sendErr(err)
+					}
+					break
+				}
+				for _, msg := range history {
+					select {
+					case messages <- msg:
+					case <-ctx.Done():
+						return
+					}
+				}
+				if len(history) > 0 {
+					key := history[len(history)-1].key()
+					last = &key
+				}
+				next = head + 1
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+			}
+		}
+	}()
+	return messages, errs
+}
+
+// newChatMessages returns the messages from next to head for TailChat. last
+// is the message at next-1 that was sent before (nil if unknown), if it
+// changed the pad was recreated and all messages up to head are returned.
+func (pad *EtherpadLite) newChatMessages(ctx context.Context, padID string, next, head int, last *chatMessageKey) ([]ChatMessage, error) {
+	if last == nil {
+		return pad.ChatHistory(ctx, padID, next, head)
+	}
+	history, err := pad.ChatHistory(ctx, padID, next-1, head)
+	if err != nil {
+		return nil, err
+	}
+	if len(history) > 0 && history[0].key() == *last {
+		return history[1:], nil
+	}
+	return pad.ChatHistory(ctx, padID, 0, head)
+}
+
+// DefaultChatPageSize is the page size used by ChatHistoryPages if no page
+// size is given.
+const DefaultChatPageSize = 100
+
+// ChatPager iterates over the chat history of a pad page by page, see
+// ChatHistoryPages.
+type ChatPager struct {
+	pad      *EtherpadLite
+	ctx      context.Context
+	padID    string
+	pageSize int
+
+	// head is the index of the last message, read on the first call to Next
+	head    int
+	started bool
+	start   int
+	page    []ChatMessage
+	err     error
+}
+
+// ChatHistoryPages returns a pager over the chat history of a pad, each page
+// contains at most pageSize messages (DefaultChatPageSize if <= 0).
+// The head of the chat is read on the first call to Next, messages added
+// later are not returned. Use it like this:
+//
+//	pager := pad.ChatHistoryPages(ctx, padID, 100)
+//	for pager.Next() {
+//		for _, msg := range pager.Page() {
+//			...
+//		}
+//	}
+//	if err := pager.Err(); err != nil {
+//		...
+//	}
+func (pad *EtherpadLite) ChatHistoryPages(ctx context.Context, padID string, pageSize int) *ChatPager {
+	if pageSize <= 0 {
+		pageSize = DefaultChatPageSize
+	}
+	return &ChatPager{pad: pad, ctx: ctx, padID: padID, pageSize: pageSize}
+}
+
+// Next fetches the next page, it returns false if there are no more messages
+// or an error occurred (see Err).
+func (p *ChatPager) Next() bool {
+	if p.err != nil {
+		return false
+	}
+	if !p.started {
+		p.started = true
+		if p.head, p.err = p.pad.ChatHead(p.ctx, p.padID); p.err != nil {
+			return false
+		}
+	}
+	if p.start > p.head {
+		p.page = nil
+		return false
+	}
+	// the end index is inclusive
+	end := p.start + p.pageSize - 1
+	if end > p.head {
+		end = p.head
+	}
+	if p.page, p.err = p.pad.ChatHistory(p.ctx, p.padID, p.start, end); p.err != nil {
+		p.page = nil
+		return false
+	}
+	p.start = end + 1
+	return true
+}
+
+// Page returns the messages of the current page.
+func (p *ChatPager) Page() []ChatMessage {
+	return p.page
+}
+
+// Err returns the error that stopped the iteration, nil if all pages were
+// read.
+func (p *ChatPager) Err() error {
+	return p.err
+}
+
+// ForEachChatMessage calls fn for each chat message of a pad in order,
+// fetching pageSize messages per request (see ChatHistoryPages). It stops at
+// the first error returned by fn and returns it.
+func (pad *EtherpadLite) ForEachChatMessage(ctx context.Context, padID string, pageSize int, fn func(msg ChatMessage) error) error {
+	pager := pad.ChatHistoryPages(ctx, padID, pageSize)
+	for pager.Next() {
+		for _, msg := range pager.Page() {
+			if err := fn(msg); err != nil {
+				return err
+			}
+		}
+	}
+	return pager.Err()
+}
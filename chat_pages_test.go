@@ -0,0 +1,134 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+func TestChatHistoryPages(t *testing.T) {
+	tests := []struct {
+		messages, pageSize int
+		pages              []int
+	}{
+		{0, 3, nil},
+		{1, 3, []int{1}},
+		{2, 3, []int{2}},
+		{3, 3, []int{3}},
+		{4, 3, []int{3, 1}},
+		{6, 3, []int{3, 3}},
+		{7, 3, []int{3, 3, 1}},
+		{3, 1, []int{1, 1, 1}},
+		{2, 0, []int{2}},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d messages page size %d", test.messages, test.pageSize), func(t *testing.T) {
+			server, pad := newTestClient(t)
+			ctx := context.Background()
+			if err := server.Store.AddPad("pad", "text"); err != nil {
+				t.Fatal(err)
+			}
+			authorID := server.Store.AddAuthor("author", "Alice")
+			for i := 0; i < test.messages; i++ {
+				if err := server.Store.AddChatMessage("pad", authorID, fmt.Sprintf("message %d", i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var pages []int
+			next := 0
+			pager := pad.ChatHistoryPages(ctx, "pad", test.pageSize)
+			for pager.Next() {
+				pages = append(pages, len(pager.Page()))
+				for _, msg := range pager.Page() {
+					if expected := fmt.Sprintf("message %d", next); msg.Text != expected {
+						t.Errorf("expected %q, got %q", expected, msg.Text)
+					}
+					next++
+				}
+			}
+			if err := pager.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if next != test.messages {
+				t.Errorf("expected %d messages, got %d", test.messages, next)
+			}
+			if fmt.Sprint(pages) != fmt.Sprint(test.pages) {
+				t.Errorf("expected pages %v, got %v", test.pages, pages)
+			}
+			// the windows must be adjacent, the end index is inclusive
+			start := 0
+			for _, req := range server.RequestsFor("getChatHistory") {
+				if got := req.Params.Get("start"); got != fmt.Sprint(start) {
+					t.Errorf("expected start %d, got %s", start, got)
+				}
+				var end int
+				fmt.Sscan(req.Params.Get("end"), &end)
+				if end < start || end >= test.messages {
+					t.Errorf("invalid window [%d, %d] for %d messages", start, end, test.messages)
+				}
+				start = end + 1
+			}
+			if pager.Next() {
+				t.Error("expected Next to return false after the last page")
+			}
+		})
+	}
+}
+
+func TestForEachChatMessage(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	if err := server.Store.AddPad("pad", "text"); err != nil {
+		t.Fatal(err)
+	}
+	authorID := server.Store.AddAuthor("author", "Alice")
+	for i := 0; i < 5; i++ {
+		if err := server.Store.AddChatMessage("pad", authorID, fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var texts []string
+	err := pad.ForEachChatMessage(ctx, "pad", 2, func(msg etherpadlite.ChatMessage) error {
+		texts = append(texts, msg.Text)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(texts) != "[0 1 2 3 4]" {
+		t.Errorf("expected messages [0 1 2 3 4], got %v", texts)
+	}
+	stop := errors.New("stop")
+	calls := 0
+	err = pad.ForEachChatMessage(ctx, "pad", 2, func(msg etherpadlite.ChatMessage) error {
+		calls++
+		if msg.Text == "2" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || calls != 3 {
+		t.Errorf("expected to stop after 3 messages with the error of fn, got %d calls and %v", calls, err)
+	}
+	pager := pad.ChatHistoryPages(ctx, "missing", 2)
+	if pager.Next() || pager.Err() == nil {
+		t.Error("expected an error for a pad that doesn't exist")
+	}
+}
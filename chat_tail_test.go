@@ -0,0 +1,129 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+	"github.com/FabianWe/etherpadlite-golang/mock"
+)
+
+// headTransport signals on heads whenever a getChatHead request was answered.
+type headTransport struct {
+	base  http.RoundTripper
+	heads chan struct{}
+}
+
+func (t headTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if strings.HasSuffix(req.URL.Path, "/getChatHead") {
+		t.heads <- struct{}{}
+	}
+	return resp, err
+}
+
+func TestTailChat(t *testing.T) {
+	m := mock.New()
+	// all messages get the same time, so identical messages can't be told
+	// apart by their content
+	m.Store.Clock = etherpadtest.NewFakeClock(time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC))
+	heads := make(chan struct{}, 10)
+	m.Client = &http.Client{Transport: headTransport{base: mock.Transport(m.Store), heads: heads}}
+	clock := etherpadtest.NewFakeClock(time.Now())
+	m.Clock = clock
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := m.Store.AddPad("pad", "text"); err != nil {
+		t.Fatal(err)
+	}
+	authorID := m.Store.AddAuthor("author", "Alice")
+	add := func(texts ...string) {
+		t.Helper()
+		for _, text := range texts {
+			if err := m.Store.AddChatMessage("pad", authorID, text); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	waitForHead := func() {
+		t.Helper()
+		select {
+		case <-heads:
+		case <-time.After(testTimeout):
+			t.Fatal("getChatHead was not called")
+		}
+	}
+	add("old")
+	messages, errs := m.TailChat(ctx, "pad", time.Minute)
+	waitForHead()
+	// poll sends the next messages and checks that they are the expected ones
+	poll := func(expected ...string) {
+		t.Helper()
+		clock.Advance(time.Minute)
+		waitForHead()
+		for _, text := range expected {
+			select {
+			case msg := <-messages:
+				if msg.Text != text {
+					t.Errorf("expected message %q, got %q", text, msg.Text)
+				}
+			case err := <-errs:
+				t.Fatal(err)
+			case <-time.After(testTimeout):
+				t.Fatalf("message %q was not sent", text)
+			}
+		}
+	}
+
+	add("ok")
+	poll("ok")
+	// the same message again is a new message
+	add("ok", "ok")
+	poll("ok", "ok")
+	poll()
+
+	// a recreated pad with more messages than before is tailed from the start
+	if _, err := m.DeletePad(ctx, "pad"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Store.AddPad("pad", "text"); err != nil {
+		t.Fatal(err)
+	}
+	add("a", "b", "c", "d", "e")
+	poll("a", "b", "c", "d", "e")
+	add("f")
+	poll("f")
+
+	// a recreated pad with fewer messages
+	if _, err := m.DeletePad(ctx, "pad"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Store.AddPad("pad", "text"); err != nil {
+		t.Fatal(err)
+	}
+	add("x")
+	poll("x")
+
+	cancel()
+	for msg := range messages {
+		t.Errorf("unexpected message %q", msg.Text)
+	}
+}
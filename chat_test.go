@@ -0,0 +1,148 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestChatMessageUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		expected ChatMessage
+	}{
+		{
+			"named user",
+			`{"text": "hi", "userId": "a.1", "time": 1551675967890, "userName": "Alice"}`,
+			ChatMessage{Text: "hi", AuthorID: "a.1", Time: time.Date(2019, 3, 4, 5, 6, 7, 890*int(time.Millisecond), time.UTC), UserName: "Alice"},
+		},
+		{
+			"anonymous user",
+			`{"text": "hi", "userId": "a.1", "time": 1551675967000, "userName": null}`,
+			ChatMessage{Text: "hi", AuthorID: "a.1", Time: time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)},
+		},
+		{
+			"missing user name",
+			`{"text": "hi", "userId": "a.1", "time": 1}`,
+			ChatMessage{Text: "hi", AuthorID: "a.1", Time: time.Date(1970, 1, 1, 0, 0, 0, int(time.Millisecond), time.UTC)},
+		},
+		{
+			"missing time",
+			`{"text": "hi", "userId": "a.1"}`,
+			ChatMessage{Text: "hi", AuthorID: "a.1"},
+		},
+		{
+			"time above 2^53",
+			`{"text": "", "userId": "a.1", "time": 9007199254740993}`,
+			ChatMessage{AuthorID: "a.1", Time: millisToTime(9007199254740993)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var msg ChatMessage
+			if err := json.Unmarshal([]byte(test.json), &msg); err != nil {
+				t.Fatal(err)
+			}
+			if !msg.Time.Equal(test.expected.Time) {
+				t.Errorf("expected time %v, got %v", test.expected.Time, msg.Time)
+			}
+			msg.Time = test.expected.Time
+			if msg != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, msg)
+			}
+		})
+	}
+}
+
+func TestChatMessageUnmarshalInvalidTime(t *testing.T) {
+	var msg ChatMessage
+	if err := json.Unmarshal([]byte(`{"text": "hi", "time": 1.5}`), &msg); err == nil {
+		t.Errorf("expected an error for a fractional time, got %+v", msg)
+	}
+}
+
+func TestChatMessageMarshal(t *testing.T) {
+	messages := []ChatMessage{
+		{Text: "hi", AuthorID: "a.1", Time: time.Date(2019, 3, 4, 5, 6, 7, 890*int(time.Millisecond), time.UTC), UserName: "Alice"},
+		{Text: "anonymous", AuthorID: "a.2", Time: time.Date(2019, 3, 4, 5, 6, 8, 0, time.UTC)},
+	}
+	for _, msg := range messages {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded ChatMessage
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded != msg {
+			t.Errorf("expected %+v after round trip, got %+v (%s)", msg, decoded, data)
+		}
+	}
+}
+
+func TestChatHistoryParams(t *testing.T) {
+	queries := make(chan url.Values, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.Query()
+		io.WriteString(w, `{"code": 0, "message": "ok", "data": {"messages": [{"text": "hi", "userId": "a.1", "time": 0, "userName": null}]}}`)
+	}))
+	defer server.Close()
+	pad := NewEtherpadLite("key")
+	pad.BaseURL = server.URL + "/api"
+	ctx := context.Background()
+
+	messages, err := pad.ChatHistory(ctx, "pad", 3, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].Text != "hi" || messages[0].UserName != "" {
+		t.Errorf("unexpected messages %+v", messages)
+	}
+	query := <-queries
+	if query.Get("start") != "3" || query.Get("end") != "7" {
+		t.Errorf("expected start 3 and end 7, got %v", query)
+	}
+
+	if _, err := pad.FullChatHistory(ctx, "pad"); err != nil {
+		t.Fatal(err)
+	}
+	query = <-queries
+	if _, has := query["start"]; has {
+		t.Errorf("expected FullChatHistory not to send start, got %v", query)
+	}
+	if _, has := query["end"]; has {
+		t.Errorf("expected FullChatHistory not to send end, got %v", query)
+	}
+}
+
+func TestChatHistoryEmpty(t *testing.T) {
+	pad := serveBody(t, `{"code": 0, "message": "ok", "data": {"messages": null}}`)
+	messages, err := pad.FullChatHistory(context.Background(), "pad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messages == nil || len(messages) != 0 {
+		t.Errorf("expected an empty non-nil slice, got %#v", messages)
+	}
+}
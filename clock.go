@@ -0,0 +1,112 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the source of time used by EtherpadLite and the helper types, for
+// example for session expiry, retries, the circuit breaker, cache TTLs and
+// polling. The default is RealClock, tests can use a fake clock (see package
+// etherpadtest) to control time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer returns a timer that fires once after d.
+	NewTimer(d time.Duration) Timer
+
+	// NewTicker returns a ticker that fires every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a timer created by a Clock, see time.Timer.
+type Timer interface {
+	// C returns the channel the time is sent on.
+	C() <-chan time.Time
+
+	// Stop stops the timer, see time.Timer.Stop.
+	Stop() bool
+}
+
+// Ticker is a ticker created by a Clock, see time.Ticker.
+type Ticker interface {
+	// C returns the channel the ticks are sent on.
+	C() <-chan time.Time
+
+	// Stop stops the ticker.
+	Stop()
+}
+
+// RealClock is the Clock using the functions of package time.
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// clockOrReal returns c, RealClock if c is nil.
+func clockOrReal(c Clock) Clock {
+	if c == nil {
+		return RealClock
+	}
+	return c
+}
+
+// clock returns the Clock of pad.
+func (pad *EtherpadLite) clock() Clock {
+	return clockOrReal(pad.Clock)
+}
+
+// sleepContext waits for the duration d on clock or until ctx is done,
+// whatever happens first. It returns ctx.Err() if the context was done.
+func sleepContext(ctx context.Context, clock Clock, d time.Duration) error {
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
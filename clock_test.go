@@ -0,0 +1,225 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+	"github.com/FabianWe/etherpadlite-golang/mock"
+)
+
+// testTimeout is the time we wait for a goroutine that should return as soon
+// as the fake clock is advanced.
+const testTimeout = 5 * time.Second
+
+func TestRetryFakeClock(t *testing.T) {
+	server, pad := newTestClient(t)
+	clock := etherpadtest.NewFakeClock(time.Now())
+	pad.Clock = clock
+	pad.MaxRetries = 1
+	server.FailNext("checkToken", etherpadtest.Failure{Status: http.StatusTooManyRequests})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := pad.CheckToken(context.Background())
+		done <- err
+	}()
+	// the client waits one second (no Retry-After header) before retrying
+	clock.BlockUntil(1)
+	if n := len(server.RequestsFor("checkToken")); n != 1 {
+		t.Fatalf("expected 1 request before the clock advances, got %d", n)
+	}
+	clock.Advance(time.Second)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("retry did not happen after advancing the clock")
+	}
+	if n := len(server.RequestsFor("checkToken")); n != 2 {
+		t.Errorf("expected 2 requests, got %d", n)
+	}
+}
+
+func TestCircuitBreakerFakeClock(t *testing.T) {
+	server, pad := newTestClient(t)
+	clock := etherpadtest.NewFakeClock(time.Now())
+	pad.Clock = clock
+	pad.CircuitBreakerThreshold = 2
+	pad.CircuitBreakerTimeout = time.Minute
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		server.FailNext("checkToken", etherpadtest.Failure{Status: http.StatusInternalServerError})
+		if _, err := pad.CheckToken(ctx); err == nil {
+			t.Fatal("expected an error for HTTP 500")
+		}
+	}
+
+	if _, err := pad.CheckToken(ctx); !errors.Is(err, etherpadlite.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	clock.Advance(time.Minute - time.Millisecond)
+	if _, err := pad.CheckToken(ctx); !errors.Is(err, etherpadlite.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen before the timeout, got %v", err)
+	}
+	if n := len(server.RequestsFor("checkToken")); n != 2 {
+		t.Errorf("expected no requests while the breaker is open, got %d", n-2)
+	}
+
+	clock.Advance(time.Millisecond)
+	if _, err := pad.CheckToken(ctx); err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+	if _, err := pad.CheckToken(ctx); err != nil {
+		t.Errorf("expected the breaker to be closed, got %v", err)
+	}
+}
+
+func TestHealthCheckFakeClock(t *testing.T) {
+	_, pad := newTestClient(t)
+	now := time.Date(2019, 5, 1, 12, 0, 0, 0, time.UTC)
+	pad.Clock = etherpadtest.NewFakeClock(now)
+	health, err := pad.HealthCheck(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !health.Healthy() || !health.Checked.Equal(now) || health.Latency != 0 {
+		t.Errorf("unexpected health %+v", health)
+	}
+}
+
+func TestMonitorHealthFakeClock(t *testing.T) {
+	server, pad := newTestClient(t)
+	clock := etherpadtest.NewFakeClock(time.Now())
+	pad.Clock = clock
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type change struct {
+		old, current *etherpadlite.Health
+	}
+	changes := make(chan change, 10)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		pad.MonitorHealth(ctx, time.Minute, func(old, current *etherpadlite.Health, err error) {
+			changes <- change{old, current}
+		})
+	}()
+	next := func() change {
+		t.Helper()
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(testTimeout):
+			t.Fatal("fn was not called")
+			return change{}
+		}
+	}
+
+	if c := next(); c.old != nil || !c.current.Healthy() {
+		t.Fatalf("unexpected first check: %+v", c)
+	}
+	clock.BlockUntil(1)
+	server.FailNext("checkToken", etherpadtest.Failure{Code: etherpadlite.WrongAPIKey, Message: "no or wrong API Key"})
+	clock.Advance(time.Minute)
+	if c := next(); !c.old.Healthy() || !c.current.Reachable || c.current.APIKeyValid {
+		t.Fatalf("expected the API key to become invalid, got %+v -> %+v", c.old, c.current)
+	}
+	clock.Advance(time.Minute)
+	if c := next(); !c.current.Healthy() {
+		t.Fatalf("expected the server to become healthy again, got %+v", c.current)
+	}
+	// without a change fn must not be called
+	clock.Advance(time.Minute)
+	select {
+	case c := <-changes:
+		t.Errorf("unexpected call without a change: %+v -> %+v", c.old, c.current)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(testTimeout):
+		t.Fatal("MonitorHealth did not return after cancel")
+	}
+}
+
+func TestNewPoolClock(t *testing.T) {
+	server := etherpadtest.NewServer(t)
+	pad := server.Client()
+	clock := etherpadtest.NewFakeClock(time.Now())
+	pad.Clock = clock
+	pool, err := etherpadlite.NewPool(pad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pool.Clock != clock {
+		t.Errorf("expected the pool to use the clock of the first instance, got %v", pool.Clock)
+	}
+}
+
+func TestPoolRunFakeClock(t *testing.T) {
+	store := mock.NewStore()
+	primary := etherpadtest.NewServer(t, etherpadtest.WithStore(store))
+	secondary := etherpadtest.NewServer(t, etherpadtest.WithStore(store))
+	clock := etherpadtest.NewFakeClock(time.Now())
+	pad := primary.Client()
+	pad.Clock = clock
+	pool, err := etherpadlite.NewPool(pad, secondary.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.RaiseEtherpadErrors = true
+	pool.ProbeInterval = time.Minute
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for i := 0; i < etherpadlite.DefaultPoolFailureThreshold; i++ {
+		primary.FailNext("checkToken", etherpadtest.Failure{Status: http.StatusInternalServerError})
+		if _, err := pool.CheckToken(ctx); err != nil {
+			t.Fatalf("expected failover to the secondary backend, got %v", err)
+		}
+	}
+	if healthy := pool.Healthy(); len(healthy) != 1 || healthy[0] != secondary.BaseURL() {
+		t.Fatalf("expected only the secondary backend to be healthy, got %v", healthy)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		pool.Run(ctx)
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	deadline := time.Now().Add(testTimeout)
+	for len(pool.Healthy()) != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("primary backend was not restored, healthy: %v", pool.Healthy())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-stopped
+}
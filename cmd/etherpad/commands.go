@@ -0,0 +1,237 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// command is a subcommand of the CLI.
+type command interface {
+	// name returns the name of the command.
+	name() string
+
+	// usage returns a one line description of the arguments.
+	usage() string
+
+	// run runs the command with its arguments.
+	run(c *cli, args []string) error
+}
+
+// apiCommand calls an API method, it is created from etherpadlite.Methods so
+// that each API method gets a command.
+type apiCommand struct {
+	method etherpadlite.MethodInfo
+}
+
+func (cmd apiCommand) name() string {
+	return cmd.method.Name
+}
+
+func (cmd apiCommand) usage() string {
+	parts := []string{cmd.method.Name}
+	for _, param := range cmd.method.Required {
+		parts = append(parts, "<"+param+">")
+	}
+	for _, param := range cmd.method.Optional {
+		parts = append(parts, fmt.Sprintf("[--%s %s]", param, param))
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseArgs parses the arguments of an API command: the required parameters
+// as positional arguments and the optional ones as flags. Flags may be given
+// before, after or between the positional arguments.
+func (cmd apiCommand) parseArgs(args []string) (map[string]interface{}, error) {
+	fs := flag.NewFlagSet(cmd.method.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	optional := make(map[string]*string, len(cmd.method.Optional))
+	for _, param := range cmd.method.Optional {
+		value := new(string)
+		optional[param] = value
+		fs.StringVar(value, param, "", param)
+		if lower := strings.ToLower(param); lower != param {
+			fs.StringVar(value, lower, "", param)
+		}
+	}
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return nil, usagef("%s: %v, usage: %s", cmd.method.Name, err, cmd.usage())
+	}
+	if len(positional) != len(cmd.method.Required) {
+		return nil, usagef("%s expects %d arguments, got %d, usage: %s",
+			cmd.method.Name, len(cmd.method.Required), len(positional), cmd.usage())
+	}
+	params := make(map[string]interface{}, len(positional)+len(optional))
+	for i, param := range cmd.method.Required {
+		params[param] = positional[i]
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for _, param := range cmd.method.Optional {
+		if set[param] || set[strings.ToLower(param)] {
+			params[param] = *optional[param]
+		}
+	}
+	return params, nil
+}
+
+// parseInterleaved parses args with fs and returns the positional arguments,
+// in contrast to fs.Parse flags after positional arguments are parsed as
+// well. Arguments after "--" are always positional.
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// fs.Parse stops at the first positional argument or after "--"
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func (cmd apiCommand) run(c *cli, args []string) error {
+	params, err := cmd.parseArgs(args)
+	if err != nil {
+		return err
+	}
+	pad, err := c.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	resp, err := pad.Call(ctx, cmd.method.Name, params)
+	if err != nil {
+		return err
+	}
+	return c.printResponse(cmd.method.Name, resp)
+}
+
+// builtinCommands are the commands that don't call a single API method.
+var builtinCommands = []command{
+	profilesCommand{},
+	padsCommand,
+	sessionsCommand,
+	authorsCommand,
+	purgeCommand{},
+}
+
+// commands returns all commands sorted by name.
+func commands() []command {
+	methods := etherpadlite.Methods()
+	res := make([]command, 0, len(methods)+len(builtinCommands))
+	res = append(res, builtinCommands...)
+	for _, m := range methods {
+		cmd := apiCommand{method: m}
+		switch m.Name {
+		case "getText":
+			res = append(res, getTextCommand{cmd})
+		case "setText":
+			res = append(res, setTextCommand{cmd})
+		default:
+			res = append(res, cmd)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].name() < res[j].name()
+	})
+	return res
+}
+
+// lookupCommand returns the command with the given name, the case of the name
+// is ignored.
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands() {
+		if strings.EqualFold(cmd.name(), name) {
+			return cmd, true
+		}
+	}
+	return nil, false
+}
+
+// maxSuggestionDistance is the maximal edit distance of a suggested command.
+const maxSuggestionDistance = 3
+
+// suggest returns the names of commands similar to name: commands with an edit
+// distance of at most maxSuggestionDistance and commands starting with name.
+func suggest(name string) []string {
+	name = strings.ToLower(name)
+	var res []string
+	for _, cmd := range commands() {
+		candidate := strings.ToLower(cmd.name())
+		if levenshtein(name, candidate) <= maxSuggestionDistance || (len(name) >= 3 && strings.HasPrefix(candidate, name)) {
+			res = append(res, cmd.name())
+		}
+	}
+	return res
+}
+
+// levenshtein returns the edit distance of a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	res := a
+	if b < res {
+		res = b
+	}
+	if c < res {
+		res = c
+	}
+	return res
+}
+
+// joinOr joins the strings as "a, b or c".
+func joinOr(s []string) string {
+	if len(s) == 1 {
+		return s[0]
+	}
+	return strings.Join(s[:len(s)-1], ", ") + " or " + s[len(s)-1]
+}
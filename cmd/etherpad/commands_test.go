@@ -0,0 +1,174 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+)
+
+// runAgainst runs the command with the global flags for server and returns
+// the exit code and the output.
+func runAgainst(server *etherpadtest.Server, stdin string, args ...string) (code int, stdout, stderr string) {
+	c, out, errOut := testCLI(stdin, nil)
+	args = append([]string{"--url", server.BaseURL(), "--apikey", server.APIKey}, args...)
+	code = c.run(args)
+	return code, out.String(), errOut.String()
+}
+
+func TestParseArgs(t *testing.T) {
+	cmd, found := lookupCommand("createPad")
+	if !found {
+		t.Fatal("createPad not found")
+	}
+	api := cmd.(apiCommand)
+	tests := []struct {
+		args     []string
+		expected map[string]interface{}
+	}{
+		{[]string{"foo"}, map[string]interface{}{"padID": "foo"}},
+		{[]string{"foo", "--text", "Hello"}, map[string]interface{}{"padID": "foo", "text": "Hello"}},
+		{[]string{"--text", "Hello", "foo"}, map[string]interface{}{"padID": "foo", "text": "Hello"}},
+		{[]string{"foo", "--authorid", "a.x", "--text="}, map[string]interface{}{"padID": "foo", "authorId": "a.x", "text": ""}},
+		{[]string{"--authorId", "a.x", "--", "-foo"}, map[string]interface{}{"padID": "-foo", "authorId": "a.x"}},
+	}
+	for _, tc := range tests {
+		params, err := api.parseArgs(tc.args)
+		if err != nil {
+			t.Errorf("%q: %v", tc.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(params, tc.expected) {
+			t.Errorf("%q: expected %v, got %v", tc.args, tc.expected, params)
+		}
+	}
+	for _, args := range [][]string{nil, {"foo", "bar"}, {"foo", "--unknown", "x"}, {"foo", "--text"}} {
+		_, err := api.parseArgs(args)
+		var usageErr *usageError
+		if !errors.As(err, &usageErr) {
+			t.Errorf("%q: expected a usage error, got %v", args, err)
+		}
+	}
+}
+
+func TestCommandForEachMethod(t *testing.T) {
+	for _, m := range etherpadlite.Methods() {
+		cmd, found := lookupCommand(strings.ToUpper(m.Name))
+		if !found || cmd.name() != m.Name {
+			t.Errorf("no command for method %s", m.Name)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"getTxt", "getText"},
+		{"gettext2", "getText"},
+		{"listAllPad", "listAllPads"},
+		{"createGroupP", "createGroupPad"},
+		{"purg", "purge"},
+	}
+	for _, tc := range tests {
+		suggestions := suggest(tc.name)
+		found := false
+		for _, s := range suggestions {
+			found = found || s == tc.expected
+		}
+		if !found {
+			t.Errorf("expected %s in the suggestions for %s, got %v", tc.expected, tc.name, suggestions)
+		}
+	}
+	if suggestions := suggest("completelyUnrelated"); len(suggestions) != 0 {
+		t.Errorf("expected no suggestions, got %v", suggestions)
+	}
+}
+
+func TestRunCommands(t *testing.T) {
+	server := etherpadtest.NewServer(t)
+	if code, _, stderr := runAgainst(server, "", "createPad", "foo", "--text", "Hello"); code != exitOK {
+		t.Fatalf("createPad: exit code %d: %s", code, stderr)
+	}
+	if text, _ := server.Store.Text("foo"); text != "Hello\n" {
+		t.Errorf("expected text %q, got %q", "Hello\n", text)
+	}
+	if code, _, stderr := runAgainst(server, "from stdin", "setText", "foo", "-"); code != exitOK {
+		t.Fatalf("setText: exit code %d: %s", code, stderr)
+	}
+	code, stdout, _ := runAgainst(server, "", "getText", "foo")
+	if code != exitOK || stdout != "from stdin\n" {
+		t.Errorf("getText: expected %q, got %q (exit code %d)", "from stdin\n", stdout, code)
+	}
+	code, stdout, _ = runAgainst(server, "", "--output", "raw", "getRevisionsCount", "foo")
+	if code != exitOK || strings.TrimSpace(stdout) != "1" {
+		t.Errorf("getRevisionsCount: expected 1, got %q (exit code %d)", stdout, code)
+	}
+	code, stdout, _ = runAgainst(server, "", "--template", "{{join .padIDs \",\"}}", "listAllPads")
+	if code != exitOK || stdout != "foo\n" {
+		t.Errorf("listAllPads: expected %q, got %q (exit code %d)", "foo\n", stdout, code)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	server := etherpadtest.NewServer(t)
+	if err := server.Store.AddPad("foo", "text"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		args   []string
+		fail   *etherpadtest.Failure
+		code   int
+		stderr string
+	}{
+		{"success", []string{"getRevisionsCount", "foo"}, nil, exitOK, ""},
+		{"wrong parameters", []string{"getText", "missing"}, nil, exitAPIError + int(etherpadlite.WrongParameters), "padID does not exist"},
+		{"internal error", []string{"getRevisionsCount", "foo"}, &etherpadtest.Failure{Code: etherpadlite.InternalError}, exitAPIError + int(etherpadlite.InternalError), ""},
+		{"wrong API key", []string{"--apikey", "invalid-key", "getRevisionsCount", "foo"}, nil, exitAPIError + int(etherpadlite.WrongAPIKey), "wrong API Key"},
+		{"json output", []string{"--output", "json", "getText", "missing"}, nil, exitAPIError + int(etherpadlite.WrongParameters), ""},
+		{"http error", []string{"getRevisionsCount", "foo"}, &etherpadtest.Failure{Status: 502}, exitError, ""},
+		{"network error", []string{"--url", "http://127.0.0.1:1/api", "getRevisionsCount", "foo"}, nil, exitError, ""},
+		{"unknown command", []string{"getTxt", "foo"}, nil, exitUsage, `unknown command "getTxt", did you mean `},
+		{"missing argument", []string{"getRevisionsCount"}, nil, exitUsage, "expects 1 arguments, got 0"},
+		{"unknown flag", []string{"getText", "foo", "--revision", "1"}, nil, exitUsage, "usage: getText"},
+		{"unknown global flag", []string{"--verbose", "getText", "foo"}, nil, exitUsage, ""},
+		{"no command", nil, nil, exitUsage, ""},
+		{"help", []string{"help"}, nil, exitOK, ""},
+		{"check", []string{"--check"}, nil, exitOK, ""},
+		{"check wrong key", []string{"--apikey", "invalid-key", "--check"}, nil, exitError, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server.Reset()
+			if tc.fail != nil {
+				server.FailNext("getRevisionsCount", *tc.fail)
+			}
+			code, _, stderr := runAgainst(server, "", tc.args...)
+			if code != tc.code {
+				t.Errorf("expected exit code %d, got %d: %s", tc.code, code, stderr)
+			}
+			if !strings.Contains(stderr, tc.stderr) {
+				t.Errorf("expected %q in the error output, got %q", tc.stderr, stderr)
+			}
+		})
+	}
+}
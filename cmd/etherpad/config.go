@@ -0,0 +1,515 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Environment variables read by the command, they take precedence over the
+// config file but not over flags.
+const (
+	// EnvProfile selects the profile if --profile is not given.
+	EnvProfile = "ETHERPAD_PROFILE"
+
+	// EnvURL is the base URL of the API.
+	EnvURL = "ETHERPAD_URL"
+
+	// EnvAPIKey is the API key.
+	EnvAPIKey = "ETHERPAD_APIKEY"
+
+	// EnvAPIKeyFile is the path of a file containing the API key.
+	EnvAPIKeyFile = "ETHERPAD_APIKEY_FILE"
+)
+
+// profile is a named server in the config file.
+type profile struct {
+	URL        string
+	APIKey     string
+	APIKeyFile string
+	APIVersion string
+	Timeout    time.Duration
+}
+
+// config is the content of the config file. The file is a TOML file with a
+// table for each profile:
+//
+//	[profiles.prod]
+//	url = "https://pad.example.com/api"
+//	apikey_file = "~/.config/etherpad/prod.key"
+//	api_version = "1.2.13"
+//	timeout = "10s"
+//
+// Only the subset of TOML needed for this is supported: tables, strings
+// (basic and literal) and integers (a timeout in seconds).
+type config struct {
+	profiles map[string]*profile
+
+	// names contains the names of the profiles in the order of the file
+	names []string
+}
+
+// configError is an error in the config file.
+type configError struct {
+	path string
+	line int
+	msg  string
+}
+
+func (e *configError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.path, e.line, e.msg)
+}
+
+// parseConfig parses the config file read from r, path is only used in
+// errors.
+func parseConfig(r io.Reader, path string) (*config, error) {
+	cfg := &config{profiles: make(map[string]*profile)}
+	var current *profile
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fail := func(format string, args ...interface{}) error {
+			return &configError{path: path, line: line, msg: fmt.Sprintf(format, args...)}
+		}
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		if text[0] == '[' {
+			header, rest, err := parseTableHeader(text)
+			if err != nil {
+				return nil, fail("%v", err)
+			}
+			if !isComment(rest) {
+				return nil, fail("unexpected %q after table header", rest)
+			}
+			if len(header) != 2 || header[0] != "profiles" {
+				return nil, fail("unknown table %q, expected [profiles.<name>]", strings.Join(header, "."))
+			}
+			name := header[1]
+			if _, exists := cfg.profiles[name]; exists {
+				return nil, fail("duplicate profile %q", name)
+			}
+			current = &profile{}
+			cfg.profiles[name] = current
+			cfg.names = append(cfg.names, name)
+			continue
+		}
+		key, value, err := parseKeyValue(text)
+		if err != nil {
+			return nil, fail("%v", err)
+		}
+		if current == nil {
+			return nil, fail("key %q outside of a profile", key)
+		}
+		if err := current.set(key, value); err != nil {
+			return nil, fail("%v", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// set sets the field key of the profile, value is either a string or an
+// int64.
+func (p *profile) set(key string, value interface{}) error {
+	var field *string
+	switch key {
+	case "timeout":
+		switch v := value.(type) {
+		case int64:
+			p.Timeout = time.Duration(v) * time.Second
+		case string:
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid timeout: %w", err)
+			}
+			p.Timeout = d
+		}
+		return nil
+	case "url":
+		field = &p.URL
+	case "apikey":
+		field = &p.APIKey
+	case "apikey_file":
+		field = &p.APIKeyFile
+	case "api_version":
+		field = &p.APIVersion
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+	s, isString := value.(string)
+	if !isString {
+		return fmt.Errorf("%s must be a string", key)
+	}
+	*field = s
+	return nil
+}
+
+// isComment returns true if s is empty or a comment.
+func isComment(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || s[0] == '#'
+}
+
+// parseTableHeader parses a table header like [profiles."my server"] and
+// returns the keys and the remaining text.
+func parseTableHeader(s string) ([]string, string, error) {
+	s = strings.TrimSpace(s[1:])
+	var keys []string
+	for {
+		key, rest, err := parseKey(s)
+		if err != nil {
+			return nil, "", err
+		}
+		keys = append(keys, key)
+		rest = strings.TrimSpace(rest)
+		switch {
+		case strings.HasPrefix(rest, "."):
+			s = strings.TrimSpace(rest[1:])
+		case strings.HasPrefix(rest, "]"):
+			return keys, rest[1:], nil
+		default:
+			return nil, "", errors.New("invalid table header")
+		}
+	}
+}
+
+// parseKeyValue parses a line key = value.
+func parseKeyValue(s string) (string, interface{}, error) {
+	key, rest, err := parseKey(s)
+	if err != nil {
+		return "", nil, err
+	}
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "=") {
+		return "", nil, fmt.Errorf("expected = after key %q", key)
+	}
+	rest = strings.TrimSpace(rest[1:])
+	var value interface{}
+	switch {
+	case rest == "":
+		return "", nil, fmt.Errorf("missing value of key %q", key)
+	case rest[0] == '"' || rest[0] == '\'':
+		value, rest, err = parseString(rest)
+		if err != nil {
+			return "", nil, err
+		}
+	default:
+		end := strings.IndexAny(rest, " \t#")
+		if end < 0 {
+			end = len(rest)
+		}
+		n, err := strconv.ParseInt(strings.ReplaceAll(rest[:end], "_", ""), 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid value of key %q, expected a string or an integer", key)
+		}
+		value, rest = n, rest[end:]
+	}
+	if !isComment(rest) {
+		return "", nil, fmt.Errorf("unexpected %q after value of key %q", strings.TrimSpace(rest), key)
+	}
+	return key, value, nil
+}
+
+// parseKey parses a bare or quoted key at the start of s and returns it with
+// the remaining text.
+func parseKey(s string) (string, string, error) {
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		return parseString(s)
+	}
+	end := 0
+	for end < len(s) && isBareKeyChar(s[end]) {
+		end++
+	}
+	if end == 0 {
+		return "", "", errors.New("missing key")
+	}
+	return s[:end], s[end:], nil
+}
+
+func isBareKeyChar(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '_' || b == '-'
+}
+
+// parseString parses a basic ("...") or literal ('...') string at the start of
+// s and returns it with the remaining text.
+func parseString(s string) (string, string, error) {
+	if s[0] == '\'' {
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", "", errors.New("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), s[i+1:], nil
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", "", errors.New("unterminated string")
+			}
+			switch e := s[i]; e {
+			case '"', '\\':
+				b.WriteByte(e)
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'u', 'U':
+				n := 4
+				if e == 'U' {
+					n = 8
+				}
+				if i+n >= len(s) {
+					return "", "", errors.New("invalid unicode escape")
+				}
+				r, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32)
+				if err != nil || !utf8.ValidRune(rune(r)) {
+					return "", "", errors.New("invalid unicode escape")
+				}
+				b.WriteRune(rune(r))
+				i += n
+			default:
+				return "", "", fmt.Errorf("invalid escape \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+// quote returns s as TOML basic string.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, "\\u%04X", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// quoteKey returns key as bare key if possible and quoted otherwise.
+func quoteKey(key string) string {
+	for i := 0; i < len(key); i++ {
+		if !isBareKeyChar(key[i]) {
+			return quote(key)
+		}
+	}
+	if key == "" {
+		return quote(key)
+	}
+	return key
+}
+
+// format returns the profile as TOML table.
+func (p *profile) format(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[profiles.%s]\n", quoteKey(name))
+	if p.URL != "" {
+		fmt.Fprintf(&b, "url = %s\n", quote(p.URL))
+	}
+	if p.APIKey != "" {
+		fmt.Fprintf(&b, "apikey = %s\n", quote(p.APIKey))
+	}
+	if p.APIKeyFile != "" {
+		fmt.Fprintf(&b, "apikey_file = %s\n", quote(p.APIKeyFile))
+	}
+	if p.APIVersion != "" {
+		fmt.Fprintf(&b, "api_version = %s\n", quote(p.APIVersion))
+	}
+	if p.Timeout != 0 {
+		fmt.Fprintf(&b, "timeout = %s\n", quote(p.Timeout.String()))
+	}
+	return b.String()
+}
+
+// expandHome replaces a leading ~ in path by the home directory.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// readKeyFile reads an API key from a file, surrounding whitespace (like the
+// final newline) is removed.
+func readKeyFile(path string) (string, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return "", err
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("can't read API key: %w", err)
+	}
+	key := strings.TrimSpace(string(content))
+	if key == "" {
+		return "", fmt.Errorf("API key file %s is empty", path)
+	}
+	return key, nil
+}
+
+// configPath returns the path of the config file: the value of --config or
+// etherpad/config.toml in $XDG_CONFIG_HOME (default ~/.config).
+func (c *cli) configPath() (string, error) {
+	if c.options.configPath != "" {
+		return expandHome(c.options.configPath)
+	}
+	if dir := c.getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "etherpad", "config.toml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "etherpad", "config.toml"), nil
+}
+
+// loadConfig reads the config file and returns it with its path. If the file
+// doesn't exist an empty config is returned.
+func (c *cli) loadConfig() (*config, string, error) {
+	path, err := c.configPath()
+	if err != nil {
+		return nil, "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &config{profiles: make(map[string]*profile)}, path, nil
+		}
+		return nil, "", err
+	}
+	defer f.Close()
+	cfg, err := parseConfig(f, path)
+	if err != nil {
+		return nil, "", err
+	}
+	return cfg, path, nil
+}
+
+// profileName returns the name of the selected profile: the value of
+// --profile or $ETHERPAD_PROFILE, empty if no profile is selected.
+func (c *cli) profileName() string {
+	if c.options.profile != "" {
+		return c.options.profile
+	}
+	return c.getenv(EnvProfile)
+}
+
+// resolveOptions applies the environment variables and the selected profile
+// to the global options.
+func (c *cli) resolveOptions() error {
+	if c.resolved {
+		return nil
+	}
+	c.resolved = true
+	var p *profile
+	if name := c.profileName(); name != "" {
+		cfg, path, err := c.loadConfig()
+		if err != nil {
+			return err
+		}
+		var found bool
+		if p, found = cfg.profiles[name]; !found {
+			return fmt.Errorf("profile %q not found in %s", name, path)
+		}
+	}
+	options, err := c.optionsFor(p)
+	if err != nil {
+		return err
+	}
+	c.options = options
+	return nil
+}
+
+// optionsFor returns the options to use with the profile p (nil for no
+// profile): flags given on the command line take precedence over the
+// environment variables, which take precedence over the profile.
+func (c *cli) optionsFor(p *profile) (globalOptions, error) {
+	o := c.options
+	if p == nil {
+		p = &profile{}
+	}
+	if !c.flagSet["url"] {
+		if url := c.getenv(EnvURL); url != "" {
+			o.url = url
+		} else if p.URL != "" {
+			o.url = p.URL
+		}
+	}
+	if !c.flagSet["api-version"] && p.APIVersion != "" {
+		o.apiVersion = p.APIVersion
+	}
+	if !c.flagSet["timeout"] && p.Timeout != 0 {
+		o.timeout = p.Timeout
+	}
+	var keyFile string
+	switch {
+	case c.flagSet["apikey"] && c.flagSet["apikey-file"]:
+		return o, usagef("--apikey and --apikey-file can't be used together")
+	case c.flagSet["apikey"]:
+	case c.flagSet["apikey-file"]:
+		keyFile = o.apiKeyFile
+	case c.getenv(EnvAPIKey) != "":
+		o.apiKey = c.getenv(EnvAPIKey)
+	case c.getenv(EnvAPIKeyFile) != "":
+		keyFile = c.getenv(EnvAPIKeyFile)
+	case p.APIKey != "":
+		o.apiKey = p.APIKey
+	case p.APIKeyFile != "":
+		keyFile = p.APIKeyFile
+	}
+	if keyFile != "" {
+		key, err := readKeyFile(keyFile)
+		if err != nil {
+			return o, err
+		}
+		o.apiKey = key
+	}
+	return o, nil
+}
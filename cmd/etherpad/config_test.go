@@ -0,0 +1,243 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCLI returns a cli that reads stdin and the environment env (nil for an
+// empty environment) and writes to the returned buffers.
+func testCLI(stdin string, env map[string]string) (c *cli, stdout, stderr *bytes.Buffer) {
+	stdout, stderr = &bytes.Buffer{}, &bytes.Buffer{}
+	c = &cli{
+		stdin:  strings.NewReader(stdin),
+		stdout: stdout,
+		stderr: stderr,
+		getenv: func(key string) string {
+			return env[key]
+		},
+	}
+	return c, stdout, stderr
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]profile
+		names    []string
+	}{
+		{"empty", "", map[string]profile{}, nil},
+		{"comments", "# comment\n\n   # indented\n", map[string]profile{}, nil},
+		{
+			"full profile",
+			`[profiles.prod]
+url = "https://pad.example.com/api"
+apikey_file = '~/prod.key'
+api_version = "1.2.13" # comment
+timeout = "1m30s"
+`,
+			map[string]profile{"prod": {
+				URL:        "https://pad.example.com/api",
+				APIKeyFile: "~/prod.key",
+				APIVersion: "1.2.13",
+				Timeout:    90 * time.Second,
+			}},
+			[]string{"prod"},
+		},
+		{
+			"integer timeout",
+			"[profiles.a]\ntimeout = 1_0\n",
+			map[string]profile{"a": {Timeout: 10 * time.Second}},
+			[]string{"a"},
+		},
+		{
+			"quoted names in file order",
+			`[profiles."my server"]
+apikey = "k"
+[ profiles . 'b#c' ] # comment
+apikey = "a\"b\\c\u0041\t#"
+`,
+			map[string]profile{"my server": {APIKey: "k"}, "b#c": {APIKey: "a\"b\\cA\t#"}},
+			[]string{"my server", "b#c"},
+		},
+		{
+			"literal strings keep backslashes",
+			`[profiles.win]
+apikey_file = 'C:\keys\pad.key'
+`,
+			map[string]profile{"win": {APIKeyFile: `C:\keys\pad.key`}},
+			[]string{"win"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := parseConfig(strings.NewReader(tc.input), "config.toml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]profile, len(cfg.profiles))
+			for name, p := range cfg.profiles {
+				got[name] = *p
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected profiles %+v, got %+v", tc.expected, got)
+			}
+			if !reflect.DeepEqual(cfg.names, tc.names) {
+				t.Errorf("expected names %q, got %q", tc.names, cfg.names)
+			}
+		})
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+		msg   string
+	}{
+		{"url = \"x\"\n", 1, "outside of a profile"},
+		{"# comment\n[servers.a]\n", 2, "unknown table"},
+		{"[profiles]\n", 1, "unknown table"},
+		{"[profiles.a.b]\n", 1, "unknown table"},
+		{"[profiles.a\n", 1, "invalid table header"},
+		{"[profiles.a] x\n", 1, "after table header"},
+		{"[profiles.a]\n\n[profiles.a]\n", 3, "duplicate profile"},
+		{"[profiles.a]\nurl \"x\"\n", 2, "expected ="},
+		{"[profiles.a]\nurl =\n", 2, "missing value"},
+		{"[profiles.a]\nurl = \"x\n", 2, "unterminated string"},
+		{"[profiles.a]\nurl = 'x\n", 2, "unterminated string"},
+		{"[profiles.a]\nurl = \"x\\q\"\n", 2, "invalid escape"},
+		{"[profiles.a]\nurl = \"\\u00\"\n", 2, "invalid unicode escape"},
+		{"[profiles.a]\nurl = \"\\uD800\"\n", 2, "invalid unicode escape"},
+		{"[profiles.a]\nurl = \"x\" y\n", 2, "unexpected"},
+		{"[profiles.a]\nurl = true\n", 2, "expected a string or an integer"},
+		{"[profiles.a]\nurl = 1\n", 2, "must be a string"},
+		{"[profiles.a]\ntimeout = \"soon\"\n", 2, "invalid timeout"},
+		{"[profiles.a]\n\n\nuser = \"x\"\n", 4, "unknown key"},
+		{"[profiles.a]\n= \"x\"\n", 2, "missing key"},
+	}
+	for _, tc := range tests {
+		_, err := parseConfig(strings.NewReader(tc.input), "config.toml")
+		var configErr *configError
+		if !errors.As(err, &configErr) {
+			t.Errorf("%q: expected a configError, got %v", tc.input, err)
+			continue
+		}
+		if configErr.line != tc.line || !strings.Contains(configErr.msg, tc.msg) {
+			t.Errorf("%q: expected %q in line %d, got %q in line %d", tc.input, tc.msg, tc.line, configErr.msg, configErr.line)
+		}
+		if prefix := "config.toml:"; !strings.HasPrefix(err.Error(), prefix) {
+			t.Errorf("%q: expected the error to start with %q, got %q", tc.input, prefix, err)
+		}
+	}
+}
+
+func TestProfileFormatRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		p    profile
+	}{
+		{"prod", profile{URL: "https://pad.example.com/api", APIVersion: "1.2.13", Timeout: 10 * time.Second}},
+		{"with space", profile{APIKey: "plain"}},
+		{`quote"s`, profile{APIKey: `a"b`}},
+		{`back\slash`, profile{APIKeyFile: `C:\keys\pad.key`}},
+		{"hash#name", profile{APIKey: "key # not a comment", URL: "http://host/#frag"}},
+		{"", profile{APIKey: "\t\n\x00\x7f control"}},
+		{"ünïcödé", profile{APIKey: `日本語 ' \"`}},
+		{"dots.in.name", profile{Timeout: 1500 * time.Millisecond}},
+	}
+	var file strings.Builder
+	for _, tc := range tests {
+		file.WriteString(tc.p.format(tc.name))
+		file.WriteString("\n")
+	}
+	cfg, err := parseConfig(strings.NewReader(file.String()), "config.toml")
+	if err != nil {
+		t.Fatalf("%v, config:\n%s", err, file.String())
+	}
+	if len(cfg.names) != len(tests) {
+		t.Fatalf("expected %d profiles, got %q", len(tests), cfg.names)
+	}
+	for i, tc := range tests {
+		if cfg.names[i] != tc.name {
+			t.Errorf("expected name %q, got %q", tc.name, cfg.names[i])
+		}
+		if p := cfg.profiles[tc.name]; p == nil || *p != tc.p {
+			t.Errorf("profile %q: expected %+v, got %+v", tc.name, tc.p, p)
+		}
+	}
+}
+
+func TestProfilesAdd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "etherpad", "config.toml")
+	name := `odd "name" # \`
+	key := `se#cret\"`
+	c, stdout, _ := testCLI(key+"\n", nil)
+	code := c.run([]string{"--config", path, "profiles", "add", name, "--url", "http://localhost:1/api", "--timeout", "5s"})
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	if !strings.Contains(stdout.String(), "added profile") {
+		t.Errorf("unexpected output %q", stdout.String())
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected the config to be readable only by the user, got %v", perm)
+	}
+
+	c, _, _ = testCLI("", nil)
+	c.options.configPath = path
+	cfg, _, err := c.loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := profile{URL: "http://localhost:1/api", APIKey: key, Timeout: 5 * time.Second}
+	if p := cfg.profiles[name]; p == nil || *p != expected {
+		t.Errorf("expected profile %+v, got %+v", expected, p)
+	}
+
+	// a second profile is appended, an existing one is rejected
+	c, _, _ = testCLI("other\n", nil)
+	if code := c.run([]string{"--config", path, "profiles", "add", "second", "--url", "http://localhost:2/api"}); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	c, _, stderr := testCLI("other\n", nil)
+	if code := c.run([]string{"--config", path, "profiles", "add", name, "--url", "http://localhost:2/api"}); code != exitError {
+		t.Errorf("expected exit code %d adding an existing profile, got %d", exitError, code)
+	}
+	if !strings.Contains(stderr.String(), "already exists") {
+		t.Errorf("unexpected error %q", stderr.String())
+	}
+
+	c, stdout, _ = testCLI("", nil)
+	if code := c.run([]string{"--config", path, "profiles", "list"}); code != exitOK {
+		t.Fatalf("expected exit code %d, got %d", exitOK, code)
+	}
+	if out := stdout.String(); !strings.Contains(out, name) || !strings.Contains(out, "second") || strings.Contains(out, key) {
+		t.Errorf("expected both profiles without the API key, got\n%s", out)
+	}
+}
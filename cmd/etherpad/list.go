@@ -0,0 +1,467 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// listCommand is a command with the single subcommand list that prints a
+// table, for example "pads list".
+type listCommand struct {
+	noun string
+
+	// args describes the arguments and flags of list in the usage
+	args string
+
+	list func(c *cli, args []string) error
+}
+
+func (cmd listCommand) name() string {
+	return cmd.noun
+}
+
+func (cmd listCommand) usage() string {
+	return cmd.noun + " list " + cmd.args
+}
+
+func (cmd listCommand) run(c *cli, args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		return usagef("usage: %s", cmd.usage())
+	}
+	err := cmd.list(c, args[1:])
+	if errors.Is(err, errListUsage) {
+		return usagef("usage: %s", cmd.usage())
+	}
+	return err
+}
+
+// errListUsage is returned by the list functions of listCommand if the
+// arguments are invalid, the usage of the command is printed instead.
+var errListUsage = errors.New("invalid arguments")
+
+// listCommonArgs describes the flags of listFlags in the usage.
+const listCommonArgs = "[--fast] [--no-header] [--concurrency n]"
+
+var (
+	padsCommand = listCommand{
+		noun: "pads",
+		args: "[--group groupID] [--sort id|lastedited|revisions] " + listCommonArgs,
+		list: listPads,
+	}
+
+	sessionsCommand = listCommand{
+		noun: "sessions",
+		args: "[--group groupID | --author authorID] [--sort id|expires] " + listCommonArgs,
+		list: listSessions,
+	}
+
+	authorsCommand = listCommand{
+		noun: "authors",
+		args: "<padID> [--sort id|name|pads] " + listCommonArgs,
+		list: listAuthors,
+	}
+)
+
+// listFlags are the flags common to all listing commands.
+type listFlags struct {
+	noHeader    bool
+	sort        string
+	fast        bool
+	concurrency int
+
+	// sortKeys are the allowed values of sort, the first one is the default
+	sortKeys []string
+}
+
+// newListFlags returns a flag set with the common flags registered in lf.
+func newListFlags(name string, lf *listFlags, sortKeys ...string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	lf.sortKeys = sortKeys
+	fs.BoolVar(&lf.noHeader, "no-header", false, "don't print the header")
+	fs.StringVar(&lf.sort, "sort", sortKeys[0], "sort by column")
+	fs.BoolVar(&lf.fast, "fast", false, "skip the columns that require a request per row")
+	fs.IntVar(&lf.concurrency, "concurrency", etherpadlite.DefaultConcurrency, "number of concurrent requests")
+	return fs
+}
+
+// parse parses the arguments and checks the flags. fastKeys are the sort keys
+// that can be used with --fast.
+func (lf *listFlags) parse(fs *flag.FlagSet, args []string, fastKeys ...string) ([]string, error) {
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return nil, usagef("%s: %v", fs.Name(), err)
+	}
+	valid := false
+	for _, key := range lf.sortKeys {
+		valid = valid || key == lf.sort
+	}
+	if !valid {
+		return nil, usagef("%s: invalid sort key %q, must be one of %s", fs.Name(), lf.sort, joinOr(lf.sortKeys))
+	}
+	if lf.fast {
+		allowed := false
+		for _, key := range fastKeys {
+			allowed = allowed || key == lf.sort
+		}
+		if !allowed {
+			return nil, usagef("%s: --sort %s can't be used with --fast", fs.Name(), lf.sort)
+		}
+	}
+	return positional, nil
+}
+
+// parallel calls fn for all i in [0, n) with at most workers concurrent
+// calls (DefaultConcurrency if workers <= 0).
+func parallel(n, workers int, fn func(i int)) {
+	if workers <= 0 {
+		workers = etherpadlite.DefaultConcurrency
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// reportErrors prints the errors that occurred while fetching the rows of a
+// table (errs maps the ID of the row to the error) and returns an error if
+// there is one.
+func (c *cli) reportErrors(noun string, errs map[string]error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(errs))
+	for id := range errs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(c.stderr, "etherpad: %s: %s\n", id, redact(errs[id].Error(), c.options.apiKey))
+	}
+	return fmt.Errorf("could not fetch %d %s", len(errs), noun)
+}
+
+// listPads implements "pads list": the pads with the number of revisions and
+// the time they were last edited.
+func listPads(c *cli, args []string) error {
+	var lf listFlags
+	fs := newListFlags("pads list", &lf, "id", "lastedited", "revisions")
+	groupID := fs.String("group", "", "only list the pads of this group")
+	positional, err := lf.parse(fs, args, "id")
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return errListUsage
+	}
+	pad, err := c.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	pads, err := pad.Pads(ctx, etherpadlite.PadsOptions{
+		GroupID:     *groupID,
+		LastEdited:  !lf.fast,
+		Revisions:   !lf.fast,
+		Concurrency: lf.concurrency,
+	})
+	if err != nil {
+		return err
+	}
+	var infos []etherpadlite.PadInfo
+	for info := range pads {
+		infos = append(infos, info)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		a, b := infos[i], infos[j]
+		switch {
+		case lf.sort == "lastedited" && !a.LastEdited.Equal(b.LastEdited):
+			return a.LastEdited.After(b.LastEdited)
+		case lf.sort == "revisions" && a.Revisions != b.Revisions:
+			return a.Revisions > b.Revisions
+		}
+		return a.ID < b.ID
+	})
+	t := &table{header: []string{"PAD ID"}}
+	if !lf.fast {
+		t.header = append(t.header, "REVISIONS", "LAST EDITED")
+	}
+	now := time.Now()
+	errs := make(map[string]error)
+	for _, info := range infos {
+		value := map[string]interface{}{"padID": info.ID}
+		cells := []string{info.ID}
+		if !lf.fast {
+			if info.Err != nil {
+				errs[info.ID] = info.Err
+				cells = append(cells, "?", "?")
+			} else {
+				value["revisions"] = info.Revisions
+				value["lastEdited"] = info.LastEdited
+				cells = append(cells, strconv.Itoa(info.Revisions), relativeTime(info.LastEdited, now))
+			}
+		}
+		t.add(value, cells...)
+	}
+	if err := c.printTable(t, !lf.noHeader); err != nil {
+		return err
+	}
+	return c.reportErrors("pads", errs)
+}
+
+// listSessions implements "sessions list": the sessions of a group, of an
+// author or (if neither is given) of all groups.
+func listSessions(c *cli, args []string) error {
+	var lf listFlags
+	fs := newListFlags("sessions list", &lf, "id", "expires")
+	groupID := fs.String("group", "", "only list the sessions of this group")
+	authorID := fs.String("author", "", "only list the sessions of this author")
+	positional, err := lf.parse(fs, args, "id", "expires")
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 || (*groupID != "" && *authorID != "") {
+		return errListUsage
+	}
+	pad, err := c.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	var sessions []etherpadlite.Session
+	errs := make(map[string]error)
+	switch {
+	case *groupID != "":
+		sessions, err = sessionSlice(pad.ListGroupSessions(ctx, *groupID))
+	case *authorID != "":
+		sessions, err = sessionSlice(pad.ListAuthorSessions(ctx, *authorID))
+	default:
+		sessions, err = allSessions(ctx, pad, lf.concurrency, errs)
+	}
+	if err != nil {
+		return err
+	}
+	var names map[string]string
+	if !lf.fast {
+		names = authorNames(ctx, pad, sessions, lf.concurrency, errs)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		a, b := sessions[i], sessions[j]
+		if lf.sort == "expires" && !a.ValidUntil.Equal(b.ValidUntil) {
+			return a.ValidUntil.Before(b.ValidUntil)
+		}
+		return a.ID < b.ID
+	})
+	t := &table{header: []string{"SESSION ID", "AUTHOR"}}
+	if !lf.fast {
+		t.header = append(t.header, "NAME")
+	}
+	t.header = append(t.header, "GROUP", "EXPIRES")
+	now := time.Now()
+	for _, session := range sessions {
+		value := map[string]interface{}{
+			"sessionID":  session.ID,
+			"authorID":   session.AuthorID,
+			"groupID":    session.GroupID,
+			"validUntil": session.ValidUntil,
+		}
+		cells := []string{session.ID, session.AuthorID}
+		if !lf.fast {
+			value["authorName"] = names[session.AuthorID]
+			cells = append(cells, orDash(names[session.AuthorID]))
+		}
+		cells = append(cells, session.GroupID, relativeTime(session.ValidUntil, now))
+		t.add(value, cells...)
+	}
+	if err := c.printTable(t, !lf.noHeader); err != nil {
+		return err
+	}
+	return c.reportErrors("groups or authors", errs)
+}
+
+// sessionSlice converts the result of ListGroupSessions and
+// ListAuthorSessions to a slice.
+func sessionSlice(sessions map[string]etherpadlite.Session, err error) ([]etherpadlite.Session, error) {
+	if err != nil {
+		return nil, err
+	}
+	res := make([]etherpadlite.Session, 0, len(sessions))
+	for _, session := range sessions {
+		res = append(res, session)
+	}
+	return res, nil
+}
+
+// allSessions returns the sessions of all groups, the sessions of the groups
+// are fetched concurrently. Errors for single groups are added to errs.
+func allSessions(ctx context.Context, pad *etherpadlite.EtherpadLite, concurrency int, errs map[string]error) ([]etherpadlite.Session, error) {
+	groupIDs, err := pad.AllGroupIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	perGroup := make([][]etherpadlite.Session, len(groupIDs))
+	groupErrs := make([]error, len(groupIDs))
+	parallel(len(groupIDs), concurrency, func(i int) {
+		perGroup[i], groupErrs[i] = sessionSlice(pad.ListGroupSessions(ctx, groupIDs[i]))
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var res []etherpadlite.Session
+	for i, sessions := range perGroup {
+		if groupErrs[i] != nil {
+			errs[groupIDs[i]] = groupErrs[i]
+			continue
+		}
+		res = append(res, sessions...)
+	}
+	return res, nil
+}
+
+// authorNames fetches the names of the authors of the sessions concurrently.
+// Errors are added to errs.
+func authorNames(ctx context.Context, pad *etherpadlite.EtherpadLite, sessions []etherpadlite.Session, concurrency int, errs map[string]error) map[string]string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, session := range sessions {
+		if !seen[session.AuthorID] {
+			seen[session.AuthorID] = true
+			ids = append(ids, session.AuthorID)
+		}
+	}
+	names := make([]string, len(ids))
+	nameErrs := make([]error, len(ids))
+	parallel(len(ids), concurrency, func(i int) {
+		names[i], nameErrs[i] = pad.AuthorName(ctx, ids[i])
+	})
+	res := make(map[string]string, len(ids))
+	for i, id := range ids {
+		if nameErrs[i] != nil {
+			errs[id] = nameErrs[i]
+			continue
+		}
+		res[id] = names[i]
+	}
+	return res
+}
+
+// authorInfo is a row of "authors list".
+type authorInfo struct {
+	id   string
+	name string
+	pads int
+	err  error
+}
+
+// listAuthors implements "authors list": the authors of a pad with their
+// names and the number of pads they contributed to.
+func listAuthors(c *cli, args []string) error {
+	var lf listFlags
+	fs := newListFlags("authors list", &lf, "id", "name", "pads")
+	positional, err := lf.parse(fs, args, "id")
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return errListUsage
+	}
+	pad, err := c.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	ids, err := pad.AuthorsOfPad(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	authors := make([]authorInfo, len(ids))
+	for i, id := range ids {
+		authors[i].id = id
+	}
+	if !lf.fast {
+		parallel(len(authors), lf.concurrency, func(i int) {
+			author := &authors[i]
+			if author.name, author.err = pad.AuthorName(ctx, author.id); author.err != nil {
+				return
+			}
+			var padIDs []string
+			padIDs, author.err = pad.PadIDsOfAuthor(ctx, author.id)
+			author.pads = len(padIDs)
+		})
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		a, b := authors[i], authors[j]
+		switch {
+		case lf.sort == "name" && a.name != b.name:
+			return strings.ToLower(a.name) < strings.ToLower(b.name)
+		case lf.sort == "pads" && a.pads != b.pads:
+			return a.pads > b.pads
+		}
+		return a.id < b.id
+	})
+	t := &table{header: []string{"AUTHOR ID"}}
+	if !lf.fast {
+		t.header = append(t.header, "NAME", "PADS")
+	}
+	errs := make(map[string]error)
+	for _, author := range authors {
+		value := map[string]interface{}{"authorID": author.id}
+		cells := []string{author.id}
+		switch {
+		case lf.fast:
+		case author.err != nil:
+			errs[author.id] = author.err
+			cells = append(cells, "?", "?")
+		default:
+			value["name"] = author.name
+			value["pads"] = author.pads
+			cells = append(cells, orDash(author.name), strconv.Itoa(author.pads))
+		}
+		t.add(value, cells...)
+	}
+	if err := c.printTable(t, !lf.noHeader); err != nil {
+		return err
+	}
+	return c.reportErrors("authors", errs)
+}
@@ -0,0 +1,356 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command etherpad is a command line client for the HTTP API of etherpad.
+//
+// Usage:
+//
+//	etherpad [global flags] <command> [args] [flags]
+//
+// There is a command for each API method, the required parameters are
+// given as arguments in the order of the API documentation and optional
+// parameters as flags, for example:
+//
+//	etherpad --url https://pad.example.com/api createPad foo --text "Hello"
+//	etherpad getText foo --rev 2
+//
+// The data returned by etherpad is printed as JSON. Run "etherpad help" for a
+// list of all commands.
+//
+// getText writes the text of the pad to stdout exactly as returned by
+// etherpad and setText reads the text from stdin if it's "-" or not given
+// (--append appends it instead), so pads can be piped:
+//
+//	etherpad getText mypad > out.txt
+//	cat in.txt | etherpad setText mypad -
+//
+// purge deletes pads that were not edited for a given time, after listing
+// them and asking for confirmation (skipped with --yes). If stdin is not a
+// terminal it only lists the pads unless --yes is given:
+//
+//	etherpad purge --older-than 180d --match '^tmp-'
+//
+// The output can be changed with --output: "json" prints the full response
+// (code, message and data) as JSON, "raw" prints only the primary value of
+// the data (for example the text for getText and one pad ID per line for
+// listAllPads) and "template" executes the Go template given with --template
+// with the data, for example:
+//
+//	etherpad --template '{{.revisions}}' getRevisionsCount foo
+//	etherpad --template '{{join .padIDs ","}}' listAllPads
+//
+// The template functions json (encode a value as JSON) and join (join an
+// array with a separator) are available.
+//
+// Servers can be configured as named profiles in a config file (default
+// ~/.config/etherpad/config.toml, see --config) and selected with --profile or
+// the environment variable ETHERPAD_PROFILE:
+//
+//	[profiles.prod]
+//	url = "https://pad.example.com/api"
+//	apikey_file = "~/.config/etherpad/prod.key"
+//	api_version = "1.2.13"
+//	timeout = "10s"
+//
+// The environment variables ETHERPAD_URL, ETHERPAD_APIKEY and
+// ETHERPAD_APIKEY_FILE override the profile, flags given on the command line
+// override both. The profiles command lists, adds and tests profiles.
+//
+// To keep the API key out of the shell history use --apikey-file, the
+// environment or a profile instead of --apikey. The key is never printed, not
+// even with --debug.
+//
+// With --check the command only checks the API key (with checkToken) and exits
+// with 0 if it's valid and 1 otherwise.
+//
+// Exit codes: 0 on success, 1 if the request failed (for example a network
+// error), 2 for invalid usage and 10 plus the return code if etherpad
+// returned an error (for example 11 for wrong parameters).
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// Exit codes of the command.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+
+	// exitAPIError plus the return code is used if etherpad returned an
+	// error.
+	exitAPIError = 10
+)
+
+// usageError is returned for invalid command lines.
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// usagef returns a usageError.
+func usagef(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// Defaults of the global flags.
+const (
+	defaultURL     = "http://localhost:9001/api"
+	defaultTimeout = 30 * time.Second
+)
+
+// globalOptions are the flags given before the command.
+type globalOptions struct {
+	url        string
+	apiKey     string
+	apiKeyFile string
+	apiVersion string
+	timeout    time.Duration
+	configPath string
+	profile    string
+	debug      bool
+	check      bool
+
+	output       string
+	templateText string
+}
+
+// cli is the state of a run of the command.
+type cli struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+	getenv         func(key string) string
+	options        globalOptions
+
+	// flagSet contains the global flags given on the command line
+	flagSet map[string]bool
+
+	// resolved is true once the profile was applied to options
+	resolved bool
+
+	// template is the parsed template of --template
+	template *template.Template
+}
+
+func main() {
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv}
+	os.Exit(c.run(os.Args[1:]))
+}
+
+// globalFlags returns the flag set for the global flags.
+func (c *cli) globalFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("etherpad", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&c.options.url, "url", defaultURL, "base `URL` of the API, $"+EnvURL+" overrides the default")
+	fs.StringVar(&c.options.apiKey, "apikey", "", "the API `key` (default $"+EnvAPIKey+")")
+	fs.StringVar(&c.options.apiKeyFile, "apikey-file", "", "read the API key from `file` (default $"+EnvAPIKeyFile+")")
+	fs.StringVar(&c.options.apiVersion, "api-version", etherpadlite.CurrentVersion, "the API `version`")
+	fs.DurationVar(&c.options.timeout, "timeout", defaultTimeout, "timeout of the command")
+	fs.StringVar(&c.options.configPath, "config", "", "config `file` (default ~/.config/etherpad/config.toml)")
+	fs.StringVar(&c.options.profile, "profile", "", "`name` of the profile from the config file (default $"+EnvProfile+")")
+	fs.BoolVar(&c.options.debug, "debug", false, "print requests and responses to stderr")
+	fs.StringVar(&c.options.output, "output", outputData, "output `mode`: "+strings.Join(outputModes, ", "))
+	fs.StringVar(&c.options.templateText, "template", "", "Go `template` executed with the data, implies --output template")
+	fs.BoolVar(&c.options.check, "check", false, "only check the API key, exit with 0 if it's valid and 1 otherwise")
+	return fs
+}
+
+// run runs the command with the given arguments and returns the exit code.
+func (c *cli) run(args []string) int {
+	fs := c.globalFlags()
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			c.usage(fs)
+			return exitOK
+		}
+		fmt.Fprintln(c.stderr, "etherpad:", err)
+		return exitUsage
+	}
+	c.flagSet = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		c.flagSet[f.Name] = true
+	})
+	if err := c.parseOutput(); err != nil {
+		return c.exitCode(err)
+	}
+	if c.options.check {
+		if fs.NArg() > 0 {
+			return c.exitCode(usagef("--check doesn't accept a command"))
+		}
+		if err := c.check(); err != nil {
+			c.exitCode(err)
+			return exitError
+		}
+		return exitOK
+	}
+	if fs.NArg() == 0 {
+		c.usage(fs)
+		return exitUsage
+	}
+	name, cmdArgs := fs.Arg(0), fs.Args()[1:]
+	if name == "help" {
+		c.usage(fs)
+		return exitOK
+	}
+	return c.exitCode(c.dispatch(name, cmdArgs))
+}
+
+// dispatch runs the command name.
+func (c *cli) dispatch(name string, args []string) error {
+	cmd, found := lookupCommand(name)
+	if !found {
+		msg := fmt.Sprintf("unknown command %q", name)
+		if suggestions := suggest(name); len(suggestions) > 0 {
+			msg += fmt.Sprintf(", did you mean %s?", joinOr(suggestions))
+		}
+		return &usageError{msg: msg}
+	}
+	return cmd.run(c, args)
+}
+
+// exitCode prints err and returns the exit code for it.
+func (c *cli) exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	fmt.Fprintln(c.stderr, "etherpad:", redact(err.Error(), c.options.apiKey))
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		return exitUsage
+	}
+	if code, isPadErr := etherpadlite.IsEtherpadError(err); isPadErr {
+		return exitAPIError + int(code)
+	}
+	return exitError
+}
+
+// client returns the client configured by the global flags and the selected
+// profile.
+func (c *cli) client() (*etherpadlite.EtherpadLite, error) {
+	if err := c.resolveOptions(); err != nil {
+		return nil, err
+	}
+	return c.newClient(c.options)
+}
+
+// context returns the context for a command with the timeout of the global
+// flags or the selected profile.
+func (c *cli) context() (context.Context, context.CancelFunc) {
+	return c.options.context()
+}
+
+// newClient returns a client configured by the options.
+func (c *cli) newClient(o globalOptions) (*etherpadlite.EtherpadLite, error) {
+	pad, err := etherpadlite.New(o.apiKey,
+		etherpadlite.WithBaseURL(o.url),
+		etherpadlite.WithAPIVersion(o.apiVersion))
+	if err != nil {
+		return nil, err
+	}
+	pad.UseJSONNumber = true
+	if o.debug {
+		pad.Debug = &redactWriter{w: c.stderr, key: o.apiKey}
+	}
+	return pad, nil
+}
+
+// check calls checkToken with the configured API key.
+func (c *cli) check() error {
+	pad, err := c.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	return checkToken(ctx, pad)
+}
+
+// checkToken calls checkToken and returns an error if the API key is not
+// valid.
+func checkToken(ctx context.Context, pad *etherpadlite.EtherpadLite) error {
+	resp, err := pad.CheckToken(ctx)
+	if err != nil {
+		return err
+	}
+	return etherpadlite.ResponseToError(resp)
+}
+
+// redact replaces all occurrences of the API key in s.
+func redact(s, key string) string {
+	if key == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, key, etherpadlite.Redacted)
+}
+
+// redactWriter replaces the API key in everything written to w. The client
+// already redacts its debug output, this makes sure the key is not printed
+// even if it's part of a response.
+type redactWriter struct {
+	w   io.Writer
+	key string
+}
+
+func (w *redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, redact(string(p), w.key)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// context returns a context with the timeout of the options.
+func (o globalOptions) context() (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), o.timeout)
+}
+
+// usage prints the usage of the command.
+func (c *cli) usage(fs *flag.FlagSet) {
+	fmt.Fprintln(c.stdout, "Usage: etherpad [global flags] <command> [args] [flags]")
+	fmt.Fprintln(c.stdout)
+	fmt.Fprintln(c.stdout, "Global flags:")
+	fs.SetOutput(c.stdout)
+	fs.PrintDefaults()
+	fs.SetOutput(io.Discard)
+	fmt.Fprintln(c.stdout)
+	fmt.Fprintln(c.stdout, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(c.stdout, "  %s\n", cmd.usage())
+	}
+	fmt.Fprintln(c.stdout)
+	fmt.Fprintln(c.stdout, "Exit codes:")
+	fmt.Fprintf(c.stdout, "  %d  success\n", exitOK)
+	fmt.Fprintf(c.stdout, "  %d  error, for example the server is not reachable\n", exitError)
+	fmt.Fprintf(c.stdout, "  %d  invalid usage\n", exitUsage)
+	fmt.Fprintf(c.stdout, "  %d  etherpad returned wrong parameters\n", exitAPIError+int(etherpadlite.WrongParameters))
+	fmt.Fprintf(c.stdout, "  %d  etherpad returned an internal error\n", exitAPIError+int(etherpadlite.InternalError))
+	fmt.Fprintf(c.stdout, "  %d  etherpad returned no such function\n", exitAPIError+int(etherpadlite.NoSuchFunction))
+	fmt.Fprintf(c.stdout, "  %d  etherpad returned wrong API key\n", exitAPIError+int(etherpadlite.WrongAPIKey))
+}
@@ -0,0 +1,205 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+)
+
+// parseGlobalFlags parses the global flags like run does.
+func parseGlobalFlags(t *testing.T, c *cli, args []string) {
+	t.Helper()
+	fs := c.globalFlags()
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	c.flagSet = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		c.flagSet[f.Name] = true
+	})
+}
+
+// writeFile writes content to the file name in dir and returns its path.
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOptionsPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	envKeyFile := writeFile(t, dir, "env.key", "env-file-key\n")
+	flagKeyFile := writeFile(t, dir, "flag.key", "  flag-file-key \r\n\n")
+	profileKeyFile := writeFile(t, dir, "profile.key", "profile-file-key")
+	full := &profile{
+		URL:        "http://profile/api",
+		APIKey:     "profile-key",
+		APIKeyFile: profileKeyFile,
+		APIVersion: "1.2.1",
+		Timeout:    time.Minute,
+	}
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		profile *profile
+		url     string
+		key     string
+		version string
+		timeout time.Duration
+	}{
+		{"defaults", nil, nil, nil, defaultURL, "", etherpadlite.CurrentVersion, defaultTimeout},
+		{"profile", nil, nil, full, "http://profile/api", "profile-key", "1.2.1", time.Minute},
+		{"profile key file", nil, nil, &profile{APIKeyFile: profileKeyFile}, defaultURL, "profile-file-key", etherpadlite.CurrentVersion, defaultTimeout},
+		{
+			"env over profile", nil,
+			map[string]string{EnvURL: "http://env/api", EnvAPIKey: "env-key"},
+			full, "http://env/api", "env-key", "1.2.1", time.Minute,
+		},
+		{
+			"env key file over profile", nil,
+			map[string]string{EnvAPIKeyFile: envKeyFile},
+			full, "http://profile/api", "env-file-key", "1.2.1", time.Minute,
+		},
+		{
+			"env key over env key file", nil,
+			map[string]string{EnvAPIKey: "env-key", EnvAPIKeyFile: envKeyFile},
+			nil, defaultURL, "env-key", etherpadlite.CurrentVersion, defaultTimeout,
+		},
+		{
+			"flags over env and profile",
+			[]string{"--url", "http://flag/api", "--apikey", "flag-key", "--api-version", "1.2.12", "--timeout", "5s"},
+			map[string]string{EnvURL: "http://env/api", EnvAPIKey: "env-key"},
+			full, "http://flag/api", "flag-key", "1.2.12", 5 * time.Second,
+		},
+		{
+			"key file flag over env", []string{"--apikey-file", flagKeyFile},
+			map[string]string{EnvAPIKey: "env-key"},
+			full, "http://profile/api", "flag-file-key", "1.2.1", time.Minute,
+		},
+		{
+			"flags equal to the defaults still win", []string{"--url", defaultURL, "--timeout", defaultTimeout.String()},
+			nil, full, defaultURL, "profile-key", "1.2.1", defaultTimeout,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _, _ := testCLI("", tc.env)
+			parseGlobalFlags(t, c, tc.args)
+			o, err := c.optionsFor(tc.profile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.url != tc.url || o.apiKey != tc.key || o.apiVersion != tc.version || o.timeout != tc.timeout {
+				t.Errorf("expected url=%s key=%s version=%s timeout=%v, got url=%s key=%s version=%s timeout=%v",
+					tc.url, tc.key, tc.version, tc.timeout, o.url, o.apiKey, o.apiVersion, o.timeout)
+			}
+		})
+	}
+}
+
+func TestOptionsAPIKeyErrors(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := writeFile(t, dir, "pad.key", "key")
+	emptyFile := writeFile(t, dir, "empty.key", " \n")
+
+	c, _, _ := testCLI("", nil)
+	parseGlobalFlags(t, c, []string{"--apikey", "key", "--apikey-file", keyFile})
+	_, err := c.optionsFor(nil)
+	var usageErr *usageError
+	if !errors.As(err, &usageErr) {
+		t.Errorf("expected a usage error for --apikey and --apikey-file, got %v", err)
+	}
+
+	for _, path := range []string{emptyFile, filepath.Join(dir, "missing.key")} {
+		c, _, _ = testCLI("", nil)
+		parseGlobalFlags(t, c, []string{"--apikey-file", path})
+		if _, err := c.optionsFor(nil); err == nil {
+			t.Errorf("expected an error for key file %s", path)
+		}
+	}
+}
+
+func TestProfileSelection(t *testing.T) {
+	server := etherpadtest.NewServer(t)
+	dir := t.TempDir()
+	keyFile := writeFile(t, dir, "prod.key", server.APIKey+"\n")
+	configPath := writeFile(t, dir, "config.toml", "[profiles.prod]\n"+
+		"url = "+quote(server.BaseURL())+"\n"+
+		"apikey_file = "+quote(keyFile)+"\n"+
+		"[profiles.broken]\n"+
+		"url = \"http://127.0.0.1:1/api\"\n")
+	tests := []struct {
+		args []string
+		env  map[string]string
+		code int
+	}{
+		{[]string{"--config", configPath, "--profile", "prod", "--check"}, nil, exitOK},
+		{[]string{"--config", configPath, "--check"}, map[string]string{EnvProfile: "prod"}, exitOK},
+		{[]string{"--config", configPath, "--profile", "prod", "--check"}, map[string]string{EnvProfile: "broken"}, exitOK},
+		{[]string{"--config", configPath, "--profile", "prod", "--apikey", "wrong", "--check"}, nil, exitError},
+		{[]string{"--config", configPath, "--profile", "missing", "--check"}, nil, exitError},
+	}
+	for _, tc := range tests {
+		c, _, stderr := testCLI("", tc.env)
+		if code := c.run(tc.args); code != tc.code {
+			t.Errorf("%q (env %v): expected exit code %d, got %d: %s", tc.args, tc.env, tc.code, code, stderr.String())
+		}
+	}
+}
+
+func TestAPIKeyNeverPrinted(t *testing.T) {
+	const secret = "s3cr3t-api-key"
+	server := etherpadtest.NewServer(t)
+	dir := t.TempDir()
+	keyFile := writeFile(t, dir, "pad.key", secret)
+	configPath := writeFile(t, dir, "config.toml", "[profiles.p]\nurl = \"http://127.0.0.1:1/api\"\napikey = "+quote(secret)+"\n")
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+	}{
+		{"help with flag", []string{"--apikey", secret, "--help"}, nil},
+		{"help with env", []string{"--help"}, map[string]string{EnvAPIKey: secret}},
+		{"wrong key", []string{"--url", server.BaseURL(), "--apikey", secret, "--debug", "getText", "pad"}, nil},
+		{"wrong key from file", []string{"--url", server.BaseURL(), "--apikey-file", keyFile, "--debug", "checkToken"}, nil},
+		{"network error", []string{"--url", "http://127.0.0.1:1/api", "--debug", "getText", "pad"}, map[string]string{EnvAPIKey: secret}},
+		{"network error with profile", []string{"--config", configPath, "--profile", "p", "listAllPads"}, nil},
+		{"profiles test", []string{"--config", configPath, "profiles", "test"}, nil},
+		{"profiles list", []string{"--config", configPath, "profiles", "list"}, nil},
+		{"check", []string{"--url", server.BaseURL(), "--check"}, map[string]string{EnvAPIKey: secret}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, stdout, stderr := testCLI("", tc.env)
+			c.run(tc.args)
+			if out := stdout.String() + stderr.String(); strings.Contains(out, secret) {
+				t.Errorf("the API key was printed:\n%s", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// Output modes, see --output.
+const (
+	// outputData prints the data of the response as indented JSON.
+	outputData = "data"
+
+	// outputJSON prints the full response (code, message and data) as JSON.
+	outputJSON = "json"
+
+	// outputRaw prints the primary value of the data, see writeRaw.
+	outputRaw = "raw"
+
+	// outputTemplate executes the template given with --template.
+	outputTemplate = "template"
+)
+
+// outputModes contains all output modes.
+var outputModes = []string{outputData, outputJSON, outputRaw, outputTemplate}
+
+// keyedData contains the methods that return an object with IDs as keys, the
+// data of these methods is never reduced to its single value by writeRaw.
+var keyedData = map[string]bool{
+	"listSessionsOfGroup":  true,
+	"listSessionsOfAuthor": true,
+}
+
+// templateFuncs are the functions available in output templates.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	},
+	"join": func(values []interface{}, sep string) string {
+		s := make([]string, len(values))
+		for i, v := range values {
+			s[i] = rawString(v)
+		}
+		return strings.Join(s, sep)
+	},
+}
+
+// parseOutput checks the output flags and parses the template.
+func (c *cli) parseOutput() error {
+	if c.options.templateText != "" && !c.flagSet["output"] {
+		c.options.output = outputTemplate
+	}
+	switch c.options.output {
+	case outputData, outputJSON, outputRaw:
+		if c.options.templateText != "" {
+			return usagef("--template can only be used with --output template")
+		}
+		return nil
+	case outputTemplate:
+		if c.options.templateText == "" {
+			return usagef("--output template requires --template")
+		}
+		tmpl, err := template.New("output").Funcs(templateFuncs).Parse(c.options.templateText)
+		if err != nil {
+			return usagef("invalid template: %v", err)
+		}
+		c.template = tmpl
+		return nil
+	default:
+		return usagef("invalid output mode %q, must be one of %s", c.options.output, joinOr(outputModes))
+	}
+}
+
+// jsonResponse is the response as printed in json mode.
+type jsonResponse struct {
+	Code    etherpadlite.ReturnCode `json:"code"`
+	Message string                  `json:"message"`
+	Data    interface{}             `json:"data"`
+}
+
+// printResponse prints the response of method according to the output mode.
+// The response is printed even if it's an error in json mode, the returned
+// error is the error of the response.
+func (c *cli) printResponse(method string, resp *etherpadlite.Response) error {
+	if c.options.output == outputJSON {
+		var data interface{}
+		if resp.Data != nil {
+			data = resp.Data
+		}
+		if err := c.writeJSON(jsonResponse{Code: resp.Code, Message: resp.Message, Data: data}, false); err != nil {
+			return err
+		}
+		return etherpadlite.ResponseToError(resp)
+	}
+	if err := etherpadlite.ResponseToError(resp); err != nil {
+		return err
+	}
+	if resp.Data == nil {
+		return nil
+	}
+	return c.printValue(resp.Data, keyedData[method])
+}
+
+// printValue prints the result of a command according to the output mode,
+// keyed is true if v is an object with IDs as keys.
+func (c *cli) printValue(v interface{}, keyed bool) error {
+	switch c.options.output {
+	case outputJSON:
+		return c.writeJSON(v, false)
+	case outputRaw:
+		return writeRaw(c.stdout, v, keyed)
+	case outputTemplate:
+		var buf bytes.Buffer
+		if err := c.template.Execute(&buf, v); err != nil {
+			return err
+		}
+		if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		_, err := c.stdout.Write(buf.Bytes())
+		return err
+	default:
+		return c.writeJSON(v, true)
+	}
+}
+
+// writeJSON writes v as JSON followed by a newline.
+func (c *cli) writeJSON(v interface{}, indent bool) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetEscapeHTML(false)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// writeRaw writes the primary value of v to w: if v is an object with a
+// single field (and not keyed) the value of this field, otherwise v itself.
+// Arrays are written with one element per line, objects with one field per
+// line (the key and the value separated by a tab) and scalars on a line of
+// their own. Strings are written unquoted, nested objects and arrays as JSON.
+func writeRaw(w io.Writer, v interface{}, keyed bool) error {
+	if m, isMap := v.(map[string]interface{}); isMap && len(m) == 1 && !keyed {
+		for _, value := range m {
+			v = value
+		}
+	}
+	var buf strings.Builder
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		for _, elem := range v {
+			writeRawLine(&buf, rawString(elem))
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			writeRawLine(&buf, key+"\t"+rawString(v[key]))
+		}
+	default:
+		writeRawLine(&buf, rawString(v))
+	}
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// writeRawLine writes s followed by a newline if s doesn't end with one.
+func writeRawLine(buf *strings.Builder, s string) {
+	buf.WriteString(s)
+	if !strings.HasSuffix(s, "\n") {
+		buf.WriteByte('\n')
+	}
+}
+
+// rawString returns v as string: strings unquoted, null as empty string and
+// everything else as JSON.
+func rawString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(encoded)
+}
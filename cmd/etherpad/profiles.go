@@ -0,0 +1,219 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// profilesCommand manages the profiles in the config file.
+type profilesCommand struct{}
+
+func (profilesCommand) name() string {
+	return "profiles"
+}
+
+func (profilesCommand) usage() string {
+	return "profiles list | add <name> [--url url] [--apikey-file file] [--api-version version] [--timeout duration] | test [name...]"
+}
+
+func (cmd profilesCommand) run(c *cli, args []string) error {
+	if len(args) == 0 {
+		return usagef("missing subcommand, usage: %s", cmd.usage())
+	}
+	switch args[0] {
+	case "list":
+		if len(args) > 1 {
+			return usagef("profiles list expects no arguments")
+		}
+		return cmd.list(c)
+	case "add":
+		return cmd.add(c, args[1:])
+	case "test":
+		return cmd.test(c, args[1:])
+	default:
+		return usagef("unknown subcommand %q, usage: %s", args[0], cmd.usage())
+	}
+}
+
+// list prints the profiles, the API keys are never printed.
+func (profilesCommand) list(c *cli) error {
+	cfg, _, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+	selected := c.profileName()
+	w := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\tNAME\tURL\tAPI VERSION\tTIMEOUT\tAPI KEY")
+	for _, name := range cfg.names {
+		p := cfg.profiles[name]
+		marker := ""
+		if name == selected {
+			marker = "*"
+		}
+		key := "-"
+		switch {
+		case p.APIKey != "":
+			key = "(in config)"
+		case p.APIKeyFile != "":
+			key = p.APIKeyFile
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", marker, name, orDash(p.URL), orDash(p.APIVersion), orDash(durationString(p)), key)
+	}
+	return w.Flush()
+}
+
+func durationString(p *profile) string {
+	if p.Timeout == 0 {
+		return ""
+	}
+	return p.Timeout.String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// add appends a new profile to the config file. To keep the API key out of
+// the shell history it is not accepted as flag: either --apikey-file is given
+// or the key is read from stdin.
+func (cmd profilesCommand) add(c *cli, args []string) error {
+	fs := flag.NewFlagSet("profiles add", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	p := &profile{}
+	fs.StringVar(&p.URL, "url", "", "")
+	fs.StringVar(&p.APIKeyFile, "apikey-file", "", "")
+	fs.StringVar(&p.APIVersion, "api-version", "", "")
+	fs.DurationVar(&p.Timeout, "timeout", 0, "")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return usagef("profiles add: %v", err)
+	}
+	if len(positional) != 1 {
+		return usagef("profiles add expects the name of the profile")
+	}
+	name := positional[0]
+	if p.URL == "" {
+		return usagef("profiles add: --url is required")
+	}
+	cfg, path, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+	if _, exists := cfg.profiles[name]; exists {
+		return fmt.Errorf("profile %q already exists in %s", name, path)
+	}
+	if p.APIKeyFile == "" {
+		fmt.Fprint(c.stderr, "API key: ")
+		line, err := bufio.NewReader(c.stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		p.APIKey = strings.TrimSpace(line)
+	}
+	if err := appendProfile(path, name, p); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "added profile %s to %s\n", name, path)
+	return nil
+}
+
+// appendProfile appends the profile to the config file, the file is created
+// (readable only by the user, it may contain API keys) if it doesn't exist.
+func appendProfile(path, name string, p *profile) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	table := p.format(name)
+	switch {
+	case len(content) == 0:
+	case content[len(content)-1] != '\n':
+		table = "\n\n" + table
+	default:
+		table = "\n" + table
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(table); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// test calls checkToken for the given profiles (all profiles if no name is
+// given) and prints the result for each profile.
+func (profilesCommand) test(c *cli, names []string) error {
+	cfg, path, err := c.loadConfig()
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		names = cfg.names
+	}
+	failed := 0
+	for _, name := range names {
+		if err := testProfile(c, cfg, path, name); err != nil {
+			failed++
+			fmt.Fprintf(c.stdout, "%s: %v\n", name, err)
+			continue
+		}
+		fmt.Fprintf(c.stdout, "%s: ok\n", name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d profiles failed", failed, len(names))
+	}
+	return nil
+}
+
+// testProfile calls checkToken with the profile name.
+func testProfile(c *cli, cfg *config, path, name string) error {
+	p, found := cfg.profiles[name]
+	if !found {
+		return fmt.Errorf("profile not found in %s", path)
+	}
+	options, err := c.optionsFor(p)
+	if err != nil {
+		return err
+	}
+	pad, err := c.newClient(options)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := options.context()
+	defer cancel()
+	if err := checkToken(ctx, pad); err != nil {
+		return errors.New(redact(err.Error(), options.apiKey))
+	}
+	return nil
+}
@@ -0,0 +1,287 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// purgeCommand deletes pads that were not edited for a given time.
+type purgeCommand struct{}
+
+func (purgeCommand) name() string {
+	return "purge"
+}
+
+func (purgeCommand) usage() string {
+	return "purge --older-than age [--match regexp] [--dry-run] [--yes] [--concurrency n]"
+}
+
+// purgePad is a pad in the summary of purge.
+type purgePad struct {
+	PadID      string     `json:"padID"`
+	LastEdited *time.Time `json:"lastEdited,omitempty"`
+	Action     string     `json:"action"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// purgeSummary is the result of purge, printed in json and template mode.
+type purgeSummary struct {
+	Cutoff     time.Time  `json:"cutoff"`
+	DryRun     bool       `json:"dryRun"`
+	Candidates int        `json:"candidates"`
+	Deleted    int        `json:"deleted"`
+	Failed     int        `json:"failed"`
+	Pads       []purgePad `json:"pads"`
+}
+
+// run lists the pads not edited within --older-than (and matching --match),
+// asks for confirmation and deletes them. If stdin is not a terminal it's a
+// dry run unless --yes is given, so a cron job can't delete pads by accident.
+func (cmd purgeCommand) run(c *cli, args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	olderThan := fs.String("older-than", "", "delete pads not edited within this age")
+	match := fs.String("match", "", "only delete pads with an ID matching this regular expression")
+	dryRun := fs.Bool("dry-run", false, "only list the pads")
+	yes := fs.Bool("yes", false, "don't ask for confirmation")
+	concurrency := fs.Int("concurrency", etherpadlite.DefaultConcurrency, "number of concurrent requests")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return usagef("purge: %v, usage: %s", err, cmd.usage())
+	}
+	if len(positional) > 0 || *olderThan == "" {
+		return usagef("usage: %s", cmd.usage())
+	}
+	age, err := parseAge(*olderThan)
+	if err != nil {
+		return usagef("purge: %v", err)
+	}
+	opts := etherpadlite.PurgeOptions{DryRun: true, Concurrency: *concurrency}
+	if *match != "" {
+		re, err := regexp.Compile(*match)
+		if err != nil {
+			return usagef("purge: invalid --match: %v", err)
+		}
+		opts.Exclude = func(padID string) bool {
+			return !re.MatchString(padID)
+		}
+	}
+	if !*dryRun && !*yes && !isTerminal(c.stdin) {
+		*dryRun = true
+		fmt.Fprintln(c.stderr, "etherpad: stdin is not a terminal, doing a dry run (use --yes to delete the pads)")
+	}
+	pad, err := c.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	report, err := pad.PurgeOldPads(ctx, age, opts)
+	cancel()
+	if err != nil {
+		return err
+	}
+	results := make(map[string]etherpadlite.PurgeEntry)
+	var candidates []etherpadlite.PurgeEntry
+	for _, entry := range report.Pads {
+		switch entry.Action {
+		case etherpadlite.PurgeWouldDelete:
+			candidates = append(candidates, entry)
+			results[entry.PadID] = entry
+		case etherpadlite.PurgeFailed:
+			results[entry.PadID] = entry
+		}
+	}
+	if c.options.output == outputData {
+		if err := c.printCandidates(candidates); err != nil {
+			return err
+		}
+	}
+	cutoff := report.Cutoff
+	// deleteErr is returned after the summary, so that the pads deleted
+	// before the error are reported
+	var deleteErr error
+	if !*dryRun && len(candidates) > 0 {
+		if !*yes && !c.confirm(fmt.Sprintf("Delete %d pads?", len(candidates))) {
+			return etherpadlite.ErrNotConfirmed
+		}
+		selected := make(map[string]bool, len(candidates))
+		for _, entry := range candidates {
+			selected[entry.PadID] = true
+		}
+		// only the confirmed pads are deleted, and only if they were not
+		// edited in the meantime
+		opts.DryRun = false
+		opts.Exclude = func(padID string) bool {
+			return !selected[padID]
+		}
+		ctx, cancel := c.context()
+		defer cancel()
+		deleteReport, err := pad.PurgeOldPads(ctx, age, opts)
+		if deleteReport != nil {
+			cutoff = deleteReport.Cutoff
+			for _, entry := range deleteReport.Pads {
+				if selected[entry.PadID] {
+					results[entry.PadID] = entry
+				}
+			}
+		}
+		deleteErr = err
+	}
+	summary := newPurgeSummary(cutoff, *dryRun, len(candidates), results)
+	if err := c.printPurgeSummary(summary); err != nil {
+		return err
+	}
+	if deleteErr != nil {
+		return deleteErr
+	}
+	errs := make(map[string]error)
+	for _, entry := range results {
+		if entry.Err != nil {
+			errs[entry.PadID] = entry.Err
+		}
+	}
+	return c.reportErrors("pads", errs)
+}
+
+// newPurgeSummary returns the summary for the results of purge.
+func newPurgeSummary(cutoff time.Time, dryRun bool, candidates int, results map[string]etherpadlite.PurgeEntry) *purgeSummary {
+	summary := &purgeSummary{Cutoff: cutoff, DryRun: dryRun, Candidates: candidates, Pads: []purgePad{}}
+	for _, entry := range results {
+		p := purgePad{PadID: entry.PadID, Action: string(entry.Action)}
+		if !entry.LastEdited.IsZero() {
+			lastEdited := entry.LastEdited
+			p.LastEdited = &lastEdited
+		}
+		if entry.Err != nil {
+			p.Error = entry.Err.Error()
+		}
+		switch entry.Action {
+		case etherpadlite.PurgeDeleted:
+			summary.Deleted++
+		case etherpadlite.PurgeFailed:
+			summary.Failed++
+		}
+		summary.Pads = append(summary.Pads, p)
+	}
+	sort.Slice(summary.Pads, func(i, j int) bool {
+		return summary.Pads[i].PadID < summary.Pads[j].PadID
+	})
+	return summary
+}
+
+// printCandidates prints the pads that will be deleted, the oldest first.
+func (c *cli) printCandidates(candidates []etherpadlite.PurgeEntry) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].LastEdited.Before(candidates[j].LastEdited)
+	})
+	t := &table{header: []string{"PAD ID", "LAST EDITED", "AGE"}}
+	now := time.Now()
+	for _, entry := range candidates {
+		t.add(nil, entry.PadID, entry.LastEdited.Local().Format("2006-01-02 15:04"), relativeTime(entry.LastEdited, now))
+	}
+	return t.render(c.stdout, true, c.terminalWidth())
+}
+
+// printPurgeSummary prints the summary according to the output mode, in raw
+// mode the IDs of the deleted pads (the pads that would be deleted in a dry
+// run). Candidates that were not processed because the deletion was aborted
+// are not printed in raw mode.
+func (c *cli) printPurgeSummary(summary *purgeSummary) error {
+	switch c.options.output {
+	case outputJSON, outputTemplate:
+		return c.printValue(summary, false)
+	case outputRaw:
+		var b strings.Builder
+		for _, p := range summary.Pads {
+			if p.Action == string(etherpadlite.PurgeDeleted) || (summary.DryRun && p.Action == string(etherpadlite.PurgeWouldDelete)) {
+				fmt.Fprintln(&b, p.PadID)
+			}
+		}
+		_, err := io.WriteString(c.stdout, b.String())
+		return err
+	}
+	cutoff := summary.Cutoff.Local().Format("2006-01-02 15:04")
+	var err error
+	switch {
+	case summary.DryRun:
+		_, err = fmt.Fprintf(c.stdout, "dry run: %d pads not edited since %s would be deleted\n", summary.Candidates, cutoff)
+	case summary.Candidates == 0:
+		_, err = fmt.Fprintf(c.stdout, "no pads not edited since %s\n", cutoff)
+	default:
+		_, err = fmt.Fprintf(c.stdout, "deleted %d of %d pads not edited since %s\n", summary.Deleted, summary.Candidates, cutoff)
+	}
+	return err
+}
+
+// confirm asks the question on stderr and returns true if the answer read
+// from stdin is yes.
+func (c *cli) confirm(question string) bool {
+	fmt.Fprintf(c.stderr, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(c.stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// isTerminal returns true if r is a terminal.
+func isTerminal(r io.Reader) bool {
+	f, isFile := r.(*os.File)
+	if !isFile {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// parseAge parses an age like "180d" or "2w", all units of time.ParseDuration
+// are accepted as well.
+func parseAge(s string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	var age time.Duration
+	if unit, known := units[s[len(s)-1:]]; known {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		age = time.Duration(n) * unit
+	} else {
+		var err error
+		if age, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+	}
+	if age <= 0 {
+		return 0, fmt.Errorf("age must be positive, got %q", s)
+	}
+	return age, nil
+}
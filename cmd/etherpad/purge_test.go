@@ -0,0 +1,99 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+)
+
+// newPurgeServer returns a server with the old pads old-a, old-b and old-c
+// (last edited a year ago, in this order) and the pad new.
+func newPurgeServer(t *testing.T) *etherpadtest.Server {
+	t.Helper()
+	server := etherpadtest.NewServer(t)
+	clock := etherpadtest.NewFakeClock(time.Now().AddDate(-1, 0, 0))
+	server.Store.Clock = clock
+	for _, padID := range []string{"old-a", "old-b", "old-c"} {
+		if err := server.Store.AddPad(padID, "text"); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(time.Hour)
+	}
+	server.Store.Clock = nil
+	if err := server.Store.AddPad("new", "text"); err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+// padsLeft returns the IDs of the pads on the server.
+func padsLeft(server *etherpadtest.Server) string {
+	return strings.Join(server.Store.PadIDs(), ",")
+}
+
+func TestPurgeNotTerminal(t *testing.T) {
+	server := newPurgeServer(t)
+	// stdin is not an *os.File, so it's not a terminal: even an answer of
+	// "y" must not delete anything without --yes
+	code, stdout, stderr := runAgainst(server, "y\n", "purge", "--older-than", "30d")
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	if !strings.Contains(stderr, "not a terminal") || !strings.Contains(stdout, "dry run: 3 pads") {
+		t.Errorf("expected a dry run, got output %q and %q", stdout, stderr)
+	}
+	if left := padsLeft(server); left != "new,old-a,old-b,old-c" {
+		t.Errorf("expected no pad to be deleted, got pads %s", left)
+	}
+	if n := len(server.RequestsFor("deletePad")); n != 0 {
+		t.Errorf("expected no deletePad request, got %d", n)
+	}
+}
+
+func TestPurgeYes(t *testing.T) {
+	server := newPurgeServer(t)
+	code, stdout, stderr := runAgainst(server, "", "purge", "--older-than", "30d", "--match", "^old-[ab]$", "--yes")
+	if code != exitOK {
+		t.Fatalf("expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, "deleted 2 of 2 pads") {
+		t.Errorf("unexpected output %q", stdout)
+	}
+	if left := padsLeft(server); left != "new,old-c" {
+		t.Errorf("expected old-a and old-b to be deleted, got pads %s", left)
+	}
+}
+
+func TestPurgePartialSummary(t *testing.T) {
+	server := newPurgeServer(t)
+	// the second deletion hangs until the timeout
+	server.FailNext("deletePad", etherpadtest.Failure{})
+	server.FailNext("deletePad", etherpadtest.Failure{Delay: time.Minute})
+	code, stdout, _ := runAgainst(server, "", "--timeout", "500ms", "--output", "raw",
+		"purge", "--older-than", "30d", "--yes", "--concurrency", "1")
+	if code != exitError {
+		t.Errorf("expected exit code %d, got %d", exitError, code)
+	}
+	if stdout != "old-a\n" {
+		t.Errorf("expected the deleted pad old-a to be reported, got %q", stdout)
+	}
+	if left := padsLeft(server); left != "new,old-b,old-c" {
+		t.Errorf("expected only old-a to be deleted, got pads %s", left)
+	}
+}
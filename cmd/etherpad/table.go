@@ -0,0 +1,191 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Layout of tables.
+const (
+	// columnGap is the number of spaces between two columns.
+	columnGap = 2
+
+	// minColumnWidth is the width columns are shrunk to at most if the
+	// table is wider than the terminal.
+	minColumnWidth = 8
+)
+
+// table is the result of a listing command. In the default output mode it's
+// printed as aligned table, see printTable for the other modes.
+type table struct {
+	header []string
+	rows   [][]string
+
+	// values contains a value for each row, it's printed in json and
+	// template mode
+	values []interface{}
+}
+
+// add adds a row with the cells and the value printed in json and template
+// mode.
+func (t *table) add(value interface{}, cells ...string) {
+	t.rows = append(t.rows, cells)
+	t.values = append(t.values, value)
+}
+
+// printTable prints t according to the output mode: as aligned table fitting
+// the terminal width, as tab separated lines without header in raw mode and
+// the values in json and template mode.
+func (c *cli) printTable(t *table, header bool) error {
+	switch c.options.output {
+	case outputJSON, outputTemplate:
+		values := t.values
+		if values == nil {
+			values = []interface{}{}
+		}
+		return c.printValue(values, false)
+	case outputRaw:
+		var b strings.Builder
+		for _, row := range t.rows {
+			b.WriteString(strings.Join(row, "\t"))
+			b.WriteByte('\n')
+		}
+		_, err := io.WriteString(c.stdout, b.String())
+		return err
+	default:
+		return t.render(c.stdout, header, c.terminalWidth())
+	}
+}
+
+// render writes the table with aligned columns to w. If width is positive
+// the widest columns are shrunk (and their cells truncated) until the table
+// fits.
+func (t *table) render(w io.Writer, header bool, width int) error {
+	rows := t.rows
+	if header {
+		rows = append([][]string{t.header}, rows...)
+	}
+	widths := make([]int, len(t.header))
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	if width > 0 {
+		shrinkColumns(widths, width)
+	}
+	var b strings.Builder
+	for _, row := range rows {
+		for i, cell := range row {
+			cell = truncate(cell, widths[i])
+			b.WriteString(cell)
+			if i < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)+columnGap))
+			}
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// shrinkColumns shrinks the widest column until the columns fit into width
+// or all columns have minColumnWidth.
+func shrinkColumns(widths []int, width int) {
+	total := columnGap * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for total > width {
+		widest := 0
+		for i, w := range widths {
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minColumnWidth {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+// truncate shortens s to width runes, the last rune is replaced by an
+// ellipsis if s is truncated.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+// terminalWidth returns the width of the terminal stdout is connected to,
+// $COLUMNS takes precedence. It returns 0 if stdout is not a terminal.
+func (c *cli) terminalWidth() int {
+	if columns, err := strconv.Atoi(c.getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	if f, isFile := c.stdout.(*os.File); isFile {
+		return terminalWidth(f)
+	}
+	return 0
+}
+
+// relativeTime formats t relative to now, for example "3h ago" or "in 2d".
+// The zero time is formatted as "-".
+func relativeTime(t, now time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	d := now.Sub(t)
+	if d < 0 {
+		return "in " + shortDuration(-d)
+	}
+	if d < time.Minute {
+		return "just now"
+	}
+	return shortDuration(d) + " ago"
+}
+
+// shortDuration formats d in the largest unit (up to years) that fits.
+func shortDuration(d time.Duration) string {
+	const (
+		day  = 24 * time.Hour
+		year = 365 * day
+	)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", d/time.Second)
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	case d < 2*day:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d < year:
+		return fmt.Sprintf("%dd", d/day)
+	default:
+		return fmt.Sprintf("%dy", d/year)
+	}
+}
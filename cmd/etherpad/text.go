@@ -0,0 +1,154 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// getTextCommand replaces the generic command of getText: in the default
+// output mode the text is written to stdout exactly as returned by etherpad,
+// so it can be redirected to a file.
+type getTextCommand struct {
+	apiCommand
+}
+
+func (cmd getTextCommand) run(c *cli, args []string) error {
+	if c.options.output != outputData {
+		return cmd.apiCommand.run(c, args)
+	}
+	params, err := cmd.parseArgs(args)
+	if err != nil {
+		return err
+	}
+	var revs []int
+	if rev, hasRev := params["rev"]; hasRev {
+		n, err := strconv.Atoi(rev.(string))
+		if err != nil || n < 0 {
+			return usagef("getText: invalid revision %q", rev)
+		}
+		revs = append(revs, n)
+	}
+	pad, err := c.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	text, err := pad.GetTextContent(ctx, params["padID"].(string), revs...)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(c.stdout, text)
+	return err
+}
+
+// setTextCommand replaces the generic command of setText: the text is read
+// from stdin if it's "-" or not given, with --append the text is appended
+// (appendText) instead of replacing the text of the pad.
+type setTextCommand struct {
+	apiCommand
+}
+
+func (cmd setTextCommand) usage() string {
+	return "setText <padID> [text|-] [--authorId authorId] [--append]"
+}
+
+// errBinaryInput is returned by setText if the text is not valid UTF-8 or
+// contains NUL bytes, etherpad would store it modified.
+var errBinaryInput = errors.New("input is binary, only UTF-8 text can be stored in a pad")
+
+func (cmd setTextCommand) run(c *cli, args []string) error {
+	fs := flag.NewFlagSet("setText", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var authorID string
+	fs.StringVar(&authorID, "authorId", "", "author of the change")
+	fs.StringVar(&authorID, "authorid", "", "author of the change")
+	appendText := fs.Bool("append", false, "append the text instead of replacing it")
+	positional, err := parseInterleaved(fs, args)
+	if err != nil {
+		return usagef("setText: %v, usage: %s", err, cmd.usage())
+	}
+	if len(positional) < 1 || len(positional) > 2 {
+		return usagef("usage: %s", cmd.usage())
+	}
+	padID := positional[0]
+	var text string
+	if len(positional) == 1 || positional[1] == "-" {
+		if text, err = readText(c.stdin); err != nil {
+			return err
+		}
+	} else {
+		text = positional[1]
+	}
+	pad, err := c.client()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := c.context()
+	defer cancel()
+	var author interface{} = etherpadlite.OptionalParam
+	if authorID != "" {
+		author = authorID
+	}
+	method, call := "setText", pad.SetTextAs
+	if *appendText {
+		method, call = "appendText", pad.AppendTextAs
+	}
+	resp, err := call(ctx, padID, text, author)
+	if err != nil {
+		return err
+	}
+	return c.printResponse(method, resp)
+}
+
+// readText reads the text for a pad from r. It returns errBinaryInput if the
+// input is not valid UTF-8 or contains NUL bytes.
+func readText(r io.Reader) (string, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("can't read text from stdin: %w", err)
+	}
+	if i := bytes.IndexByte(content, 0); i >= 0 {
+		return "", fmt.Errorf("%w: NUL byte at offset %d", errBinaryInput, i)
+	}
+	if !utf8.Valid(content) {
+		return "", fmt.Errorf("%w: invalid UTF-8 at offset %d", errBinaryInput, invalidUTF8Offset(content))
+	}
+	return string(content), nil
+}
+
+// invalidUTF8Offset returns the offset of the first invalid UTF-8 sequence in
+// b.
+func invalidUTF8Offset(b []byte) int {
+	offset := 0
+	for offset < len(b) {
+		r, size := utf8.DecodeRune(b[offset:])
+		if r == utf8.RuneError && size == 1 {
+			return offset
+		}
+		offset += size
+	}
+	return offset
+}
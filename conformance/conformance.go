@@ -0,0 +1,433 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conformance contains behavioural tests for implementations of
+// etherpadlite.API. They describe how etherpad behaves and can be run against
+// the mock, the fake server of package etherpadtest and a real etherpad:
+//
+//	func TestMockConformance(t *testing.T) {
+//		conformance.Run(t, func() etherpadlite.API {
+//			return mock.New()
+//		})
+//	}
+//
+// The tests only create pads, groups and authors with unique names and delete
+// them afterwards, so they can be run against a server that is used
+// otherwise. Sessions and authors can't be deleted with the API, running the
+// suite against a real server leaves some of them behind.
+package conformance
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// test is a single conformance test.
+type test struct {
+	name string
+	fn   func(t *testing.T, s *suite)
+}
+
+// tests are all conformance tests.
+var tests = []test{
+	{"PadLifecycle", testPadLifecycle},
+	{"CreateExistingPad", testCreateExistingPad},
+	{"MissingPad", testMissingPad},
+	{"Revisions", testRevisions},
+	{"AppendText", testAppendText},
+	{"CopyAndMovePad", testCopyAndMovePad},
+	{"ReadOnlyID", testReadOnlyID},
+	{"GroupPadLifecycle", testGroupPadLifecycle},
+	{"GroupMapper", testGroupMapper},
+	{"MissingGroup", testMissingGroup},
+	{"Authors", testAuthors},
+	{"Sessions", testSessions},
+	{"SessionExpiry", testSessionExpiry},
+	{"ChatOrdering", testChatOrdering},
+	{"NoSuchFunction", testNoSuchFunction},
+}
+
+// suite is passed to each test.
+type suite struct {
+	ctx    context.Context
+	api    etherpadlite.API
+	prefix string
+}
+
+// id returns a unique ID with the given name.
+func (s *suite) id(name string) string {
+	return s.prefix + name
+}
+
+// check returns the error of a call returning a Response: err if it's not nil
+// and the EtherpadError of the response otherwise. This way the tests work
+// with and without RaiseEtherpadErrors.
+func check(resp *etherpadlite.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	return etherpadlite.ResponseToError(resp)
+}
+
+// must fails the test if err is not nil.
+func must(t *testing.T, err error, format string, args ...interface{}) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", fmt.Sprintf(format, args...), err)
+	}
+}
+
+// expectError fails the test if err doesn't match target.
+func expectError(t *testing.T, err, target error, what string) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%s: expected error %q, got %v", what, target, err)
+	}
+}
+
+// createPad creates a pad with a unique ID and deletes it once the test
+// finished.
+func (s *suite) createPad(t *testing.T, name, text string) string {
+	t.Helper()
+	padID := s.id(name)
+	must(t, check(s.api.CreatePad(s.ctx, padID, text)), "creating pad %s", padID)
+	t.Cleanup(func() {
+		s.api.DeletePad(context.Background(), padID)
+	})
+	return padID
+}
+
+// createGroup creates a group with a unique mapper and deletes it once the
+// test finished.
+func (s *suite) createGroup(t *testing.T, name string) string {
+	t.Helper()
+	groupID, err := s.api.CreateGroupIDFor(s.ctx, s.id(name))
+	must(t, err, "creating group")
+	t.Cleanup(func() {
+		s.api.DeleteGroup(context.Background(), groupID)
+	})
+	return groupID
+}
+
+// expectText fails the test if the text of the pad is not text.
+func (s *suite) expectText(t *testing.T, padID, text string, rev ...int) {
+	t.Helper()
+	got, err := s.api.GetTextContent(s.ctx, padID, rev...)
+	must(t, err, "getting text of %s", padID)
+	if got != text {
+		t.Errorf("text of %s: expected %q, got %q", padID, text, got)
+	}
+}
+
+// Run runs all conformance tests as subtests of t. newClient is called for
+// each test, the clients may share their state (the tests don't depend on
+// an empty server).
+func Run(t *testing.T, newClient func() etherpadlite.API) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	prefix := "conformance-" + hex.EncodeToString(buf) + "-"
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			test.fn(t, &suite{ctx: ctx, api: newClient(), prefix: prefix + test.name + "-"})
+		})
+	}
+}
+
+func testPadLifecycle(t *testing.T, s *suite) {
+	padID := s.createPad(t, "pad", "Hello")
+	// etherpad terminates the text with a newline
+	s.expectText(t, padID, "Hello\n")
+	exists, err := s.api.PadExists(s.ctx, padID)
+	must(t, err, "checking if %s exists", padID)
+	if !exists {
+		t.Errorf("pad %s doesn't exist after creation", padID)
+	}
+	ids, err := s.api.ListAllPadIDs(s.ctx)
+	must(t, err, "listing pads")
+	if !contains(ids, padID) {
+		t.Errorf("listAllPads doesn't contain %s", padID)
+	}
+	must(t, check(s.api.DeletePad(s.ctx, padID)), "deleting %s", padID)
+	exists, err = s.api.PadExists(s.ctx, padID)
+	must(t, err, "checking if %s exists", padID)
+	if exists {
+		t.Errorf("pad %s exists after deletion", padID)
+	}
+}
+
+func testCreateExistingPad(t *testing.T, s *suite) {
+	padID := s.createPad(t, "pad", "first")
+	err := check(s.api.CreatePad(s.ctx, padID, "second"))
+	expectError(t, err, etherpadlite.ErrPadExists, "creating an existing pad")
+	expectError(t, err, etherpadlite.ErrWrongParameters, "creating an existing pad")
+	s.expectText(t, padID, "first\n")
+}
+
+func testMissingPad(t *testing.T, s *suite) {
+	padID := s.id("missing")
+	_, err := s.api.GetTextContent(s.ctx, padID)
+	expectError(t, err, etherpadlite.ErrPadNotFound, "getText of a missing pad")
+	expectError(t, err, etherpadlite.ErrWrongParameters, "getText of a missing pad")
+	err = check(s.api.SetText(s.ctx, padID, "text"))
+	expectError(t, err, etherpadlite.ErrPadNotFound, "setText of a missing pad")
+	err = check(s.api.DeletePad(s.ctx, padID))
+	expectError(t, err, etherpadlite.ErrPadNotFound, "deletePad of a missing pad")
+	_, err = s.api.RevisionsCount(s.ctx, padID)
+	expectError(t, err, etherpadlite.ErrPadNotFound, "getRevisionsCount of a missing pad")
+}
+
+func testRevisions(t *testing.T, s *suite) {
+	padID := s.createPad(t, "pad", "one")
+	initial, err := s.api.RevisionsCount(s.ctx, padID)
+	must(t, err, "getting revisions")
+	must(t, check(s.api.SetText(s.ctx, padID, "two")), "setting text")
+	revisions, err := s.api.RevisionsCount(s.ctx, padID)
+	must(t, err, "getting revisions")
+	if revisions <= initial {
+		t.Fatalf("setText didn't create a revision: %d before, %d after", initial, revisions)
+	}
+	s.expectText(t, padID, "two\n")
+	s.expectText(t, padID, "one\n", initial)
+	_, err = s.api.GetTextContent(s.ctx, padID, revisions+1)
+	expectError(t, err, etherpadlite.ErrWrongParameters, "getText of a future revision")
+}
+
+func testAppendText(t *testing.T, s *suite) {
+	padID := s.createPad(t, "pad", "Hello")
+	must(t, check(s.api.AppendText(s.ctx, padID, "World")), "appending text")
+	s.expectText(t, padID, "Hello\nWorld\n")
+}
+
+func testCopyAndMovePad(t *testing.T, s *suite) {
+	src := s.createPad(t, "src", "content")
+	dst := s.id("dst")
+	t.Cleanup(func() {
+		s.api.DeletePad(context.Background(), dst)
+	})
+	must(t, check(s.api.CopyPad(s.ctx, src, dst, false)), "copying pad")
+	s.expectText(t, dst, "content\n")
+	err := check(s.api.CopyPad(s.ctx, src, dst, false))
+	expectError(t, err, etherpadlite.ErrWrongParameters, "copying to an existing pad without force")
+	moved := s.id("moved")
+	t.Cleanup(func() {
+		s.api.DeletePad(context.Background(), moved)
+	})
+	must(t, check(s.api.MovePad(s.ctx, dst, moved, false)), "moving pad")
+	s.expectText(t, moved, "content\n")
+	_, err = s.api.GetTextContent(s.ctx, dst)
+	expectError(t, err, etherpadlite.ErrPadNotFound, "getText of a moved pad")
+}
+
+func testReadOnlyID(t *testing.T, s *suite) {
+	padID := s.createPad(t, "pad", "text")
+	readOnlyID, err := s.api.ReadOnlyID(s.ctx, padID)
+	must(t, err, "getting read-only ID")
+	if readOnlyID == "" || readOnlyID == padID {
+		t.Fatalf("invalid read-only ID %q", readOnlyID)
+	}
+	got, err := s.api.PadIDFromReadOnly(s.ctx, readOnlyID)
+	must(t, err, "getting pad ID")
+	if got != padID {
+		t.Errorf("getPadID: expected %s, got %s", padID, got)
+	}
+}
+
+func testGroupPadLifecycle(t *testing.T, s *suite) {
+	groupID := s.createGroup(t, "group")
+	padID, created, err := s.api.EnsureGroupPad(s.ctx, groupID, "pad", "text")
+	must(t, err, "creating group pad")
+	if !created {
+		t.Error("new group pad was not created")
+	}
+	if padID != groupID+"$pad" {
+		t.Errorf("invalid group pad ID %s", padID)
+	}
+	if _, created, err = s.api.EnsureGroupPad(s.ctx, groupID, "pad", "other"); err != nil || created {
+		t.Errorf("creating the pad again: created = %v, err = %v", created, err)
+	}
+	err = check(s.api.CreateGroupPad(s.ctx, groupID, "pad", "other"))
+	expectError(t, err, etherpadlite.ErrPadExists, "creating an existing group pad")
+	s.expectText(t, padID, "text\n")
+	names, err := s.api.ListGroupPadNames(s.ctx, groupID)
+	must(t, err, "listing group pads")
+	if len(names) != 1 || names[0] != "pad" {
+		t.Errorf("listPads: expected [pad], got %v", names)
+	}
+	must(t, check(s.api.SetPublicStatus(s.ctx, padID, true)), "setting public status")
+	public, err := s.api.PublicStatus(s.ctx, padID)
+	must(t, err, "getting public status")
+	if !public {
+		t.Error("group pad is not public after setPublicStatus")
+	}
+	must(t, check(s.api.DeleteGroup(s.ctx, groupID)), "deleting group")
+	_, err = s.api.GetTextContent(s.ctx, padID)
+	expectError(t, err, etherpadlite.ErrPadNotFound, "getText of a pad of a deleted group")
+	_, err = s.api.ListGroupPadIDs(s.ctx, groupID)
+	expectError(t, err, etherpadlite.ErrGroupNotFound, "listPads of a deleted group")
+}
+
+func testGroupMapper(t *testing.T, s *suite) {
+	groupID := s.createGroup(t, "group")
+	again, err := s.api.CreateGroupIDFor(s.ctx, s.id("group"))
+	must(t, err, "creating group again")
+	if again != groupID {
+		t.Errorf("createGroupIfNotExistsFor returned %s and %s for the same mapper", groupID, again)
+	}
+	groups, err := s.api.AllGroupIDs(s.ctx)
+	must(t, err, "listing groups")
+	if !contains(groups, groupID) {
+		t.Errorf("listAllGroups doesn't contain %s", groupID)
+	}
+}
+
+func testMissingGroup(t *testing.T, s *suite) {
+	groupID := s.createGroup(t, "group")
+	must(t, check(s.api.DeleteGroup(s.ctx, groupID)), "deleting group")
+	err := check(s.api.DeleteGroup(s.ctx, groupID))
+	expectError(t, err, etherpadlite.ErrGroupNotFound, "deleting a missing group")
+	err = check(s.api.CreateGroupPad(s.ctx, groupID, "pad", "text"))
+	expectError(t, err, etherpadlite.ErrGroupNotFound, "createGroupPad in a missing group")
+}
+
+func testAuthors(t *testing.T, s *suite) {
+	authorID, err := s.api.EnsureAuthorID(s.ctx, s.id("author"), "Alice")
+	must(t, err, "creating author")
+	again, err := s.api.EnsureAuthorID(s.ctx, s.id("author"), "Alice")
+	must(t, err, "creating author again")
+	if again != authorID {
+		t.Errorf("createAuthorIfNotExistsFor returned %s and %s for the same mapper", authorID, again)
+	}
+	name, err := s.api.AuthorName(s.ctx, authorID)
+	must(t, err, "getting author name")
+	if name != "Alice" {
+		t.Errorf("getAuthorName: expected Alice, got %q", name)
+	}
+	padID := s.createPad(t, "pad", "text")
+	must(t, check(s.api.SetTextAs(s.ctx, padID, "by alice", authorID)), "setting text as author")
+	authors, err := s.api.AuthorsOfPad(s.ctx, padID)
+	must(t, err, "listing authors")
+	if !contains(authors, authorID) {
+		t.Errorf("listAuthorsOfPad doesn't contain %s: %v", authorID, authors)
+	}
+	pads, err := s.api.PadIDsOfAuthor(s.ctx, authorID)
+	must(t, err, "listing pads of author")
+	if !contains(pads, padID) {
+		t.Errorf("listPadsOfAuthor doesn't contain %s: %v", padID, pads)
+	}
+}
+
+func testSessions(t *testing.T, s *suite) {
+	groupID := s.createGroup(t, "group")
+	authorID, err := s.api.EnsureAuthorID(s.ctx, s.id("author"), "Bob")
+	must(t, err, "creating author")
+	validUntil := time.Now().Add(time.Hour).Truncate(time.Second)
+	session, err := s.api.CreateSessionTyped(s.ctx, groupID, authorID, validUntil)
+	must(t, err, "creating session")
+	got, err := s.api.GetSession(s.ctx, session.ID)
+	must(t, err, "getting session")
+	if got.GroupID != groupID || got.AuthorID != authorID || !got.ValidUntil.Equal(validUntil) {
+		t.Errorf("getSessionInfo: expected %v, got %v", session, got)
+	}
+	sessions, err := s.api.ListGroupSessions(s.ctx, groupID)
+	must(t, err, "listing group sessions")
+	if _, has := sessions[session.ID]; !has {
+		t.Errorf("listSessionsOfGroup doesn't contain %s", session.ID)
+	}
+	sessions, err = s.api.ListAuthorSessions(s.ctx, authorID)
+	must(t, err, "listing author sessions")
+	if _, has := sessions[session.ID]; !has {
+		t.Errorf("listSessionsOfAuthor doesn't contain %s", session.ID)
+	}
+	must(t, check(s.api.DeleteSession(s.ctx, session.ID)), "deleting session")
+	_, err = s.api.GetSession(s.ctx, session.ID)
+	expectError(t, err, etherpadlite.ErrWrongParameters, "getSessionInfo of a deleted session")
+}
+
+func testSessionExpiry(t *testing.T, s *suite) {
+	groupID := s.createGroup(t, "group")
+	authorID, err := s.api.EnsureAuthorID(s.ctx, s.id("author"), "Carol")
+	must(t, err, "creating author")
+	_, err = s.api.CreateSessionTyped(s.ctx, groupID, authorID, time.Now().Add(-time.Hour))
+	expectError(t, err, etherpadlite.ErrWrongParameters, "creating a session in the past")
+	session, err := s.api.CreateSessionFor(s.ctx, groupID, authorID, time.Minute)
+	must(t, err, "creating session")
+	if remaining := time.Until(session.ValidUntil); remaining <= 0 || remaining > time.Minute {
+		t.Errorf("session created for one minute is valid until %s", session.ValidUntil)
+	}
+}
+
+func testChatOrdering(t *testing.T, s *suite) {
+	padID := s.createPad(t, "pad", "text")
+	authorID, err := s.api.EnsureAuthorID(s.ctx, s.id("author"), "Dave")
+	must(t, err, "creating author")
+	head, err := s.api.ChatHead(s.ctx, padID)
+	must(t, err, "getting chat head")
+	if head != -1 {
+		t.Errorf("chat head of a new pad: expected -1, got %d", head)
+	}
+	texts := []string{"first", "second", "third"}
+	// the time is given in milliseconds
+	start := time.Now().Add(-time.Hour).UnixNano() / int64(time.Millisecond)
+	for i, text := range texts {
+		params := map[string]interface{}{"padID": padID, "text": text, "authorID": authorID, "time": start + int64(i)*1000}
+		must(t, check(s.api.Call(s.ctx, "appendChatMessage", params)), "appending chat message")
+	}
+	head, err = s.api.ChatHead(s.ctx, padID)
+	must(t, err, "getting chat head")
+	if head != len(texts)-1 {
+		t.Errorf("chat head: expected %d, got %d", len(texts)-1, head)
+	}
+	messages, err := s.api.FullChatHistory(s.ctx, padID)
+	must(t, err, "getting chat history")
+	if len(messages) != len(texts) {
+		t.Fatalf("chat history: expected %d messages, got %d", len(texts), len(messages))
+	}
+	for i, msg := range messages {
+		if msg.Text != texts[i] || msg.AuthorID != authorID {
+			t.Errorf("message %d: expected %q by %s, got %q by %s", i, texts[i], authorID, msg.Text, msg.AuthorID)
+		}
+	}
+	messages, err = s.api.ChatHistory(s.ctx, padID, 1, 2)
+	must(t, err, "getting chat history")
+	if len(messages) != 2 || messages[0].Text != "second" || messages[1].Text != "third" {
+		t.Errorf("chat history 1-2: expected second and third, got %v", messages)
+	}
+}
+
+func testNoSuchFunction(t *testing.T, s *suite) {
+	err := check(s.api.Call(s.ctx, "conformanceNoSuchFunction", nil))
+	expectError(t, err, etherpadlite.ErrNoSuchFunction, "calling an unknown method")
+}
+
+// contains returns true if s contains value.
+func contains(s []string, value string) bool {
+	for _, entry := range s {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
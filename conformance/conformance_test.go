@@ -0,0 +1,64 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance_test
+
+import (
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/conformance"
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+	"github.com/FabianWe/etherpadlite-golang/mock"
+)
+
+func TestMock(t *testing.T) {
+	conformance.Run(t, func() etherpadlite.API {
+		return mock.New()
+	})
+}
+
+func TestMockSharedStore(t *testing.T) {
+	// other pads in the store must not influence the tests
+	store := mock.NewStore()
+	store.AddPad("unrelated", "some text")
+	store.AddGroup("unrelated")
+	conformance.Run(t, func() etherpadlite.API {
+		return mock.NewWithStore(store)
+	})
+}
+
+func TestMockRaiseErrors(t *testing.T) {
+	conformance.Run(t, func() etherpadlite.API {
+		m := mock.New()
+		m.RaiseEtherpadErrors = true
+		return m
+	})
+}
+
+func TestServer(t *testing.T) {
+	server := etherpadtest.NewServer(t)
+	conformance.Run(t, func() etherpadlite.API {
+		return server.Client()
+	})
+}
+
+func TestServerRaiseErrors(t *testing.T) {
+	server := etherpadtest.NewServer(t)
+	conformance.Run(t, func() etherpadlite.API {
+		pad := server.Client()
+		pad.RaiseEtherpadErrors = true
+		return pad
+	})
+}
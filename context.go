@@ -0,0 +1,101 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+)
+
+// contextKey is the type of the keys this package stores in a context.
+type contextKey int
+
+const (
+	// raiseErrorsKey is the key for the override of RaiseEtherpadErrors.
+	raiseErrorsKey contextKey = iota
+
+	// sessionIDsKey is the key for the session IDs sent as cookie.
+	sessionIDsKey
+
+	// normalizeKey is the key for the override of NormalizeLineEndings.
+	normalizeKey
+)
+
+// WithRaiseEtherpadErrors returns a context that overrides
+// EtherpadLite.RaiseEtherpadErrors for all calls made with this context.
+// This way errors can be raised (or not) for single calls without changing
+// the EtherpadLite instance, for example:
+//
+//	ctx := etherpadlite.WithRaiseEtherpadErrors(context.Background(), true)
+//	response, err := pad.GetText(ctx, "foo", etherpadlite.OptionalParam)
+//
+// Even if an EtherpadError is returned the response is not nil.
+func WithRaiseEtherpadErrors(ctx context.Context, raise bool) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, raiseErrorsKey, raise)
+}
+
+// raiseEtherpadErrors returns true if EtherpadErrors should be raised for a
+// call with ctx.
+func (pad *EtherpadLite) raiseEtherpadErrors(ctx context.Context) bool {
+	if ctx != nil {
+		if raise, ok := ctx.Value(raiseErrorsKey).(bool); ok {
+			return raise
+		}
+	}
+	return pad.RaiseEtherpadErrors
+}
+
+// WithSessionIDs returns a context that contains etherpad session IDs (see
+// CreateSession). Requests to the etherpad frontend (like ExportPad) send
+// them as sessionID cookie, this is required for group pads that are not
+// public. The API methods ignore the session IDs.
+func WithSessionIDs(ctx context.Context, sessionIDs ...string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, sessionIDsKey, sessionIDs)
+}
+
+// sessionIDsFromContext returns the session IDs stored in ctx by
+// WithSessionIDs.
+func sessionIDsFromContext(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	ids, _ := ctx.Value(sessionIDsKey).([]string)
+	return ids
+}
+
+// WithNormalizeLineEndings returns a context that overrides
+// EtherpadLite.NormalizeLineEndings for all calls made with this context.
+func WithNormalizeLineEndings(ctx context.Context, normalize bool) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, normalizeKey, normalize)
+}
+
+// normalizeLineEndings returns true if texts should be normalized for a call
+// with ctx.
+func (pad *EtherpadLite) normalizeLineEndings(ctx context.Context) bool {
+	if ctx != nil {
+		if normalize, ok := ctx.Value(normalizeKey).(bool); ok {
+			return normalize
+		}
+	}
+	return pad.NormalizeLineEndings
+}
@@ -0,0 +1,101 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SessionCookieName is the name of the cookie etherpad reads the session IDs
+// from.
+const SessionCookieName = "sessionID"
+
+// splitSessionIDs splits a sessionID cookie value into the session IDs,
+// empty entries are skipped.
+func splitSessionIDs(value string) []string {
+	var res []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// sessionCookie creates the cookie for the session IDs ids (duplicates are
+// removed), expiring at expires.
+func sessionCookie(ids []string, expires time.Time, domain string, secure bool) *http.Cookie {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	cookie := &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    strings.Join(unique, ","),
+		Path:     "/",
+		Domain:   domain,
+		Expires:  expires,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+	// pads are usually embedded in an iframe of another site, browsers only
+	// send cross-site cookies with SameSite=None which requires Secure
+	if secure {
+		cookie.SameSite = http.SameSiteNoneMode
+	}
+	return cookie
+}
+
+// SessionCookie returns the sessionID cookie for the sessions: Etherpad
+// expects the IDs of all sessions comma-joined in a single cookie. The cookie
+// expires with the session that is valid the longest.
+// domain is the domain of the etherpad server (or a parent domain of it), it
+// may be empty for a host-only cookie. If secure is true the cookie is only
+// sent via HTTPS and uses SameSite=None so that it is sent to pads embedded
+// in an iframe, otherwise SameSite=Lax is used.
+func SessionCookie(sessions []Session, domain string, secure bool) *http.Cookie {
+	ids := make([]string, 0, len(sessions))
+	var expires time.Time
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+		if session.ValidUntil.After(expires) {
+			expires = session.ValidUntil
+		}
+	}
+	return sessionCookie(ids, expires, domain, secure)
+}
+
+// SetSessionCookie sets the sessionID cookie for the sessions (see
+// SessionCookie) on w.
+// If r is not nil the session IDs of an existing sessionID cookie in r are
+// kept, the new sessions are appended. The expiry of the existing sessions
+// is unknown, so the cookie expires with the longest valid of the new
+// sessions.
+func SetSessionCookie(w http.ResponseWriter, r *http.Request, sessions []Session, domain string, secure bool) {
+	cookie := SessionCookie(sessions, domain, secure)
+	if r != nil {
+		if existing, err := r.Cookie(SessionCookieName); err == nil {
+			ids := append(splitSessionIDs(existing.Value), splitSessionIDs(cookie.Value)...)
+			cookie = sessionCookie(ids, cookie.Expires, domain, secure)
+		}
+	}
+	http.SetCookie(w, cookie)
+}
@@ -0,0 +1,131 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSessionCookie(t *testing.T) {
+	base := time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)
+	tests := []struct {
+		name     string
+		sessions []Session
+		value    string
+		expires  time.Time
+	}{
+		{"none", nil, "", time.Time{}},
+		{"one", []Session{{ID: "s.1", ValidUntil: base}}, "s.1", base},
+		{
+			"max in the middle",
+			[]Session{
+				{ID: "s.1", ValidUntil: base},
+				{ID: "s.2", ValidUntil: base.Add(2 * time.Hour)},
+				{ID: "s.3", ValidUntil: base.Add(time.Hour)},
+			},
+			"s.1,s.2,s.3", base.Add(2 * time.Hour),
+		},
+		{
+			"max last",
+			[]Session{
+				{ID: "s.1", ValidUntil: base},
+				{ID: "s.2", ValidUntil: base.Add(time.Second)},
+			},
+			"s.1,s.2", base.Add(time.Second),
+		},
+		{
+			"duplicates",
+			[]Session{
+				{ID: "s.1", ValidUntil: base},
+				{ID: "s.2", ValidUntil: base},
+				{ID: "s.1", ValidUntil: base.Add(time.Hour)},
+			},
+			"s.1,s.2", base.Add(time.Hour),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cookie := SessionCookie(test.sessions, "example.com", false)
+			if cookie.Name != SessionCookieName {
+				t.Errorf("expected name %s, got %s", SessionCookieName, cookie.Name)
+			}
+			if cookie.Value != test.value {
+				t.Errorf("expected value %q, got %q", test.value, cookie.Value)
+			}
+			if !cookie.Expires.Equal(test.expires) {
+				t.Errorf("expected expiry %v, got %v", test.expires, cookie.Expires)
+			}
+			if cookie.Path != "/" || cookie.Domain != "example.com" {
+				t.Errorf("expected path / and domain example.com, got %q and %q", cookie.Path, cookie.Domain)
+			}
+		})
+	}
+}
+
+func TestSessionCookieSecure(t *testing.T) {
+	sessions := []Session{{ID: "s.1"}}
+	if cookie := SessionCookie(sessions, "", false); cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("expected an insecure cookie with SameSite=Lax, got %+v", cookie)
+	}
+	if cookie := SessionCookie(sessions, "", true); !cookie.Secure || cookie.SameSite != http.SameSiteNoneMode {
+		t.Errorf("expected a secure cookie with SameSite=None, got %+v", cookie)
+	}
+}
+
+func TestSetSessionCookie(t *testing.T) {
+	base := time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)
+	sessions := []Session{
+		{ID: "s.3", ValidUntil: base.Add(time.Hour)},
+		{ID: "s.2", ValidUntil: base},
+	}
+	tests := []struct {
+		name     string
+		existing string
+		value    string
+	}{
+		{"no cookie", "", "s.3,s.2"},
+		{"existing", "s.1", "s.1,s.3,s.2"},
+		{"existing with overlap", "s.1, s.2,,", "s.1,s.2,s.3"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.existing != "" {
+				r.AddCookie(&http.Cookie{Name: SessionCookieName, Value: test.existing})
+			}
+			w := httptest.NewRecorder()
+			SetSessionCookie(w, r, sessions, "example.com", true)
+			cookies := w.Result().Cookies()
+			if len(cookies) != 1 {
+				t.Fatalf("expected one cookie, got %v", cookies)
+			}
+			if cookies[0].Value != test.value {
+				t.Errorf("expected value %q, got %q", test.value, cookies[0].Value)
+			}
+			// the expiry of existing sessions is unknown, the new sessions decide
+			if !cookies[0].Expires.Equal(base.Add(time.Hour)) {
+				t.Errorf("expected expiry %v, got %v", base.Add(time.Hour), cookies[0].Expires)
+			}
+		})
+	}
+	w := httptest.NewRecorder()
+	SetSessionCookie(w, nil, sessions, "", false)
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Value != "s.3,s.2" {
+		t.Errorf("expected cookie s.3,s.2 without request, got %v", cookies)
+	}
+}
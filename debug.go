@@ -0,0 +1,52 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// dumpDebug writes the debug output for a request to pad.Debug (if set).
+// resp and body are nil if the request failed, err is the error that
+// occurred (if any).
+// The body of req is read via req.GetBody, so it's never consumed.
+func (pad *EtherpadLite) dumpDebug(method string, req *http.Request, resp *http.Response, body []byte, err error) {
+	if pad.Debug == nil {
+		return
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "etherpadlite: %s\n", method)
+	fmt.Fprintf(&buf, "> %s %s\n", req.Method, RedactURL(req.URL.String()))
+	if req.GetBody != nil {
+		if reqBody, bodyErr := req.GetBody(); bodyErr == nil {
+			content, _ := ioutil.ReadAll(reqBody)
+			reqBody.Close()
+			fmt.Fprintf(&buf, "> %s\n", redactQuery(string(content)))
+		}
+	}
+	if resp != nil {
+		fmt.Fprintf(&buf, "< %s\n", resp.Status)
+		fmt.Fprintf(&buf, "< %s\n", body)
+	}
+	if err != nil {
+		fmt.Fprintf(&buf, "! %v\n", err)
+	}
+	pad.debugMutex.Lock()
+	defer pad.debugMutex.Unlock()
+	pad.Debug.Write(buf.Bytes())
+}
@@ -0,0 +1,305 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// DiffOp is the operation of a line in a diff.
+type DiffOp int
+
+const (
+	// DiffEqual is a line that is contained in both texts.
+	DiffEqual DiffOp = iota
+
+	// DiffInsert is a line that was added.
+	DiffInsert
+
+	// DiffDelete is a line that was removed.
+	DiffDelete
+)
+
+// prefix returns the prefix of the operation in a unified diff.
+func (op DiffOp) prefix() string {
+	switch op {
+	case DiffInsert:
+		return "+"
+	case DiffDelete:
+		return "-"
+	default:
+		return " "
+	}
+}
+
+// DiffLine is a line in a diff.
+type DiffLine struct {
+	Op DiffOp
+
+	// Text is the line without the trailing newline.
+	Text string
+
+	// NoNewline is true for the last line of a text that doesn't end with a
+	// newline.
+	NoNewline bool
+}
+
+// DiffHunk is a group of changed lines together with up to three lines of
+// context, as in a unified diff.
+type DiffHunk struct {
+	// OldStart is the line number (starting at 1) of the first line of the
+	// hunk in the old text. If OldLines is 0 it is the line after which the
+	// lines were inserted.
+	OldStart int
+
+	// OldLines is the number of lines of the hunk in the old text.
+	OldLines int
+
+	// NewStart is the line number (starting at 1) of the first line of the
+	// hunk in the new text. If NewLines is 0 it is the line after which the
+	// lines were removed.
+	NewStart int
+
+	// NewLines is the number of lines of the hunk in the new text.
+	NewLines int
+
+	Lines []DiffLine
+}
+
+// TextDiff is a line based diff between two texts, see DiffLines.
+type TextDiff struct {
+	// OldName and NewName are the names of the texts used in the header of
+	// the unified diff.
+	OldName string
+	NewName string
+
+	// Hunks are the changes, empty if the texts are equal.
+	Hunks []DiffHunk
+}
+
+// Equal returns true if the texts don't differ.
+func (d *TextDiff) Equal() bool {
+	return len(d.Hunks) == 0
+}
+
+// String returns the diff in the unified diff format, the empty string if the
+// texts are equal.
+func (d *TextDiff) String() string {
+	if d.Equal() {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", d.OldName, d.NewName)
+	for _, hunk := range d.Hunks {
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", unifiedRange(hunk.OldStart, hunk.OldLines), unifiedRange(hunk.NewStart, hunk.NewLines))
+		for _, line := range hunk.Lines {
+			b.WriteString(line.Op.prefix())
+			b.WriteString(line.Text)
+			b.WriteByte('\n')
+			if line.NoNewline {
+				b.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// unifiedRange formats the range of a hunk, the length is omitted if it is 1.
+func unifiedRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// diffContext is the number of unchanged lines around changes in a hunk.
+const diffContext = 3
+
+// splitLines splits s into lines, each line (except possibly the last one)
+// ends with a newline.
+func splitLines(s string) []string {
+	var lines []string
+	for s != "" {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			lines = append(lines, s)
+			break
+		}
+		lines = append(lines, s[:i+1])
+		s = s[i+1:]
+	}
+	return lines
+}
+
+// myers computes the shortest edit script from a to b with the Myers
+// algorithm.
+func myers(a, b []string) []DiffLine {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+	line := func(op DiffOp, s string) DiffLine {
+		text := strings.TrimSuffix(s, "\n")
+		return DiffLine{Op: op, Text: text, NoNewline: text == s}
+	}
+	var reversed []DiffLine
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, line(DiffEqual, a[x-1]))
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, line(DiffInsert, b[y-1]))
+				y--
+			} else {
+				reversed = append(reversed, line(DiffDelete, a[x-1]))
+				x--
+			}
+		}
+	}
+	ops := make([]DiffLine, len(reversed))
+	for i, op := range reversed {
+		ops[len(reversed)-1-i] = op
+	}
+	return ops
+}
+
+// hunks groups the edit script into hunks with diffContext lines of context,
+// changes that are separated by at most 2 * diffContext unchanged lines are in
+// the same hunk.
+func hunks(ops []DiffLine) []DiffHunk {
+	// oldPos and newPos are the (0 based) line numbers before each operation
+	oldPos := make([]int, len(ops)+1)
+	newPos := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if op.Op != DiffInsert {
+			oldPos[i+1]++
+		}
+		if op.Op != DiffDelete {
+			newPos[i+1]++
+		}
+	}
+	var res []DiffHunk
+	i := 0
+	for {
+		for i < len(ops) && ops[i].Op == DiffEqual {
+			i++
+		}
+		if i == len(ops) {
+			return res
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for {
+			for end < len(ops) && ops[end].Op != DiffEqual {
+				end++
+			}
+			run := end
+			for run < len(ops) && ops[run].Op == DiffEqual {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				if run-end < diffContext {
+					end = run
+				} else {
+					end += diffContext
+				}
+				break
+			}
+			end = run
+		}
+		hunk := DiffHunk{
+			OldStart: oldPos[start] + 1,
+			OldLines: oldPos[end] - oldPos[start],
+			NewStart: newPos[start] + 1,
+			NewLines: newPos[end] - newPos[start],
+			Lines:    ops[start:end],
+		}
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+		res = append(res, hunk)
+		i = end
+	}
+}
+
+// DiffLines computes a line based diff between the texts a and b.
+// A missing newline at the end of a text is a difference, it is marked with
+// DiffLine.NoNewline.
+func DiffLines(a, b string) *TextDiff {
+	return &TextDiff{OldName: "a", NewName: "b", Hunks: hunks(myers(splitLines(a), splitLines(b)))}
+}
+
+// DiffRevisions fetches the texts of the revisions revA and revB of a pad and
+// computes a line based diff between them (see DiffLines). In contrast to
+// createDiffHTML the result is meant to be processed by programs.
+func (pad *EtherpadLite) DiffRevisions(ctx context.Context, padID string, revA, revB int) (*TextDiff, error) {
+	a, err := pad.GetTextContent(ctx, padID, revA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := pad.GetTextContent(ctx, padID, revB)
+	if err != nil {
+		return nil, err
+	}
+	diff := DiffLines(a, b)
+	diff.OldName = fmt.Sprintf("%s@%d", padID, revA)
+	diff.NewName = fmt.Sprintf("%s@%d", padID, revB)
+	return diff, nil
+}
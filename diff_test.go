@@ -0,0 +1,153 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{"equal", "a\nb\n", "a\nb\n", ""},
+		{"both empty", "", "", ""},
+		{"from empty", "", "a\nb\n", "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+a\n+b\n"},
+		{"to empty", "a\n", "", "--- a\n+++ b\n@@ -1 +0,0 @@\n-a\n"},
+		{"from empty without newline", "", "x", "--- a\n+++ b\n@@ -0,0 +1 @@\n+x\n\\ No newline at end of file\n"},
+		{"newline added", "a", "a\n", "--- a\n+++ b\n@@ -1 +1 @@\n-a\n\\ No newline at end of file\n+a\n"},
+		{"newline removed", "a\nb\n", "a\nb", "--- a\n+++ b\n@@ -1,2 +1,2 @@\n a\n-b\n+b\n\\ No newline at end of file\n"},
+		{"empty lines", "\n\n", "\n\n\n", "--- a\n+++ b\n@@ -1,2 +1,3 @@\n \n \n+\n"},
+		{"changed line", "a\nb\nc\n", "a\nB\nc\n", "--- a\n+++ b\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"reversed", "a\nb\nc\n", "c\nb\na\n", "--- a\n+++ b\n@@ -1,3 +1,3 @@\n-a\n-b\n c\n+b\n+a\n"},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"1\n2\nX\n4\n5\n6\n7\n8\n9\n10\nY\n12\n",
+			"--- a\n+++ b\n@@ -1,6 +1,6 @@\n 1\n 2\n-3\n+X\n 4\n 5\n 6\n@@ -8,5 +8,5 @@\n 8\n 9\n 10\n-11\n+Y\n 12\n",
+		},
+		{
+			// at most 6 unchanged lines between changes are part of one hunk
+			"merged hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n",
+			"1\n2\nX\n4\n5\n6\n7\nY\n",
+			"--- a\n+++ b\n@@ -1,8 +1,8 @@\n 1\n 2\n-3\n+X\n 4\n 5\n 6\n 7\n-8\n+Y\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := DiffLines(test.a, test.b)
+			if got := diff.String(); got != test.expected {
+				t.Errorf("expected diff\n%s\ngot\n%s", test.expected, got)
+			}
+			if diff.Equal() != (test.expected == "") {
+				t.Errorf("expected Equal() to be %v", test.expected == "")
+			}
+			if got := applyDiff(test.a, diff); got != test.b {
+				t.Errorf("applying the diff returned %q, expected %q", got, test.b)
+			}
+		})
+	}
+}
+
+// applyDiff applies the hunks of diff to a.
+func applyDiff(a string, diff *TextDiff) string {
+	lines := splitLines(a)
+	var b strings.Builder
+	pos := 0
+	for _, hunk := range diff.Hunks {
+		start := hunk.OldStart - 1
+		if hunk.OldLines == 0 {
+			start = hunk.OldStart
+		}
+		for ; pos < start; pos++ {
+			b.WriteString(lines[pos])
+		}
+		for _, line := range hunk.Lines {
+			if line.Op != DiffDelete {
+				b.WriteString(line.Text)
+				if !line.NoNewline {
+					b.WriteByte('\n')
+				}
+			}
+			if line.Op != DiffInsert {
+				pos++
+			}
+		}
+	}
+	for ; pos < len(lines); pos++ {
+		b.WriteString(lines[pos])
+	}
+	return b.String()
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// TestDiffLinesRandom checks that random diffs are correct and minimal.
+func TestDiffLinesRandom(t *testing.T) {
+	random := rand.New(rand.NewSource(42))
+	text := func() string {
+		var b strings.Builder
+		n := random.Intn(15)
+		for i := 0; i < n; i++ {
+			b.WriteString(string(rune('a' + random.Intn(4))))
+			if i < n-1 || random.Intn(4) > 0 {
+				b.WriteByte('\n')
+			}
+		}
+		return b.String()
+	}
+	for i := 0; i < 500; i++ {
+		a, b := text(), text()
+		diff := DiffLines(a, b)
+		if got := applyDiff(a, diff); got != b {
+			t.Fatalf("diff of %q and %q: applying the diff returned %q", a, b, got)
+		}
+		changes := 0
+		for _, hunk := range diff.Hunks {
+			for _, line := range hunk.Lines {
+				if line.Op != DiffEqual {
+					changes++
+				}
+			}
+		}
+		linesA, linesB := splitLines(a), splitLines(b)
+		if minimal := len(linesA) + len(linesB) - 2*lcs(linesA, linesB); changes != minimal {
+			t.Fatalf("diff of %q and %q: expected %d changes, got %d", a, b, minimal, changes)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// maxBodySnippet is the maximal number of bytes of a response body that is
+// included in a RequestError.
+const maxBodySnippet = 256
+
+// RequestError is returned if a request fails for a reason other than an
+// error reported by etherpad, for example if the connection fails, the
+// context gets cancelled or the response can't be decoded.
+// Use errors.Is and errors.As to check the underlying error, for example
+// errors.Is(err, context.DeadlineExceeded).
+type RequestError struct {
+	// Method is the API method that was called.
+	Method string
+
+	// URL is the URL of the request with the API key redacted, it is empty if
+	// the request could not be built.
+	URL string
+
+	// Err is the underlying error.
+	Err error
+
+	// Body is the beginning of the response body if the response could not be
+	// decoded.
+	Body string
+}
+
+// Error returns the error as a string.
+func (e *RequestError) Error() string {
+	var b strings.Builder
+	b.WriteString("request ")
+	b.WriteString(e.Method)
+	if e.URL != "" {
+		fmt.Fprintf(&b, " (%s)", e.URL)
+	}
+	b.WriteString(" failed: ")
+	b.WriteString(e.Err.Error())
+	if e.Body != "" {
+		fmt.Fprintf(&b, ", response body: %q", e.Body)
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// bodySnippet returns the beginning of body to be included in an error.
+func bodySnippet(body []byte) string {
+	if len(body) <= maxBodySnippet {
+		return string(body)
+	}
+	return string(body[:maxBodySnippet]) + "..."
+}
+
+// ResponseToError returns an EtherpadError if the code of resp is not
+// EverythingOk and nil otherwise. This is the error that is returned if
+// RaiseEtherpadErrors is true.
+func ResponseToError(resp *Response) error {
+	if resp == nil || resp.Code == EverythingOk {
+		return nil
+	}
+	return NewEtherpadError(resp.Code, resp.Message)
+}
+
+// IsEtherpadError returns the code of the EtherpadError wrapped by err, if
+// there is such an error.
+func IsEtherpadError(err error) (ReturnCode, bool) {
+	var padErr EtherpadError
+	if errors.As(err, &padErr) {
+		return padErr.Code, true
+	}
+	return EverythingOk, false
+}
+
+// IsTimeout returns true if err is caused by a timeout, either because the
+// deadline of the context was exceeded or because of a network timeout (for
+// example http.Client.Timeout).
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsNetworkError returns true if err is caused by the network or the transport,
+// for example if the connection was refused, the host could not be resolved or
+// a timeout occurred on the connection. Cancelled contexts and errors reported
+// by etherpad are not network errors.
+func IsNetworkError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if _, isPadErr := IsEtherpadError(err); isPadErr {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// joinFailures formats the error err (if not nil) and the failed IDs with
+// their errors, sorted by ID, as "err; id1: err1; id2: err2". It is used by
+// the errors of the helpers that process multiple pads or sessions.
+func joinFailures(err error, failed map[string]error) string {
+	ids := make([]string, 0, len(failed))
+	for id := range failed {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	parts := make([]string, 0, len(ids)+1)
+	if err != nil {
+		parts = append(parts, err.Error())
+	}
+	for _, id := range ids {
+		parts = append(parts, fmt.Sprintf("%s: %v", id, failed[id]))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,38 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestJoinFailuresErrors(t *testing.T) {
+	failed := map[string]error{"b": errors.New("second"), "a": errors.New("first")}
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{&DeletePadsError{Failed: failed}, "failed to delete 2 pad(s): a: first; b: second"},
+		{&DeleteSessionsError{Failed: failed, Err: context.Canceled}, "failed to delete 2 session(s): context canceled; a: first; b: second"},
+		{&CloneGroupError{Failed: map[string]error{}, Err: context.Canceled}, "failed to copy 0 pad(s): context canceled"},
+	}
+	for _, tc := range tests {
+		if got := tc.err.Error(); got != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, got)
+		}
+	}
+}
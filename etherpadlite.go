@@ -32,16 +32,26 @@
 //
 // It is safe to call the API methods simultaneously from multiple goroutines.
 //
+// All API methods are shortcuts for EtherpadLite.Call, which can also be used
+// to call methods not wrapped by this package (for example from plugins).
+//
 // I didn't document the methods since they're documented very well on the
 // etherpad homepage: https://etherpad.org/doc/v1.7.5/#index_http_api
 package etherpadlite
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 )
 
 // optionalParamType is an unexported type to identify an optional parameter
@@ -68,6 +78,8 @@ type EtherpadLite struct {
 	// The entry BaseParams["apikey"] = YOUR-API-KEY
 	// should always be present.
 	// NewEtherpadLite will take care of this.
+	// If a method is called with a parameter that is also present in
+	// BaseParams the value of the method call is used.
 	BaseParams map[string]interface{}
 
 	// BaseURL is the URL pointing to the API of your pad, i.e.
@@ -87,7 +99,95 @@ type EtherpadLite struct {
 	// By setting it to true the calls to all functions will return an error
 	// for all responses with Response.Code != EverythingOk.
 	// In this case an instance of EtherpadError is raised.
+	// It can be overridden for single calls with WithRaiseEtherpadErrors.
 	RaiseEtherpadErrors bool
+
+	// MaxRetries is the number of times a request is retried if the server
+	// (or a reverse proxy in front of it) answers with 429 Too Many Requests,
+	// or with 503 Service Unavailable together with a Retry-After header.
+	// Before retrying the client waits for the duration given in Retry-After,
+	// but never beyond the deadline of the context.
+	// It defaults to 0, in this case a RateLimitedError is returned directly.
+	MaxRetries int
+
+	// CircuitBreakerThreshold is the number of consecutive failures (network
+	// errors, exhausted retries and HTTP status codes >= 500) after which the
+	// client stops sending requests and fails fast with ErrCircuitOpen.
+	// After CircuitBreakerTimeout a single probe request is allowed, if it
+	// succeeds requests are sent again normally.
+	// It defaults to 0 which disables the circuit breaker.
+	CircuitBreakerThreshold int
+
+	// CircuitBreakerTimeout is the time the circuit breaker stays open before
+	// a probe request is allowed.
+	// If it is 0 (the default) a timeout of 30 seconds is used.
+	CircuitBreakerTimeout time.Duration
+
+	// MaxConcurrentRequests limits the number of requests that are in flight
+	// at the same time. Additional calls wait until a request finished (or
+	// their context is done).
+	// It defaults to 0 which means no limit.
+	// It should not be changed once requests have been sent.
+	MaxConcurrentRequests int
+
+	// UseJSONNumber specifies if numbers in Response.Data are decoded as
+	// json.Number instead of float64.
+	// float64 can't represent all integers exactly (for example very large
+	// millisecond timestamps), json.Number keeps the exact value. Use AsInt64
+	// to convert both representations to an int64.
+	// It defaults to false to keep backwards compatibility.
+	UseJSONNumber bool
+
+	// TimeEncoding specifies how parameters of type time.Time and
+	// time.Duration are encoded, for example validUntil in CreateSession.
+	// It defaults to UnixSeconds.
+	TimeEncoding TimeEncoding
+
+	// StrictIDs specifies if pad, group, author and session IDs are validated
+	// (see ValidatePadID etc.) before a request is sent. In this case an
+	// InvalidIDError is returned for malformed IDs instead of sending a
+	// request that etherpad answers with "wrong parameters".
+	// It defaults to false.
+	StrictIDs bool
+
+	// NormalizeLineEndings specifies if the text sent by setText, appendText,
+	// createPad and createGroupPad is normalized with NormalizeText: "\r\n" and
+	// "\r" are converted to "\n" and a leading UTF-8 byte order mark is
+	// removed. It can be overridden for single calls with
+	// WithNormalizeLineEndings.
+	// It defaults to false.
+	NormalizeLineEndings bool
+
+	// MapperCache, if not nil, caches the IDs returned for author and group
+	// mappers by EnsureAuthorID and CreateGroupIDFor, so repeated lookups
+	// don't need a request.
+	// It defaults to nil.
+	MapperCache *MapperCache
+
+	// Debug, if not nil, receives a dump of each request: the API method, the
+	// URL (with the API key redacted), the request body (if any), the HTTP
+	// status and the raw response body.
+	// The dump of a request is written in a single call to Write, so dumps of
+	// concurrent requests don't interleave.
+	// It defaults to nil.
+	Debug io.Writer
+
+	// Clock is the source of time, for example for retries, the circuit
+	// breaker and polling helpers like TailChat. It defaults to nil, which
+	// means RealClock.
+	Clock Clock
+
+	// breaker stores the state of the circuit breaker.
+	breaker circuitBreaker
+
+	// limiter is the semaphore used to enforce MaxConcurrentRequests.
+	limiter requestLimiter
+
+	// debugMutex synchronizes writes to Debug.
+	debugMutex sync.Mutex
+
+	// exportFormats caches the result of DetectExportFormats.
+	exportFormats exportFormatCache
 }
 
 // NewEtherpadLite creates a new EtherpadLite instance given the
@@ -136,53 +236,154 @@ func (c ReturnCode) String() string {
 
 // Response is the response from the etherpad API.
 // See https://github.com/ether/etherpad-lite/wiki/HTTP-API
+//
+// Data is nil if the API returned no data or null (for example deletePad
+// does so on success), use HasData to check this. Data is also nil if the
+// data returned is not a JSON object. DecodeData can be used to decode the
+// data into a custom type instead of using Data.
 type Response struct {
 	Code    ReturnCode
 	Message string
 	Data    map[string]interface{}
+
+	// rawData is the data object as returned by the API, nil if the response
+	// was not decoded from JSON.
+	rawData json.RawMessage
 }
 
 // EtherpadError is an error returned by all methods if
 // EtherpadLite.RaiseEtherpadErrors is true. It reports any internal error
 // returned by calling the HTTP API of etherpad, signaling that the ReturnCode
 // is not EverythingOk.
+//
+// Use errors.Is to check for a certain class of errors, for example
+// errors.Is(err, ErrWrongAPIKey) or errors.Is(err, ErrPadNotFound).
 type EtherpadError struct {
-	code ReturnCode
-	message string
+	// Code is the code returned by the API.
+	Code ReturnCode
+
+	// Message is the error message returned by the API.
+	Message string
 }
 
+// Sentinel errors to be used with errors.Is, an EtherpadError matches the
+// error for its code.
+var (
+	ErrWrongParameters = errors.New("wrong parameters")
+	ErrInternal        = errors.New("internal error")
+	ErrNoSuchFunction  = errors.New("no such function")
+	ErrWrongAPIKey     = errors.New("no or wrong API key")
+
+	// ErrPadNotFound is matched by an EtherpadError if etherpad reports that
+	// the pad does not exist (which is reported as WrongParameters).
+	ErrPadNotFound = errors.New("pad does not exist")
+
+	// ErrPadExists is matched by an EtherpadError if etherpad reports that
+	// the pad already exists (which is reported as WrongParameters).
+	ErrPadExists = errors.New("pad does already exist")
+
+	// ErrGroupNotFound is matched by an EtherpadError if etherpad reports that
+	// the group does not exist (which is reported as WrongParameters).
+	ErrGroupNotFound = errors.New("group does not exist")
+)
+
 // NewEtherpadError returns a new EtherpadError.
 // The code should be != EverythingOk and the message is the error message
 // returned by the HTTP API.
 func NewEtherpadError(code ReturnCode, message string) EtherpadError {
-	return EtherpadError{code: code, message: message}
+	return EtherpadError{Code: code, Message: message}
 }
 
 // Error returns the error as a string.
 func (e EtherpadError) Error() string {
-	codeStr := e.code.String()
-	return fmt.Sprintf("%s: %s", codeStr, e.message)
+	codeStr := e.Code.String()
+	return fmt.Sprintf("%s: %s", codeStr, e.Message)
+}
+
+// Is reports whether the error matches target, which should be one of the
+// sentinel errors like ErrWrongAPIKey or ErrPadNotFound.
+func (e EtherpadError) Is(target error) bool {
+	switch target {
+	case ErrWrongParameters:
+		return e.Code == WrongParameters
+	case ErrInternal:
+		return e.Code == InternalError
+	case ErrNoSuchFunction:
+		return e.Code == NoSuchFunction
+	case ErrWrongAPIKey:
+		return e.Code == WrongAPIKey
+	case ErrPadNotFound:
+		return e.Code == WrongParameters && isPadNotFoundMessage(e.Message)
+	case ErrPadExists:
+		return e.Code == WrongParameters && isPadExistsMessage(e.Message)
+	case ErrGroupNotFound:
+		return e.Code == WrongParameters && strings.Contains(strings.ToLower(e.Message), "groupid does not exist")
+	default:
+		return false
+	}
 }
 
-// sendRequest is the function doing most of the work by sending the real
-// request. It will encode the BaseParams and params into URL queries and
-// do the http GET.
-// It decodes the JSON result and returns the decoded version.
-// If ctx != nil the method will be cancelled once ctx gets cancelled.
-// Note that ctx = nil, should not be used according to the documentation,
-// but we allow it since it's much easier.
-// Instead we could always use context.Background().
-func (pad *EtherpadLite) sendRequest(ctx context.Context, path string, params map[string]interface{}) (*Response, error) {
-	getURL, err := url.Parse(fmt.Sprintf("%s/%s/%s", pad.BaseURL, pad.APIVersion, path))
+// isPadNotFoundMessage returns true if message is the message etherpad
+// returns if a pad does not exist ("padID does not exist").
+func isPadNotFoundMessage(message string) bool {
+	return strings.Contains(strings.ToLower(message), "padid does not exist")
+}
+
+// isPadExistsMessage returns true if message is the message etherpad returns
+// if a pad already exists ("padID does already exist" or "padName does already
+// exist" for group pads, "destinationID already exists" for copyPad and
+// movePad).
+func isPadExistsMessage(message string) bool {
+	message = strings.ToLower(message)
+	return strings.Contains(message, "padid does already exist") ||
+		strings.Contains(message, "padname does already exist") ||
+		strings.Contains(message, "destinationid already exists")
+}
+
+// BuildRequest builds the http.Request for the API method with the given
+// parameters without sending it. It encodes the BaseParams and params into
+// URL queries of a GET request to BaseURL/APIVersion/method.
+// This can be used to send requests through a custom HTTP pipeline, the
+// response can then be decoded with DecodeResponse.
+// Before the request is built the required parameters are checked, see
+// MissingParameterError, and if StrictIDs is true all IDs are validated.
+// If the method doesn't exist in APIVersion an error wrapping
+// ErrUnsupportedInVersion is returned.
+// If ctx != nil the request uses this context.
+func (pad *EtherpadLite) BuildRequest(ctx context.Context, method string, params map[string]interface{}) (*http.Request, error) {
+	if err := checkRequiredParams(method, params); err != nil {
+		return nil, err
+	}
+	if err := checkAPIVersion(method, pad.APIVersion); err != nil {
+		return nil, err
+	}
+	if pad.StrictIDs {
+		if err := checkIDs(params); err != nil {
+			return nil, err
+		}
+	}
+	getURL, err := url.Parse(fmt.Sprintf("%s/%s/%s", pad.BaseURL, pad.APIVersion, method))
 	if err != nil {
 		return nil, err
 	}
 	parameters := url.Values{}
 	for key, value := range pad.BaseParams {
-		parameters.Add(key, fmt.Sprintf("%v", value))
+		encoded, encodeErr := encodeParam(value, pad.TimeEncoding)
+		if encodeErr != nil {
+			return nil, fmt.Errorf("invalid base parameter %s: %w", key, encodeErr)
+		}
+		parameters.Set(key, encoded)
 	}
 	for key, value := range params {
-		parameters.Add(key, fmt.Sprintf("%v", value))
+		encoded, encodeErr := encodeParam(value, pad.TimeEncoding)
+		if encodeErr != nil {
+			return nil, fmt.Errorf("invalid parameter %s for %s: %w", key, method, encodeErr)
+		}
+		if key == "text" && textMethods[method] && pad.normalizeLineEndings(ctx) {
+			encoded = NormalizeText(encoded)
+		}
+		// parameters of the call override BaseParams, never send a parameter twice
+		parameters.Set(key, encoded)
 	}
 	getURL.RawQuery = parameters.Encode()
 	req, reqErr := http.NewRequest("GET", getURL.String(), nil)
@@ -192,23 +393,90 @@ func (pad *EtherpadLite) sendRequest(ctx context.Context, path string, params ma
 	if ctx != nil {
 		req = req.WithContext(ctx)
 	}
-	resp, doErr := pad.Client.Do(req)
-	if resp != nil {
-		defer resp.Body.Close()
+	return req, nil
+}
+
+// DecodeResponse decodes the JSON response of the API read from r.
+// It respects UseJSONNumber, but it does not check the return code: The
+// error is nil even if RaiseEtherpadErrors is true and the code is not
+// EverythingOk.
+func (pad *EtherpadLite) DecodeResponse(r io.Reader) (*Response, error) {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
 	}
+	return pad.decodeResponse(body)
+}
+
+// sendRequest is the function doing most of the work by sending the real
+// request. It builds the request with BuildRequest and does the http GET.
+// It decodes the JSON result with DecodeResponse and returns the decoded
+// version.
+// If ctx != nil the method will be cancelled once ctx gets cancelled.
+// Note that ctx = nil, should not be used according to the documentation,
+// but we allow it since it's much easier.
+// Instead we could always use context.Background().
+// All errors except EtherpadError are wrapped in a RequestError.
+func (pad *EtherpadLite) sendRequest(ctx context.Context, path string, params map[string]interface{}) (*Response, error) {
+	req, reqErr := pad.BuildRequest(ctx, path, params)
+	if reqErr != nil {
+		return nil, &RequestError{Method: path, Err: reqErr}
+	}
+	requestErr := func(err error, body []byte) error {
+		return &RequestError{
+			Method: path,
+			URL:    RedactURL(req.URL.String()),
+			Err:    err,
+			Body:   bodySnippet(body),
+		}
+	}
+	release, acquireErr := pad.limiter.acquire(ctx, pad.MaxConcurrentRequests)
+	if acquireErr != nil {
+		return nil, requestErr(acquireErr, nil)
+	}
+	defer release()
+	resp, doErr := pad.doRequest(ctx, req)
 	if doErr != nil {
-		return nil, doErr
+		pad.dumpDebug(path, req, nil, nil, doErr)
+		return nil, requestErr(doErr, nil)
 	}
-	var padResponse Response
-	if jsonErr := json.NewDecoder(resp.Body).Decode(&padResponse); jsonErr != nil {
-		return nil, jsonErr
+	defer resp.Body.Close()
+	// read the whole body first, this way the debug output can't interfere
+	// with decoding
+	body, readErr := ioutil.ReadAll(resp.Body)
+	pad.dumpDebug(path, req, resp, body, readErr)
+	if readErr != nil {
+		return nil, requestErr(readErr, nil)
+	}
+	padResponse, decodeErr := pad.DecodeResponse(bytes.NewReader(body))
+	if decodeErr != nil {
+		return nil, requestErr(decodeErr, body)
 	}
 	// check how to handle response errors
 	// and if we have to care about them what to do about it
-	if pad.RaiseEtherpadErrors && padResponse.Code != EverythingOk {
-		return &padResponse, NewEtherpadError(padResponse.Code, padResponse.Message)
+	if pad.raiseEtherpadErrors(ctx) && padResponse.Code != EverythingOk {
+		return padResponse, NewEtherpadError(padResponse.Code, padResponse.Message)
+	}
+	return padResponse, nil
+}
+
+// Call calls an arbitrary API method with the given parameters, for example
+// methods added by etherpad plugins or by new etherpad versions that are not
+// wrapped by this package yet.
+// All other API methods are just shortcuts for Call, the same configuration
+// (API key, version, RaiseEtherpadErrors, retries etc.) applies.
+// The parameters are encoded as described in the package documentation.
+func (pad *EtherpadLite) Call(ctx context.Context, method string, params map[string]interface{}) (*Response, error) {
+	return pad.sendRequest(ctx, method, params)
+}
+
+// withAuthorID adds the optional parameter authorId to params if authorID is
+// not OptionalParam.
+func withAuthorID(params map[string]interface{}, authorID interface{}) map[string]interface{} {
+	if authorID != OptionalParam {
+		params["authorId"] = authorID
 	}
-	return &padResponse, nil
+	return params
 }
 
 // Groups
@@ -269,7 +537,14 @@ func (pad *EtherpadLite) GetAuthorName(ctx context.Context, authorID interface{}
 
 // Session
 
+// CreateSession creates a new session. validUntil can be a time.Time, it is
+// encoded as described by EtherpadLite.TimeEncoding.
+// A numeric validUntil that looks like milliseconds (13 or more digits) is
+// rejected with ErrValidUntilMilliseconds, etherpad expects seconds.
 func (pad *EtherpadLite) CreateSession(ctx context.Context, groupID, authorID, validUntil interface{}) (*Response, error) {
+	if err := checkValidUntil(validUntil); err != nil {
+		return nil, &RequestError{Method: "createSession", Err: err}
+	}
 	return pad.sendRequest(ctx,
 		"createSession",
 		map[string]interface{}{"groupID": groupID, "authorID": authorID, "validUntil": validUntil})
@@ -302,11 +577,25 @@ func (pad *EtherpadLite) GetText(ctx context.Context, padID, rev interface{}) (*
 }
 
 func (pad *EtherpadLite) SetText(ctx context.Context, padID, text interface{}) (*Response, error) {
-	return pad.sendRequest(ctx, "setText", map[string]interface{}{"padID": padID, "text": text})
+	return pad.SetTextAs(ctx, padID, text, OptionalParam)
+}
+
+// SetTextAs is like SetText but attributes the change to the author with the
+// ID authorID (requires etherpad 1.9 or newer). If authorID is OptionalParam
+// the parameter is not sent.
+func (pad *EtherpadLite) SetTextAs(ctx context.Context, padID, text, authorID interface{}) (*Response, error) {
+	return pad.sendRequest(ctx, "setText", withAuthorID(map[string]interface{}{"padID": padID, "text": text}, authorID))
 }
 
 func (pad *EtherpadLite) AppendText(ctx context.Context, padID, text interface{}) (*Response, error) {
-	return pad.sendRequest(ctx, "appendText", map[string]interface{}{"padID": padID, "text": text})
+	return pad.AppendTextAs(ctx, padID, text, OptionalParam)
+}
+
+// AppendTextAs is like AppendText but attributes the change to the author with
+// the ID authorID (requires etherpad 1.9 or newer). If authorID is
+// OptionalParam the parameter is not sent.
+func (pad *EtherpadLite) AppendTextAs(ctx context.Context, padID, text, authorID interface{}) (*Response, error) {
+	return pad.sendRequest(ctx, "appendText", withAuthorID(map[string]interface{}{"padID": padID, "text": text}, authorID))
 }
 
 func (pad *EtherpadLite) GetHTML(ctx context.Context, padID, rev interface{}) (*Response, error) {
@@ -318,7 +607,14 @@ func (pad *EtherpadLite) GetHTML(ctx context.Context, padID, rev interface{}) (*
 }
 
 func (pad *EtherpadLite) SetHTML(ctx context.Context, padID, html interface{}) (*Response, error) {
-	return pad.sendRequest(ctx, "setHTML", map[string]interface{}{"padID": padID, "html": html})
+	return pad.SetHTMLAs(ctx, padID, html, OptionalParam)
+}
+
+// SetHTMLAs is like SetHTML but attributes the change to the author with the
+// ID authorID (requires etherpad 1.9 or newer). If authorID is OptionalParam
+// the parameter is not sent.
+func (pad *EtherpadLite) SetHTMLAs(ctx context.Context, padID, html, authorID interface{}) (*Response, error) {
+	return pad.sendRequest(ctx, "setHTML", withAuthorID(map[string]interface{}{"padID": padID, "html": html}, authorID))
 }
 
 func (pad *EtherpadLite) GetAttributePool(ctx context.Context, padID interface{}) (*Response, error) {
@@ -343,21 +639,31 @@ func (pad *EtherpadLite) CreateDiffHTML(ctx context.Context, padID, startRev, en
 		})
 }
 
-func (pad *EtherpadLite) RestoreRevision(ctx context.Context, padId, rev interface{}) (*Response, error) {
-	return pad.sendRequest(ctx, "restoreRevision", map[string]interface{}{"padId": padId, "rev": rev})
+func (pad *EtherpadLite) RestoreRevision(ctx context.Context, padID, rev interface{}) (*Response, error) {
+	return pad.RestoreRevisionAs(ctx, padID, rev, OptionalParam)
+}
+
+// RestoreRevisionAs is like RestoreRevision but attributes the change to the
+// author with the ID authorID (requires etherpad 1.9 or newer). If authorID is
+// OptionalParam the parameter is not sent.
+func (pad *EtherpadLite) RestoreRevisionAs(ctx context.Context, padID, rev, authorID interface{}) (*Response, error) {
+	return pad.sendRequest(ctx, "restoreRevision", withAuthorID(map[string]interface{}{"padID": padID, "rev": rev}, authorID))
 }
 
 // Chat
 
 func (pad *EtherpadLite) GetChatHistory(ctx context.Context, padID, start, end interface{}) (*Response, error) {
 	params := map[string]interface{}{"padID": padID}
-	// actually here both start and end must be != OptionalParam, not just one
-	// of them. But we let the user read the docs, errors here are only for things
-	// that really go wrong
-	if start != OptionalParam {
-		params["start"] = start
+	// both start and end must be set, or none of them
+	if isMissing(start) != isMissing(end) {
+		missing := "start"
+		if isMissing(end) {
+			missing = "end"
+		}
+		return nil, &MissingParameterError{Method: "getChatHistory", Parameter: missing}
 	}
-	if end != OptionalParam {
+	if !isMissing(start) {
+		params["start"] = start
 		params["end"] = end
 	}
 	return pad.sendRequest(ctx, "getChatHistory", params)
@@ -378,11 +684,18 @@ func (pad *EtherpadLite) appendChatMessage(ctx context.Context, padID, text, aut
 // Pad
 
 func (pad *EtherpadLite) CreatePad(ctx context.Context, padID, text interface{}) (*Response, error) {
+	return pad.CreatePadAs(ctx, padID, text, OptionalParam)
+}
+
+// CreatePadAs is like CreatePad but attributes the initial text to the author
+// with the ID authorID (requires etherpad 1.9 or newer). If authorID is
+// OptionalParam the parameter is not sent.
+func (pad *EtherpadLite) CreatePadAs(ctx context.Context, padID, text, authorID interface{}) (*Response, error) {
 	params := map[string]interface{}{"padID": padID}
 	if text != OptionalParam {
 		params["text"] = text
 	}
-	return pad.sendRequest(ctx, "createPad", params)
+	return pad.sendRequest(ctx, "createPad", withAuthorID(params, authorID))
 }
 
 func (pad *EtherpadLite) GetRevisionsCount(ctx context.Context, padID interface{}) (*Response, error) {
@@ -437,6 +750,21 @@ func (pad *EtherpadLite) MovePad(ctx context.Context, sourceID, destinationID, f
 	return pad.sendRequest(ctx, "movePad", params)
 }
 
+// CopyPadWithoutHistory copies a pad without its revision history (the
+// destination pad only has the current content). It requires API version
+// 1.2.15, which is newer than CurrentVersion: Set APIVersion (see
+// WithAPIVersion), otherwise an error wrapping ErrUnsupportedInVersion is
+// returned and no request is sent.
+func (pad *EtherpadLite) CopyPadWithoutHistory(ctx context.Context, sourceID, destinationID, force interface{}) (*Response, error) {
+	params := map[string]interface{}{"sourceID": sourceID, "destinationID": destinationID}
+	if force == OptionalParam {
+		params["force"] = false
+	} else {
+		params["force"] = force
+	}
+	return pad.sendRequest(ctx, "copyPadWithoutHistory", params)
+}
+
 func (pad *EtherpadLite) GetReadOnlyID(ctx context.Context, padID interface{}) (*Response, error) {
 	return pad.sendRequest(ctx, "getReadOnlyID", map[string]interface{}{"padID": padID})
 }
@@ -469,7 +797,17 @@ func (pad *EtherpadLite) GetLastEdited(ctx context.Context, padID interface{}) (
 	return pad.sendRequest(ctx, "getLastEdited", map[string]interface{}{"padID": padID})
 }
 
+// SendClientsMessage sends a custom message to all clients connected to a pad.
+// If msg is a string it is sent unchanged, all other values (for example maps
+// or structs) are encoded to JSON first.
 func (pad *EtherpadLite) SendClientsMessage(ctx context.Context, padID, msg interface{}) (*Response, error) {
+	if _, isString := msg.(string); !isString && !isMissing(msg) {
+		encoded, err := json.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("can't encode message for sendClientsMessage: %w", err)
+		}
+		msg = string(encoded)
+	}
 	return pad.sendRequest(ctx, "sendClientsMessage", map[string]interface{}{"padID": padID, "msg": msg})
 }
 
@@ -482,3 +820,11 @@ func (pad *EtherpadLite) CheckToken(ctx context.Context) (*Response, error) {
 func (pad *EtherpadLite) ListAllPads(ctx context.Context) (*Response, error) {
 	return pad.sendRequest(ctx, "listAllPads", nil)
 }
+
+// GetStats returns statistics about the etherpad instance. It requires API
+// version 1.2.14, which is newer than CurrentVersion: Set APIVersion (see
+// WithAPIVersion), otherwise an error wrapping ErrUnsupportedInVersion is
+// returned and no request is sent.
+func (pad *EtherpadLite) GetStats(ctx context.Context) (*Response, error) {
+	return pad.sendRequest(ctx, "getStats", nil)
+}
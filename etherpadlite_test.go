@@ -0,0 +1,559 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+	"github.com/FabianWe/etherpadlite-golang/mock"
+)
+
+func newTestClient(t *testing.T) (*etherpadtest.Server, *etherpadlite.EtherpadLite) {
+	t.Helper()
+	server := etherpadtest.NewServer(t)
+	pad := server.Client()
+	pad.RaiseEtherpadErrors = true
+	return server, pad
+}
+
+func TestPadText(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	if _, err := pad.CreatePad(ctx, "pad", "Hello"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pad.AppendText(ctx, "pad", " World"); err != nil {
+		t.Fatal(err)
+	}
+	text, err := pad.GetTextContent(ctx, "pad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Hello\n World\n" {
+		t.Errorf("expected text %q, got %q", "Hello\n World\n", text)
+	}
+	text, err = pad.GetTextContent(ctx, "pad", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Hello\n" {
+		t.Errorf("expected text %q in revision 0, got %q", "Hello\n", text)
+	}
+	if _, err := pad.SetText(ctx, "pad", "replaced"); err != nil {
+		t.Fatal(err)
+	}
+	revs, err := pad.RevisionsCount(ctx, "pad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revs != 2 {
+		t.Errorf("expected 2 revisions, got %d", revs)
+	}
+	if text, _ := server.Store.Text("pad"); text != "replaced\n" {
+		t.Errorf("expected text %q in the store, got %q", "replaced\n", text)
+	}
+}
+
+func TestPadLifecycle(t *testing.T) {
+	_, pad := newTestClient(t)
+	ctx := context.Background()
+	if _, err := pad.CreatePad(ctx, "pad", etherpadlite.OptionalParam); err != nil {
+		t.Fatal(err)
+	}
+	_, err := pad.CreatePad(ctx, "pad", etherpadlite.OptionalParam)
+	if !errors.Is(err, etherpadlite.ErrPadExists) {
+		t.Errorf("expected ErrPadExists creating the pad twice, got %v", err)
+	}
+	ids, err := pad.ListAllPadIDs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != "pad" {
+		t.Errorf("expected pads [pad], got %v", ids)
+	}
+	if _, err := pad.DeletePad(ctx, "pad"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = pad.GetText(ctx, "pad", etherpadlite.OptionalParam)
+	if !errors.Is(err, etherpadlite.ErrPadNotFound) {
+		t.Errorf("expected ErrPadNotFound after deleting the pad, got %v", err)
+	}
+}
+
+func TestErrorsNotRaised(t *testing.T) {
+	server := etherpadtest.NewServer(t)
+	pad := server.Client()
+	resp, err := pad.GetText(context.Background(), "missing", etherpadlite.OptionalParam)
+	if err != nil {
+		t.Fatalf("expected no error with RaiseEtherpadErrors disabled, got %v", err)
+	}
+	if resp.Code != etherpadlite.WrongParameters {
+		t.Errorf("expected code %v, got %v", etherpadlite.WrongParameters, resp.Code)
+	}
+	if !errors.Is(etherpadlite.ResponseToError(resp), etherpadlite.ErrPadNotFound) {
+		t.Errorf("expected the response to be converted to ErrPadNotFound, got %v", etherpadlite.ResponseToError(resp))
+	}
+}
+
+func TestWrongAPIKey(t *testing.T) {
+	server := etherpadtest.NewServer(t, etherpadtest.WithAPIKey("secret"))
+	pad := server.Client()
+	pad.BaseParams["apikey"] = "wrong"
+	pad.RaiseEtherpadErrors = true
+	_, err := pad.CheckToken(context.Background())
+	if !errors.Is(err, etherpadlite.ErrWrongAPIKey) {
+		t.Errorf("expected ErrWrongAPIKey, got %v", err)
+	}
+	if err := server.Client().Verify(context.Background()); err != nil {
+		t.Errorf("expected the correct key to be accepted, got %v", err)
+	}
+}
+
+func TestRequestParams(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	if _, err := pad.CreatePad(ctx, "pad", "text"); err != nil {
+		t.Fatal(err)
+	}
+	requests := server.RequestsFor("createPad")
+	if len(requests) != 1 {
+		t.Fatalf("expected one createPad request, got %d", len(requests))
+	}
+	params := requests[0].Params
+	if got := params.Get("apikey"); got != etherpadtest.DefaultAPIKey {
+		t.Errorf("expected apikey %q, got %q", etherpadtest.DefaultAPIKey, got)
+	}
+	if got := params.Get("padID"); got != "pad" {
+		t.Errorf("expected padID %q, got %q", "pad", got)
+	}
+	if got := params.Get("text"); got != "text" {
+		t.Errorf("expected text %q, got %q", "text", got)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	_, pad := newTestClient(t)
+	ctx := context.Background()
+	groupID, err := pad.CreateGroupIDFor(ctx, "mapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := pad.CreateGroupIDFor(ctx, "mapper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != groupID {
+		t.Errorf("expected the same group for the same mapper, got %s and %s", groupID, again)
+	}
+	for _, name := range []string{"b", "a"} {
+		if _, err := pad.CreateGroupPad(ctx, groupID, name, etherpadlite.OptionalParam); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err := pad.ListGroupPadNames(ctx, groupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("expected pad names [a b], got %v", names)
+	}
+	groups, err := pad.AllGroupIDs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0] != groupID {
+		t.Errorf("expected groups [%s], got %v", groupID, groups)
+	}
+	if _, err := pad.DeleteGroup(ctx, groupID); err != nil {
+		t.Fatal(err)
+	}
+	_, err = pad.ListPads(ctx, groupID)
+	if !errors.Is(err, etherpadlite.ErrGroupNotFound) {
+		t.Errorf("expected ErrGroupNotFound after deleting the group, got %v", err)
+	}
+}
+
+func TestAuthors(t *testing.T) {
+	_, pad := newTestClient(t)
+	ctx := context.Background()
+	authorID, err := pad.EnsureAuthorID(ctx, "user-1", "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := pad.AuthorName(ctx, authorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Alice" {
+		t.Errorf("expected name Alice, got %q", name)
+	}
+	if _, err := pad.CreatePadAs(ctx, "pad", "text", authorID); err != nil {
+		t.Fatal(err)
+	}
+	authors, err := pad.AuthorsOfPad(ctx, "pad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(authors) != 1 || authors[0] != authorID {
+		t.Errorf("expected authors [%s], got %v", authorID, authors)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	_, pad := newTestClient(t)
+	ctx := context.Background()
+	groupID, err := pad.CreateGroupID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorID, err := pad.CreateAuthorID(ctx, "Bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	validUntil := time.Now().Add(time.Hour).Truncate(time.Second)
+	session, err := pad.CreateSessionTyped(ctx, groupID, authorID, validUntil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := pad.GetSession(ctx, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.GroupID != groupID || info.AuthorID != authorID || !info.ValidUntil.Equal(validUntil) {
+		t.Errorf("expected session %+v, got %+v", session, info)
+	}
+	sessions, err := pad.ListGroupSessions(ctx, groupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, has := sessions[session.ID]; !has || len(sessions) != 1 {
+		t.Errorf("expected only session %s in the group, got %v", session.ID, sessions)
+	}
+	if _, err := pad.DeleteSession(ctx, session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pad.GetSession(ctx, session.ID); err == nil {
+		t.Error("expected an error getting a deleted session")
+	}
+}
+
+func TestPadSettings(t *testing.T) {
+	_, pad := newTestClient(t)
+	ctx := context.Background()
+	// the public status and password can only be set for group pads
+	groupID, err := pad.CreateGroupID(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	padID, _, err := pad.EnsureGroupPad(ctx, groupID, "pad", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readOnlyID, err := pad.ReadOnlyID(ctx, padID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := pad.PadIDFromReadOnly(ctx, readOnlyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != padID {
+		t.Errorf("expected read-only ID %s to resolve to %s, got %q", readOnlyID, padID, resolved)
+	}
+	if _, err := pad.SetPublicStatus(ctx, padID, true); err != nil {
+		t.Fatal(err)
+	}
+	public, err := pad.PublicStatus(ctx, padID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !public {
+		t.Error("expected pad to be public")
+	}
+	if _, err := pad.SetPassword(ctx, padID, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	protected, err := pad.PasswordProtected(ctx, padID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !protected {
+		t.Error("expected pad to be password protected")
+	}
+}
+
+func TestFailNext(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	server.FailNext("createPad", etherpadtest.Failure{Code: etherpadlite.InternalError})
+	_, err := pad.CreatePad(ctx, "pad", etherpadlite.OptionalParam)
+	if !errors.Is(err, etherpadlite.ErrInternal) {
+		t.Errorf("expected ErrInternal, got %v", err)
+	}
+	if _, has := server.Store.Text("pad"); has {
+		t.Error("expected the failed request not to create the pad")
+	}
+	server.FailNext("createPad", etherpadtest.Failure{Status: 502})
+	_, err = pad.CreatePad(ctx, "pad", etherpadlite.OptionalParam)
+	var reqErr *etherpadlite.RequestError
+	if !errors.As(err, &reqErr) || reqErr.Method != "createPad" {
+		t.Errorf("expected a RequestError for createPad, got %v", err)
+	}
+	if _, err := pad.CreatePad(ctx, "pad", etherpadlite.OptionalParam); err != nil {
+		t.Errorf("expected only the next request to fail, got %v", err)
+	}
+}
+
+func TestCallUnknownMethod(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	server.Store.ExpectCall("pluginMethod").
+		WithParam("padID", "pad").
+		WithParam("count", "3").
+		Times(1).
+		Return(mock.CannedResponse{Data: map[string]interface{}{"result": "done"}})
+	resp, err := pad.Call(ctx, "pluginMethod", map[string]interface{}{"padID": "pad", "count": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := resp.GetString("result")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != "done" {
+		t.Errorf("expected result done, got %q", result)
+	}
+	requests := server.RequestsFor("pluginMethod")
+	if len(requests) != 1 {
+		t.Fatalf("expected one pluginMethod request, got %d", len(requests))
+	}
+	if got := requests[0].Params.Get("apikey"); got != etherpadtest.DefaultAPIKey {
+		t.Errorf("expected the API key to be sent with Call, got %q", got)
+	}
+	server.Store.Verify(t)
+
+	// without a canned response the method doesn't exist, the error is raised
+	// like for all other methods
+	_, err = pad.Call(ctx, "otherPluginMethod", nil)
+	if !errors.Is(err, etherpadlite.ErrNoSuchFunction) {
+		t.Errorf("expected ErrNoSuchFunction, got %v", err)
+	}
+	server.Store.QueueResponse("otherPluginMethod", mock.CannedResponse{
+		Code:    etherpadlite.WrongParameters,
+		Message: "plugin error",
+	})
+	_, err = pad.Call(ctx, "otherPluginMethod", nil)
+	var etherpadErr etherpadlite.EtherpadError
+	if !errors.As(err, &etherpadErr) || etherpadErr.Message != "plugin error" {
+		t.Errorf("expected EtherpadError with message plugin error, got %v", err)
+	}
+}
+
+// lastParams returns the parameters of the last request for method.
+func lastParams(t *testing.T, server *etherpadtest.Server, method string) url.Values {
+	t.Helper()
+	requests := server.RequestsFor(method)
+	if len(requests) == 0 {
+		t.Fatalf("no %s request received", method)
+	}
+	return requests[len(requests)-1].Params
+}
+
+// TestRestoreRevisionPadID is a regression test: restoreRevision must send
+// padID, not padId.
+func TestRestoreRevisionPadID(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	if _, err := pad.CreatePad(ctx, "pad", "first"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pad.SetText(ctx, "pad", "second"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pad.RestoreRevision(ctx, "pad", 0); err != nil {
+		t.Fatal(err)
+	}
+	params := lastParams(t, server, "restoreRevision")
+	if got := params["padID"]; len(got) != 1 || got[0] != "pad" {
+		t.Errorf("expected padID pad, got %v", got)
+	}
+	if _, has := params["padId"]; has {
+		t.Errorf("expected padId not to be sent, got %v", params)
+	}
+	if got := params.Get("rev"); got != "0" {
+		t.Errorf("expected rev 0, got %q", got)
+	}
+	if text, _ := server.Store.Text("pad"); text != "first\n" {
+		t.Errorf("expected revision 0 to be restored, got text %q", text)
+	}
+}
+
+func TestSendClientsMessageJSON(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	if _, err := pad.CreatePad(ctx, "pad", "text"); err != nil {
+		t.Fatal(err)
+	}
+	type payload struct {
+		Type  string   `json:"type"`
+		Count int      `json:"count"`
+		Tags  []string `json:"tags"`
+	}
+	tests := []struct {
+		name     string
+		msg      interface{}
+		expected string
+	}{
+		{"string", "plain text", "plain text"},
+		{"json string", `{"already":"encoded"}`, `{"already":"encoded"}`},
+		{"struct", payload{Type: "notify", Count: 2, Tags: []string{"a", "b"}}, `{"type":"notify","count":2,"tags":["a","b"]}`},
+		{"pointer", &payload{Type: "notify"}, `{"type":"notify","count":0,"tags":null}`},
+		{"map", map[string]interface{}{"b": 1, "a": true}, `{"a":true,"b":1}`},
+		{"number", 42, "42"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := pad.SendClientsMessage(ctx, "pad", test.msg); err != nil {
+				t.Fatal(err)
+			}
+			if got := lastParams(t, server, "sendClientsMessage").Get("msg"); got != test.expected {
+				t.Errorf("expected msg %s, got %s", test.expected, got)
+			}
+		})
+	}
+	_, err := pad.SendClientsMessage(ctx, "pad", func() {})
+	if err == nil {
+		t.Error("expected an error for a message that can't be encoded")
+	}
+}
+
+func TestAuthorIDParam(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	authorID := server.Store.AddAuthor("author", "Alice")
+	if err := server.Store.AddPad("pad", "text"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		method string
+		call   func(authorID interface{}) (*etherpadlite.Response, error)
+	}{
+		{"createPad", func(authorID interface{}) (*etherpadlite.Response, error) {
+			pad.DeletePad(ctx, "created")
+			return pad.CreatePadAs(ctx, "created", "text", authorID)
+		}},
+		{"setText", func(authorID interface{}) (*etherpadlite.Response, error) {
+			return pad.SetTextAs(ctx, "pad", "text", authorID)
+		}},
+		{"appendText", func(authorID interface{}) (*etherpadlite.Response, error) {
+			return pad.AppendTextAs(ctx, "pad", "text", authorID)
+		}},
+		{"setHTML", func(authorID interface{}) (*etherpadlite.Response, error) {
+			return pad.SetHTMLAs(ctx, "pad", "<p>text</p>", authorID)
+		}},
+		{"restoreRevision", func(authorID interface{}) (*etherpadlite.Response, error) {
+			return pad.RestoreRevisionAs(ctx, "pad", 0, authorID)
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.method, func(t *testing.T) {
+			if _, err := test.call(authorID); err != nil {
+				t.Fatal(err)
+			}
+			if got := lastParams(t, server, test.method)["authorId"]; len(got) != 1 || got[0] != authorID {
+				t.Errorf("expected authorId %s, got %v", authorID, got)
+			}
+			if _, err := test.call(etherpadlite.OptionalParam); err != nil {
+				t.Fatal(err)
+			}
+			if got, has := lastParams(t, server, test.method)["authorId"]; has {
+				t.Errorf("expected authorId not to be sent, got %v", got)
+			}
+		})
+	}
+}
+
+func TestEnsureTextMissing(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	action, err := pad.EnsureTextAction(ctx, "pad", "Hello", etherpadlite.EnsureTextOptions{EnsureMissing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action != etherpadlite.TextCreated {
+		t.Errorf("expected action %q, got %q", etherpadlite.TextCreated, action)
+	}
+	if text, _ := server.Store.Text("pad"); text != "Hello\n" {
+		t.Errorf("expected text %q, got %q", "Hello\n", text)
+	}
+	if revs, _ := server.Store.Revisions("pad"); revs != 0 {
+		t.Errorf("expected the pad to be created without an extra revision, got %d revisions", revs)
+	}
+	if n := len(server.RequestsFor("setText")); n != 0 {
+		t.Errorf("expected no setText request, got %d", n)
+	}
+
+	_, err = pad.EnsureTextAction(ctx, "missing", "Hello")
+	if !errors.Is(err, etherpadlite.ErrPadNotFound) {
+		t.Errorf("expected ErrPadNotFound without EnsureMissing, got %v", err)
+	}
+}
+
+func TestNewerAPIVersionRequired(t *testing.T) {
+	server, pad := newTestClient(t)
+	if err := server.Store.AddPad("source", "text"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	calls := []struct {
+		version string
+		call    func() error
+	}{
+		{"1.2.14", func() error {
+			_, err := pad.GetStats(ctx)
+			return err
+		}},
+		{"1.2.14", func() error {
+			_, err := pad.StatsTyped(ctx)
+			return err
+		}},
+		{"1.2.15", func() error {
+			_, err := pad.CopyPadWithoutHistory(ctx, "source", "copy", true)
+			return err
+		}},
+	}
+	for _, c := range calls {
+		pad.APIVersion = etherpadlite.CurrentVersion
+		if err := c.call(); !errors.Is(err, etherpadlite.ErrUnsupportedInVersion) {
+			t.Errorf("expected ErrUnsupportedInVersion with the default version, got %v", err)
+		}
+		if n := len(server.Requests()); n != 0 {
+			t.Errorf("expected no request with the default version, got %d", n)
+		}
+		pad.APIVersion = c.version
+		if err := c.call(); err != nil {
+			t.Errorf("version %s: %v", c.version, err)
+		}
+		server.Reset()
+	}
+}
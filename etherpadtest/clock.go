@@ -0,0 +1,171 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadtest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// FakeClock is an etherpadlite.Clock that only advances when told so, timers
+// and tickers fire during Advance. Use BlockUntil to wait until the code
+// under test is waiting on the clock:
+//
+//	clock := etherpadtest.NewFakeClock(time.Now())
+//	pad.Clock = clock
+//	go codeThatRetries(pad)
+//	clock.BlockUntil(1)
+//	clock.Advance(time.Minute)
+//
+// A FakeClock is safe for concurrent use.
+type FakeClock struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+var _ etherpadlite.Clock = (*FakeClock)(nil)
+
+// NewFakeClock returns a fake clock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mutex)
+	return c
+}
+
+// fakeWaiter is a timer (period 0) or a ticker of a FakeClock.
+type fakeWaiter struct {
+	clock  *FakeClock
+	when   time.Time
+	period time.Duration
+	c      chan time.Time
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+// Stop implements etherpadlite.Timer, it returns false if the waiter already
+// fired or was stopped.
+func (w *fakeWaiter) Stop() bool {
+	return w.clock.remove(w)
+}
+
+// fakeTicker wraps a fakeWaiter to implement etherpadlite.Ticker.
+type fakeTicker struct {
+	*fakeWaiter
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeWaiter.Stop()
+}
+
+// Now implements etherpadlite.Clock.
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// add adds a new waiter.
+func (c *FakeClock) add(d, period time.Duration) *fakeWaiter {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	w := &fakeWaiter{clock: c, when: c.now.Add(d), period: period, c: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, w)
+	c.cond.Broadcast()
+	// timers with a non-positive duration fire immediately
+	c.fire()
+	return w
+}
+
+// remove removes a waiter, it returns false if it was not active.
+func (c *FakeClock) remove(w *fakeWaiter) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i, other := range c.waiters {
+		if other == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// NewTimer implements etherpadlite.Clock.
+func (c *FakeClock) NewTimer(d time.Duration) etherpadlite.Timer {
+	return c.add(d, 0)
+}
+
+// NewTicker implements etherpadlite.Clock. It panics if d <= 0, like
+// time.NewTicker.
+func (c *FakeClock) NewTicker(d time.Duration) etherpadlite.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	return fakeTicker{c.add(d, d)}
+}
+
+// fire sends the time on the channels of all waiters that are due. Like
+// time.Ticker a ticker drops ticks if the receiver is too slow. c.mutex must
+// be locked.
+func (c *FakeClock) fire() {
+	active := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.when.After(c.now) {
+			active = append(active, w)
+			continue
+		}
+		select {
+		case w.c <- w.when:
+		default:
+		}
+		if w.period > 0 {
+			for !w.when.After(c.now) {
+				w.when = w.when.Add(w.period)
+			}
+			active = append(active, w)
+		}
+	}
+	c.waiters = active
+}
+
+// Advance moves the clock forward by d and fires all timers and tickers that
+// are due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// Waiters returns the number of active timers and tickers.
+func (c *FakeClock) Waiters() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.waiters)
+}
+
+// BlockUntil blocks until at least n timers and tickers are active.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
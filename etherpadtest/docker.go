@@ -0,0 +1,209 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+
+package etherpadtest
+
+// This file contains a harness for integration tests against a real etherpad,
+// it is only built with the build tag integration:
+//
+//	go test -tags=integration ./...
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+const (
+	// EnvURL is the environment variable with the base URL of the API of an
+	// existing etherpad (for example "http://localhost:9001/api"). If it is
+	// set StartEtherpad uses this server instead of starting a container.
+	EnvURL = "ETHERPAD_TEST_URL"
+
+	// EnvAPIKey is the environment variable with the API key of the server
+	// given by EnvURL.
+	EnvAPIKey = "ETHERPAD_TEST_APIKEY"
+
+	// DefaultImage is the docker image started by StartEtherpad.
+	DefaultImage = "etherpad/etherpad"
+
+	// DefaultReadyTimeout is the time StartEtherpad waits for etherpad to
+	// become ready.
+	DefaultReadyTimeout = 2 * time.Minute
+
+	// apiKeyPath is the path of the API key file in the official image.
+	apiKeyPath = "/opt/etherpad-lite/APIKEY.txt"
+
+	// containerPort is the port etherpad listens on in the container.
+	containerPort = "9001/tcp"
+)
+
+// DockerOptions are options for StartEtherpad.
+type DockerOptions struct {
+	// Image is the docker image, DefaultImage if empty.
+	Image string
+
+	// ReadyTimeout is the time to wait until etherpad is ready,
+	// DefaultReadyTimeout if <= 0.
+	ReadyTimeout time.Duration
+}
+
+// StartEtherpad returns a client connected to a real etherpad and a cleanup
+// function that stops it. The cleanup function is also registered with
+// t.Cleanup, calling it multiple times is safe.
+//
+// If the environment variable EnvURL is set the server given by EnvURL and
+// EnvAPIKey is used. Otherwise a container of the official etherpad image is
+// started with docker (the port is published on a random port of the host)
+// and the API key generated by etherpad is read from the container. The test
+// is skipped if docker is not available.
+// In both cases StartEtherpad waits until the server accepts the API key
+// (see EtherpadLite.HealthCheck), if this doesn't happen within the timeout
+// the test fails.
+func StartEtherpad(t testing.TB, opts ...DockerOptions) (*etherpadlite.EtherpadLite, func()) {
+	t.Helper()
+	var options DockerOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.Image == "" {
+		options.Image = DefaultImage
+	}
+	if options.ReadyTimeout <= 0 {
+		options.ReadyTimeout = DefaultReadyTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), options.ReadyTimeout)
+	defer cancel()
+	if baseURL := os.Getenv(EnvURL); baseURL != "" {
+		apiKey := os.Getenv(EnvAPIKey)
+		if apiKey == "" {
+			t.Fatalf("%s is set but %s is empty", EnvURL, EnvAPIKey)
+		}
+		pad := etherpadlite.NewEtherpadLite(apiKey)
+		pad.BaseURL = baseURL
+		if err := waitReady(ctx, pad); err != nil {
+			t.Fatalf("etherpad at %s is not ready: %v", baseURL, err)
+		}
+		return pad, func() {}
+	}
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not available, set", EnvURL, "to run against an existing etherpad")
+	}
+	if _, err := docker(ctx, "info"); err != nil {
+		t.Skip("docker is not usable:", err)
+	}
+	id, err := docker(ctx, "run", "-d", "-P", "-e", "AUTHENTICATION_METHOD=apikey", options.Image)
+	if err != nil {
+		t.Fatalf("starting %s: %v", options.Image, err)
+	}
+	var once sync.Once
+	cleanup := func() {
+		once.Do(func() {
+			if _, err := docker(context.Background(), "rm", "-f", "-v", id); err != nil {
+				t.Logf("removing container %s: %v", id, err)
+			}
+		})
+	}
+	t.Cleanup(cleanup)
+	pad, err := containerClient(ctx, id)
+	if err == nil {
+		err = waitReady(ctx, pad)
+	}
+	if err != nil {
+		if logs, logErr := docker(context.Background(), "logs", "--tail", "20", id); logErr == nil {
+			t.Log(logs)
+		}
+		cleanup()
+		t.Fatalf("etherpad in container %s is not ready: %v", id, err)
+	}
+	return pad, cleanup
+}
+
+// docker runs docker with the given arguments and returns the trimmed
+// output.
+func docker(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// containerClient returns a client for the etherpad in the container. The API
+// key file is created by etherpad during startup, so it's polled until it
+// exists or ctx is done.
+func containerClient(ctx context.Context, id string) (*etherpadlite.EtherpadLite, error) {
+	ports, err := docker(ctx, "port", id, containerPort)
+	if err != nil {
+		return nil, err
+	}
+	// one line per address, for example "0.0.0.0:49153" and "[::]:49153"
+	_, port, err := net.SplitHostPort(strings.SplitN(ports, "\n", 2)[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid port mapping %q: %w", ports, err)
+	}
+	var apiKey string
+	err = poll(ctx, func() error {
+		apiKey, err = docker(ctx, "exec", id, "cat", apiKeyPath)
+		if err == nil && apiKey == "" {
+			err = fmt.Errorf("%s is empty", apiKeyPath)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	pad := etherpadlite.NewEtherpadLite(apiKey)
+	pad.BaseURL = "http://localhost:" + port + "/api"
+	return pad, nil
+}
+
+// waitReady waits until the server accepts the API key of pad.
+func waitReady(ctx context.Context, pad *etherpadlite.EtherpadLite) error {
+	return poll(ctx, func() error {
+		_, err := pad.HealthCheck(ctx)
+		return err
+	})
+}
+
+// poll calls fn every second until it returns nil or ctx is done, in the
+// latter case the last error of fn is returned.
+func poll(ctx context.Context, fn func() error) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-ticker.C:
+		}
+	}
+}
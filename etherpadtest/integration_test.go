@@ -0,0 +1,35 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build integration
+
+package etherpadtest_test
+
+import (
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/conformance"
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+)
+
+// TestEtherpadConformance runs the conformance suite against a real etherpad,
+// either the one given by ETHERPAD_TEST_URL or a docker container.
+func TestEtherpadConformance(t *testing.T) {
+	pad, stop := etherpadtest.StartEtherpad(t)
+	defer stop()
+	conformance.Run(t, func() etherpadlite.API {
+		return pad
+	})
+}
@@ -0,0 +1,301 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package etherpadtest provides a fake etherpad HTTP server for tests.
+//
+// The server speaks etherpad's HTTP API backed by the in-memory store of
+// package mock, records all requests and can be told to fail requests:
+//
+//	func TestSomething(t *testing.T) {
+//		server := etherpadtest.NewServer(t)
+//		server.FailNext("setText", etherpadtest.Failure{Code: etherpadlite.InternalError})
+//		pad := server.Client()
+//		...
+//		if len(server.RequestsFor("setText")) != 2 {
+//			t.Error("setText was not retried")
+//		}
+//	}
+//
+// The server is closed once the test finished.
+package etherpadtest
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/mock"
+)
+
+// DefaultAPIKey is the API key the server accepts if no other key is set with
+// WithAPIKey.
+const DefaultAPIKey = "etherpadtest"
+
+// Request is a request received by the server.
+type Request struct {
+	// Method is the API method (for example "setText"), for requests to the
+	// frontend it is "export/<format>" or "import" as in
+	// etherpadlite.RequestError.
+	Method string
+
+	// Params are the query and form parameters, including the API key.
+	Params url.Values
+
+	Time time.Time
+}
+
+// Failure describes how the server fails a request, see Server.FailNext.
+// The fields are applied in order: Delay first, then Drop, Status and Code.
+type Failure struct {
+	// Delay delays the response, the request is answered as usual afterwards
+	// unless another field is set.
+	Delay time.Duration
+
+	// Drop closes the connection without sending a response.
+	Drop bool
+
+	// Status is the HTTP status code of the response, for example 503.
+	Status int
+
+	// Code is the return code of the response, for example InternalError.
+	// The state of the store is not changed.
+	Code etherpadlite.ReturnCode
+
+	// Message is the message of the response, the string representation of
+	// Code if empty.
+	Message string
+}
+
+// Option is an option for NewServer.
+type Option func(s *Server)
+
+// WithAPIKey sets the API key the server accepts.
+func WithAPIKey(apiKey string) Option {
+	return func(s *Server) {
+		s.APIKey = apiKey
+	}
+}
+
+// WithStore sets the store of the server, for example a store preloaded with
+// pads. The API key of the store is overwritten with the key of the server.
+func WithStore(store *mock.Store) Option {
+	return func(s *Server) {
+		s.Store = store
+	}
+}
+
+// WithTLS starts the server with TLS, the certificate is signed by a test CA
+// available from CertPool.
+func WithTLS() Option {
+	return func(s *Server) {
+		s.tls = true
+	}
+}
+
+// Server is a fake etherpad server, see the package documentation.
+type Server struct {
+	*httptest.Server
+
+	// Store is the state of the server.
+	Store *mock.Store
+
+	// APIKey is the API key the server accepts.
+	APIKey string
+
+	tls      bool
+	mutex    sync.Mutex
+	requests []Request
+	failures map[string][]Failure
+}
+
+// NewServer starts a new server that is closed once the test finished.
+func NewServer(t testing.TB, opts ...Option) *Server {
+	t.Helper()
+	s := &Server{APIKey: DefaultAPIKey, failures: make(map[string][]Failure)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.Store == nil {
+		s.Store = mock.NewStore()
+	}
+	s.Store.APIKey = s.APIKey
+	if s.tls {
+		s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	} else {
+		s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// BaseURL returns the URL of the API, to be used as EtherpadLite.BaseURL.
+func (s *Server) BaseURL() string {
+	return s.URL + "/api"
+}
+
+// Client returns a new client connected to the server with the correct API
+// key. Each call returns a new client, so the configuration of the client can
+// be changed by the test.
+func (s *Server) Client() *etherpadlite.EtherpadLite {
+	pad := etherpadlite.NewEtherpadLite(s.APIKey)
+	pad.BaseURL = s.BaseURL()
+	pad.Client = s.Server.Client()
+	return pad
+}
+
+// CertPool returns a pool containing the certificate of a server started with
+// WithTLS, to be used with etherpadlite.WithRootCAs. It returns nil if the
+// server doesn't use TLS.
+func (s *Server) CertPool() *x509.CertPool {
+	cert := s.Certificate()
+	if cert == nil {
+		return nil
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return pool
+}
+
+// FailNext makes the server fail the next request to method (an API method
+// like "setText" or a method as described in Request). Calling FailNext
+// multiple times queues the failures, each request consumes one.
+func (s *Server) FailNext(method string, failure Failure) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures[method] = append(s.failures[method], failure)
+}
+
+// Requests returns all requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsFor returns all requests to method received so far, in order.
+func (s *Server) RequestsFor(method string) []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var res []Request
+	for _, req := range s.requests {
+		if req.Method == method {
+			res = append(res, req)
+		}
+	}
+	return res
+}
+
+// Reset removes all recorded requests and queued failures, the store is not
+// changed.
+func (s *Server) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests = nil
+	s.failures = make(map[string][]Failure)
+}
+
+// methodOf returns the method of a request as described in Request.
+func methodOf(path string) string {
+	if i := strings.LastIndex(path, "/p/"); i >= 0 {
+		rest := path[i+len("/p/"):]
+		if strings.HasSuffix(rest, "/import") {
+			return "import"
+		}
+		if j := strings.LastIndex(rest, "/export/"); j >= 0 {
+			return rest[j+1:]
+		}
+		return ""
+	}
+	if i := strings.LastIndex(path, "/api/"); i >= 0 {
+		parts := strings.Split(path[i+len("/api/"):], "/")
+		if len(parts) == 2 {
+			return parts[1]
+		}
+	}
+	return ""
+}
+
+// record records the request and returns the next failure for it, nil if it
+// should be served as usual.
+func (s *Server) record(r *http.Request) *Failure {
+	method := methodOf(r.URL.Path)
+	params := url.Values{}
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		// parsing multipart forms is left to the store
+		r.ParseForm()
+		for key, values := range r.Form {
+			params[key] = append([]string(nil), values...)
+		}
+	} else {
+		for key, values := range r.URL.Query() {
+			params[key] = values
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests = append(s.requests, Request{Method: method, Params: params, Time: time.Now()})
+	queue := s.failures[method]
+	if len(queue) == 0 {
+		return nil
+	}
+	failure := queue[0]
+	s.failures[method] = queue[1:]
+	return &failure
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	failure := s.record(r)
+	if failure == nil {
+		s.Store.ServeHTTP(w, r)
+		return
+	}
+	if failure.Delay > 0 {
+		timer := time.NewTimer(failure.Delay)
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+			return
+		}
+	}
+	switch {
+	case failure.Drop:
+		if hijacker, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hijacker.Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+		// the connection can't be dropped, abort the response instead
+		panic(http.ErrAbortHandler)
+	case failure.Status != 0:
+		http.Error(w, http.StatusText(failure.Status), failure.Status)
+	case failure.Code != etherpadlite.EverythingOk:
+		message := failure.Message
+		if message == "" {
+			message = failure.Code.String()
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": failure.Code, "message": message, "data": nil})
+	default:
+		s.Store.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ExportFormat is a format a pad can be exported to, see ExportPad.
+type ExportFormat string
+
+const (
+	// ExportText exports the pad as plain text.
+	ExportText ExportFormat = "txt"
+
+	// ExportHTML exports the pad as HTML.
+	ExportHTML ExportFormat = "html"
+
+	// ExportEtherpad exports the pad in etherpad's own JSON format, including
+	// the full history. It can be imported by another etherpad.
+	ExportEtherpad ExportFormat = "etherpad"
+
+	// ExportPDF exports the pad as PDF, requires abiword or soffice to be
+	// configured in etherpad.
+	ExportPDF ExportFormat = "pdf"
+
+	// ExportODT exports the pad as OpenDocument text, requires abiword or
+	// soffice to be configured in etherpad.
+	ExportODT ExportFormat = "odt"
+
+	// ExportDOC exports the pad as Word document, requires abiword or soffice
+	// to be configured in etherpad.
+	ExportDOC ExportFormat = "doc"
+)
+
+// ExportFormats contains all known export formats.
+var ExportFormats = []ExportFormat{ExportText, ExportHTML, ExportEtherpad, ExportPDF, ExportODT, ExportDOC}
+
+// ErrFormatUnavailable is returned by ExportPad if DetectExportFormats found
+// that the server doesn't support the format.
+var ErrFormatUnavailable = errors.New("export format not available on this server")
+
+// errUnexpectedContent is wrapped by the errors of export if the server
+// answered with an unexpected status or content type, this is how etherpad
+// reports that a format is not supported.
+var errUnexpectedContent = errors.New("unexpected response")
+
+// contentTypes maps the export formats to the media types etherpad returns.
+var contentTypes = map[ExportFormat][]string{
+	ExportText:     {"text/plain"},
+	ExportHTML:     {"text/html"},
+	ExportEtherpad: {"application/json", "application/octet-stream"},
+	ExportPDF:      {"application/pdf"},
+	ExportODT:      {"application/vnd.oasis.opendocument.text"},
+	ExportDOC:      {"application/msword"},
+}
+
+// exportFormatCache caches the result of DetectExportFormats.
+type exportFormatCache struct {
+	mutex     sync.Mutex
+	available map[ExportFormat]bool
+}
+
+// get returns the cached availability of format, known is false if the
+// formats have not been probed yet.
+func (c *exportFormatCache) get(format ExportFormat) (available, known bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.available == nil {
+		return false, false
+	}
+	return c.available[format], true
+}
+
+// formats returns the cached availability of all formats, nil if the formats
+// have not been probed yet. The map must not be modified.
+func (c *exportFormatCache) formats() map[ExportFormat]bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.available
+}
+
+// store caches available unless another probe finished first and returns the
+// cached availability.
+func (c *exportFormatCache) store(available map[ExportFormat]bool) map[ExportFormat]bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.available == nil {
+		c.available = available
+	}
+	return c.available
+}
+
+// frontendURL returns the URL of the etherpad frontend, that is BaseURL
+// without the "/api" suffix.
+func (pad *EtherpadLite) frontendURL() (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSuffix(pad.BaseURL, "/"))
+	if err != nil {
+		return nil, err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/api")
+	u.RawPath = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u, nil
+}
+
+// padPath returns the URL of the pad in the frontend plus the given suffix
+// (for example "/export/txt"), the pad ID is escaped as a path segment.
+// The suffix must not contain characters that need escaping.
+func (pad *EtherpadLite) padPath(padID, suffix string) (*url.URL, error) {
+	u, err := pad.frontendURL()
+	if err != nil {
+		return nil, err
+	}
+	base := u.EscapedPath()
+	u.Path = u.Path + "/p/" + padID + suffix
+	u.RawPath = base + "/p/" + url.PathEscape(padID) + suffix
+	return u, nil
+}
+
+// addSessionCookie adds the sessionID cookie to req if ctx contains session
+// IDs (see WithSessionIDs).
+func addSessionCookie(ctx context.Context, req *http.Request) {
+	if ids := sessionIDsFromContext(ctx); len(ids) > 0 {
+		req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: strings.Join(ids, ",")})
+	}
+}
+
+// checkContentType returns an error if the content type of resp is not one of
+// the expected types.
+func checkContentType(resp *http.Response, expected []string) error {
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("%w: invalid content type %q", errUnexpectedContent, contentType)
+	}
+	for _, t := range expected {
+		if strings.EqualFold(mediaType, t) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: content type %q, expected %s", errUnexpectedContent, mediaType, strings.Join(expected, " or "))
+}
+
+// ExportPad exports a pad in the given format and writes it to w. The export
+// is streamed, it's never held in memory completely.
+//
+// The export is done by the etherpad frontend (/p/<padID>/export/<format>),
+// the URL is derived from BaseURL by removing the "/api" suffix. The frontend
+// doesn't accept the API key, for pads that require a session (group pads
+// that are not public) use a context created with WithSessionIDs.
+//
+// Non-successful status codes and unexpected content types (etherpad serves
+// error pages as HTML) result in a RequestError.
+// If DetectExportFormats was called before and found that the format is not
+// supported ErrFormatUnavailable is returned without sending a request.
+func (pad *EtherpadLite) ExportPad(ctx context.Context, padID string, format ExportFormat, w io.Writer) error {
+	method := "export/" + string(format)
+	expected, known := contentTypes[format]
+	if !known {
+		return &RequestError{Method: method, Err: fmt.Errorf("unknown export format %q", format)}
+	}
+	if available, probed := pad.exportFormats.get(format); probed && !available {
+		return &RequestError{Method: method, Err: ErrFormatUnavailable}
+	}
+	return pad.export(ctx, padID, format, expected, w)
+}
+
+// export downloads the export of a pad in format and checks the content type.
+func (pad *EtherpadLite) export(ctx context.Context, padID string, format ExportFormat, expected []string, w io.Writer) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	method := "export/" + string(format)
+	u, err := pad.padPath(padID, "/export/"+string(format))
+	if err != nil {
+		return &RequestError{Method: method, Err: err}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return &RequestError{Method: method, Err: err}
+	}
+	addSessionCookie(ctx, req)
+	requestErr := func(err error) error {
+		return &RequestError{Method: method, URL: RedactURL(u.String()), Err: err}
+	}
+	release, err := pad.limiter.acquire(ctx, pad.MaxConcurrentRequests)
+	if err != nil {
+		return requestErr(err)
+	}
+	defer release()
+	resp, err := pad.doRequest(ctx, req)
+	if err != nil {
+		return requestErr(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return requestErr(fmt.Errorf("%w: HTTP status %s", errUnexpectedContent, resp.Status))
+	}
+	if err := checkContentType(resp, expected); err != nil {
+		return requestErr(err)
+	}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return requestErr(err)
+	}
+	return nil
+}
+
+// DetectExportFormats returns the export formats supported by the server.
+// The binary formats (pdf, odt and doc) are only supported if abiword or
+// soffice is configured in etherpad.
+//
+// To find out the formats a temporary pad is created, exported in each format
+// and deleted again. The result is cached, subsequent calls (and ExportPad)
+// use the cached result. The cache is not locked while probing, concurrent
+// calls before the first result is cached probe as well.
+func (pad *EtherpadLite) DetectExportFormats(ctx context.Context) ([]ExportFormat, error) {
+	available := pad.exportFormats.formats()
+	if available == nil {
+		probed, err := pad.probeExportFormats(ctx)
+		if err != nil {
+			return nil, err
+		}
+		available = pad.exportFormats.store(probed)
+	}
+	res := make([]ExportFormat, 0, len(ExportFormats))
+	for _, format := range ExportFormats {
+		if available[format] {
+			res = append(res, format)
+		}
+	}
+	return res, nil
+}
+
+// probeExportFormats exports a temporary pad in all formats to find out which
+// formats are supported.
+func (pad *EtherpadLite) probeExportFormats(ctx context.Context) (map[ExportFormat]bool, error) {
+	var random [8]byte
+	if _, err := rand.Read(random[:]); err != nil {
+		return nil, err
+	}
+	padID := "etherpadlite-probe-" + hex.EncodeToString(random[:])
+	if _, err := pad.callChecked(ctx, "createPad", map[string]interface{}{"padID": padID, "text": "probe"}); err != nil {
+		return nil, err
+	}
+	defer pad.callChecked(context.Background(), "deletePad", map[string]interface{}{"padID": padID})
+	available := make(map[ExportFormat]bool, len(ExportFormats))
+	for _, format := range ExportFormats {
+		err := pad.export(ctx, padID, format, contentTypes[format], ioutil.Discard)
+		switch {
+		case err == nil:
+			available[format] = true
+		case errors.Is(err, errUnexpectedContent):
+			available[format] = false
+		default:
+			return nil, err
+		}
+	}
+	return available, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+)
+
+func TestDetectExportFormats(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	if err := server.Store.AddPad("pad", "text\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	// ExportPad is not blocked by a probe that hangs
+	server.FailNext("createPad", etherpadtest.Failure{Delay: time.Hour})
+	probeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	probeDone := make(chan error, 1)
+	go func() {
+		_, err := pad.DetectExportFormats(probeCtx)
+		probeDone <- err
+	}()
+	deadline := time.Now().Add(testTimeout)
+	for len(server.RequestsFor("createPad")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the probe was not started")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	exported := make(chan error, 1)
+	var buf bytes.Buffer
+	go func() {
+		exported <- pad.ExportPad(ctx, "pad", etherpadlite.ExportText, &buf)
+	}()
+	select {
+	case err := <-exported:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("ExportPad was blocked by DetectExportFormats")
+	}
+	if buf.String() != "text\n" {
+		t.Errorf("unexpected export %q", buf.String())
+	}
+	cancel()
+	if err := <-probeDone; err == nil {
+		t.Error("expected the canceled probe to fail")
+	}
+
+	// a failed probe is not cached
+	formats, err := pad.DetectExportFormats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []etherpadlite.ExportFormat{etherpadlite.ExportText, etherpadlite.ExportHTML}
+	if !reflect.DeepEqual(formats, expected) {
+		t.Errorf("expected formats %v, got %v", expected, formats)
+	}
+	if padIDs := server.Store.PadIDs(); !reflect.DeepEqual(padIDs, []string{"pad"}) {
+		t.Errorf("expected the probe pad to be deleted, got pads %v", padIDs)
+	}
+	server.Reset()
+	if _, err := pad.DetectExportFormats(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if requests := server.Requests(); len(requests) != 0 {
+		t.Errorf("expected the result to be cached, got %d requests", len(requests))
+	}
+	err = pad.ExportPad(ctx, "pad", etherpadlite.ExportPDF, &buf)
+	if !errors.Is(err, etherpadlite.ErrFormatUnavailable) {
+		t.Errorf("expected ErrFormatUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// exportServer returns a client for a server serving exports of the pad
+// "my pad" with the content types in types (format to content type).
+func exportServer(t *testing.T, types map[ExportFormat]string) (*EtherpadLite, <-chan *http.Request) {
+	t.Helper()
+	requests := make(chan *http.Request, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		const prefix = "/p/my%20pad/export/"
+		if !strings.HasPrefix(r.URL.EscapedPath(), prefix) {
+			http.NotFound(w, r)
+			return
+		}
+		format := ExportFormat(strings.TrimPrefix(r.URL.EscapedPath(), prefix))
+		contentType, has := types[format]
+		if !has {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		io.WriteString(w, "content of "+string(format))
+	}))
+	t.Cleanup(server.Close)
+	pad := NewEtherpadLite("secret")
+	pad.BaseURL = server.URL + "/api/"
+	return pad, requests
+}
+
+func TestExportPad(t *testing.T) {
+	types := map[ExportFormat]string{
+		ExportText:     "text/plain; charset=utf-8",
+		ExportHTML:     "text/html; charset=utf-8",
+		ExportEtherpad: "application/json; charset=utf-8",
+		ExportPDF:      "application/pdf",
+		ExportODT:      "application/vnd.oasis.opendocument.text",
+		ExportDOC:      "application/msword",
+	}
+	pad, requests := exportServer(t, types)
+	for _, format := range ExportFormats {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := pad.ExportPad(context.Background(), "my pad", format, &buf); err != nil {
+				t.Fatal(err)
+			}
+			if expected := "content of " + string(format); buf.String() != expected {
+				t.Errorf("expected %q, got %q", expected, buf.String())
+			}
+			req := <-requests
+			if req.Method != http.MethodGet {
+				t.Errorf("expected GET, got %s", req.Method)
+			}
+			// the frontend doesn't accept the API key, it must not be sent
+			if req.URL.RawQuery != "" {
+				t.Errorf("expected no query, got %s", req.URL.RawQuery)
+			}
+		})
+	}
+}
+
+func TestExportPadEtherpadOctetStream(t *testing.T) {
+	// older etherpad versions serve the etherpad format as binary
+	pad, _ := exportServer(t, map[ExportFormat]string{ExportEtherpad: "application/octet-stream"})
+	if err := pad.ExportPad(context.Background(), "my pad", ExportEtherpad, io.Discard); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExportPadUnexpected(t *testing.T) {
+	// etherpad serves error pages as HTML, for example if abiword is missing
+	pad, _ := exportServer(t, map[ExportFormat]string{
+		ExportText: "text/html",
+		ExportPDF:  "text/html; charset=utf-8",
+		ExportHTML: "",
+	})
+	for _, format := range []ExportFormat{ExportText, ExportPDF, ExportHTML, ExportODT} {
+		err := pad.ExportPad(context.Background(), "my pad", format, io.Discard)
+		var reqErr *RequestError
+		if !errors.As(err, &reqErr) || reqErr.Method != "export/"+string(format) {
+			t.Errorf("%s: expected a RequestError, got %v", format, err)
+		}
+		if !errors.Is(err, errUnexpectedContent) {
+			t.Errorf("%s: expected an unexpected content error, got %v", format, err)
+		}
+		if strings.Contains(err.Error(), "secret") {
+			t.Errorf("%s: API key found in error: %v", format, err)
+		}
+	}
+	err := pad.ExportPad(context.Background(), "my pad", ExportFormat("xml"), io.Discard)
+	if err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestExportPadSessionCookie(t *testing.T) {
+	pad, requests := exportServer(t, map[ExportFormat]string{ExportText: "text/plain"})
+	ctx := WithSessionIDs(context.Background(), "s.1", "s.2")
+	if err := pad.ExportPad(ctx, "my pad", ExportText, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	cookie, err := (<-requests).Cookie(SessionCookieName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cookie.Value != "s.1,s.2" {
+		t.Errorf("expected cookie value s.1,s.2, got %q", cookie.Value)
+	}
+}
+
+func TestExportPadStreaming(t *testing.T) {
+	pad, _ := exportServer(t, map[ExportFormat]string{ExportText: "text/plain"})
+	// the export is written to the writer, an error of the writer is returned
+	err := pad.ExportPad(context.Background(), "my pad", ExportText, failingWriter{})
+	if !errors.Is(err, errWriteFailed) {
+		t.Errorf("expected the error of the writer, got %v", err)
+	}
+}
+
+var errWriteFailed = errors.New("write failed")
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errWriteFailed
+}
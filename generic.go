@@ -0,0 +1,58 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoData is returned by Do if the response contains no data (or null).
+var ErrNoData = errors.New("response contains no data")
+
+// Do calls the API method (like EtherpadLite.Call) and decodes the data object
+// of the response into a value of type T (like Response.DecodeData).
+//
+// If the return code is not EverythingOk an EtherpadError is returned
+// (independent of RaiseEtherpadErrors), if the response has no data ErrNoData
+// is returned. In both cases the value is the zero value of T.
+// The response is returned whenever one was received, even if the error is
+// not nil.
+//
+// For example for a plugin method:
+//
+//	type Stats struct {
+//		Words int `json:"words"`
+//	}
+//	stats, _, err := etherpadlite.Do[Stats](pad, ctx, "getWordCount", map[string]interface{}{"padID": "foo"})
+func Do[T any](pad *EtherpadLite, ctx context.Context, method string, params map[string]interface{}) (T, *Response, error) {
+	var res T
+	resp, err := pad.sendRequest(ctx, method, params)
+	if err != nil {
+		return res, resp, err
+	}
+	if resp.Code != EverythingOk {
+		return res, resp, NewEtherpadError(resp.Code, resp.Message)
+	}
+	if !resp.HasData() {
+		return res, resp, ErrNoData
+	}
+	if err := resp.DecodeData(&res); err != nil {
+		var zero T
+		return zero, resp, fmt.Errorf("can't decode data of %s: %w", method, err)
+	}
+	return res, resp, nil
+}
@@ -1,3 +1,3 @@
 module github.com/FabianWe/etherpadlite-golang
 
-go 1.14
+go 1.18
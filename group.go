@@ -0,0 +1,181 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// CascadeError is returned by DeleteGroupCascade, it describes the step that
+// failed.
+type CascadeError struct {
+	// GroupID is the ID of the group.
+	GroupID string
+
+	// Step is the step that failed: "list sessions", "delete sessions",
+	// "list pads", "delete pads" or "delete group".
+	Step string
+
+	// Err is the error of the step. For "delete sessions" and "delete pads"
+	// it is a *DeleteSessionsError or *DeletePadsError.
+	Err error
+}
+
+// Error returns the error as a string.
+func (e *CascadeError) Error() string {
+	return fmt.Sprintf("deleting group %s: %s failed: %v", e.GroupID, e.Step, e.Err)
+}
+
+// Unwrap returns Err.
+func (e *CascadeError) Unwrap() error {
+	return e.Err
+}
+
+// CascadeOptions are the options for DeleteGroupCascade.
+type CascadeOptions struct {
+	// SessionsOnly only deletes the sessions of the group, the pads and the
+	// group itself are kept. This revokes the access to the group without
+	// losing the content.
+	SessionsOnly bool
+
+	// Concurrency is the maximal number of concurrent delete requests,
+	// DefaultConcurrency if <= 0.
+	Concurrency int
+}
+
+// DeleteGroupCascade deletes a group together with its sessions and pads:
+// First all sessions are deleted, then all pads and finally the group.
+// Depending on the server version deleteGroup fails or leaves orphans if the
+// group still has pads or sessions.
+// If a step fails the following steps are not executed and a *CascadeError is
+// returned.
+func (pad *EtherpadLite) DeleteGroupCascade(ctx context.Context, groupID string, opts ...CascadeOptions) error {
+	var options CascadeOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	fail := func(step string, err error) error {
+		return &CascadeError{GroupID: groupID, Step: step, Err: err}
+	}
+	sessions, err := pad.ListGroupSessions(ctx, groupID)
+	if err != nil {
+		return fail("list sessions", err)
+	}
+	if _, err := pad.deleteSessions(ctx, sessionIDs(sessions), options.Concurrency); err != nil {
+		return fail("delete sessions", err)
+	}
+	if options.SessionsOnly {
+		return nil
+	}
+	padIDs, err := pad.ListGroupPadIDs(ctx, groupID)
+	if err != nil {
+		return fail("list pads", err)
+	}
+	if _, err := pad.deletePads(ctx, padIDs, options.Concurrency); err != nil {
+		return fail("delete pads", err)
+	}
+	if _, err := pad.callChecked(ctx, "deleteGroup", map[string]interface{}{"groupID": groupID}); err != nil {
+		return fail("delete group", err)
+	}
+	return nil
+}
+
+// CloneGroupError is returned by CloneGroup if some pads could not be copied.
+type CloneGroupError struct {
+	// Failed maps the IDs of the source pads that could not be copied to the
+	// error.
+	Failed map[string]error
+
+	// Err is set if the copying was aborted, either because the context was
+	// cancelled or because a copy failed and ContinueOnError was false.
+	Err error
+}
+
+// Error returns the error as a string.
+func (e *CloneGroupError) Error() string {
+	return fmt.Sprintf("failed to copy %d pad(s): %s", len(e.Failed), joinFailures(e.Err, e.Failed))
+}
+
+// Unwrap returns Err.
+func (e *CloneGroupError) Unwrap() error {
+	return e.Err
+}
+
+// CloneOptions are the options for CloneGroup.
+type CloneOptions struct {
+	// ContinueOnError copies the remaining pads if copying a pad fails,
+	// otherwise no new copies are started after the first error.
+	ContinueOnError bool
+
+	// Concurrency is the maximal number of concurrent copy requests,
+	// DefaultConcurrency if <= 0.
+	Concurrency int
+}
+
+// CloneGroup creates a new group and copies all pads of the source group into
+// it, keeping the pad names. If the server supports it (see Supports)
+// copyPadWithoutHistory is used, so the copies don't contain the revisions of
+// the source pads.
+// The ID of the new group is returned even if copying fails (but not if the
+// group could not be created), in this case a *CloneGroupError is returned.
+func (pad *EtherpadLite) CloneGroup(ctx context.Context, sourceGroupID string, opts ...CloneOptions) (string, error) {
+	var options CloneOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	padIDs, err := pad.ListGroupPadIDs(ctx, sourceGroupID)
+	if err != nil {
+		return "", err
+	}
+	newGroupID, err := pad.CreateGroupID(ctx)
+	if err != nil {
+		return "", err
+	}
+	method := "copyPad"
+	if pad.Supports("copyPadWithoutHistory") {
+		method = "copyPadWithoutHistory"
+	}
+	var (
+		mutex  sync.Mutex
+		failed = make(map[string]error)
+	)
+	err = forEach(ctx, len(padIDs), options.Concurrency, func(ctx context.Context, i int) error {
+		sourceID := padIDs[i]
+		_, padName, err := SplitGroupPadID(sourceID)
+		if err == nil {
+			_, err = pad.callChecked(ctx, method, map[string]interface{}{
+				"sourceID":      sourceID,
+				"destinationID": newGroupID + "$" + padName,
+				"force":         false,
+			})
+		}
+		if err == nil {
+			return nil
+		}
+		mutex.Lock()
+		failed[sourceID] = err
+		mutex.Unlock()
+		if options.ContinueOnError {
+			return nil
+		}
+		return err
+	})
+	if err != nil || len(failed) > 0 {
+		return newGroupID, &CloneGroupError{Failed: failed, Err: err}
+	}
+	return newGroupID, nil
+}
@@ -0,0 +1,137 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Health is the result of HealthCheck.
+type Health struct {
+	// Reachable is true if the server answered.
+	Reachable bool
+
+	// APIKeyValid is true if the server accepted the API key.
+	APIKeyValid bool
+
+	// APIVersion is the newest API version the server supports, empty if it
+	// could not be determined.
+	APIVersion string
+
+	// Latency is the round-trip time of the checkToken request.
+	Latency time.Duration
+
+	// Checked is the time the check was done.
+	Checked time.Time
+}
+
+// Healthy returns true if the server is reachable and the API key is valid.
+func (h *Health) Healthy() bool {
+	return h.Reachable && h.APIKeyValid
+}
+
+// HealthCheck checks if the server is reachable and accepts the API key
+// (checkToken) and measures the latency. It also tries to read the API
+// version the server supports, if this fails APIVersion is empty.
+// The result is never nil. The error describes why the server is not healthy,
+// a network error means the server is not reachable, an error matching
+// ErrWrongAPIKey means the API key is invalid. If no request can be sent at
+// all (for example checkToken doesn't exist in the configured APIVersion or
+// BaseURL is invalid) the error is returned and Reachable is false.
+func (pad *EtherpadLite) HealthCheck(ctx context.Context) (*Health, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	clock := pad.clock()
+	start := clock.Now()
+	health := &Health{Checked: start}
+	if _, err := pad.BuildRequest(ctx, "checkToken", nil); err != nil {
+		return health, err
+	}
+	_, err := pad.sendRequest(WithRaiseEtherpadErrors(ctx, true), "checkToken", nil)
+	health.Latency = clock.Now().Sub(start)
+	_, isPadErr := IsEtherpadError(err)
+	switch {
+	case err == nil:
+		health.Reachable, health.APIKeyValid = true, true
+	case isPadErr:
+		health.Reachable = true
+	case ctx.Err() != nil || IsNetworkError(err) || errors.Is(err, ErrCircuitOpen):
+		return health, err
+	default:
+		// the server answered, but not as expected
+		health.Reachable = true
+	}
+	health.APIVersion = pad.serverAPIVersion(ctx)
+	return health, err
+}
+
+// serverAPIVersion returns the current API version reported by the server
+// (GET on BaseURL), the empty string if it can't be determined.
+func (pad *EtherpadLite) serverAPIVersion(ctx context.Context) string {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(pad.BaseURL, "/"), nil)
+	if err != nil {
+		return ""
+	}
+	client := pad.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	var info struct {
+		CurrentVersion string `json:"currentVersion"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&info); err != nil {
+		return ""
+	}
+	return info.CurrentVersion
+}
+
+// MonitorHealth runs HealthCheck every interval until ctx is done and calls fn
+// whenever Reachable or APIKeyValid change. fn is called for the first check
+// as well, with old set to nil. err is the error of the check.
+func (pad *EtherpadLite) MonitorHealth(ctx context.Context, interval time.Duration, fn func(old, current *Health, err error)) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ticker := pad.clock().NewTicker(interval)
+	defer ticker.Stop()
+	var old *Health
+	for {
+		current, err := pad.HealthCheck(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if old == nil || old.Reachable != current.Reachable || old.APIKeyValid != current.APIKeyValid {
+			fn(old, current, err)
+		}
+		old = current
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
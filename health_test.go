@@ -0,0 +1,70 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+func TestHealthCheckRequestNotSent(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(pad *etherpadlite.EtherpadLite)
+		check     func(err error) bool
+	}{
+		{
+			name:      "checkToken not in API version",
+			configure: func(pad *etherpadlite.EtherpadLite) { pad.APIVersion = "1.1" },
+			check:     func(err error) bool { return errors.Is(err, etherpadlite.ErrUnsupportedInVersion) },
+		},
+		{
+			name:      "invalid base URL",
+			configure: func(pad *etherpadlite.EtherpadLite) { pad.BaseURL = "http://etherpad host/api" },
+			check:     func(err error) bool { return err != nil },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, pad := newTestClient(t)
+			tt.configure(pad)
+			health, err := pad.HealthCheck(context.Background())
+			if !tt.check(err) {
+				t.Errorf("unexpected error %v", err)
+			}
+			if health.Reachable || health.APIKeyValid || health.APIVersion != "" {
+				t.Errorf("expected an unreachable server, got %+v", health)
+			}
+			if requests := server.Requests(); len(requests) != 0 {
+				t.Errorf("expected no requests, got %d", len(requests))
+			}
+		})
+	}
+}
+
+func TestHealthCheckWrongAPIKey(t *testing.T) {
+	_, pad := newTestClient(t)
+	pad.BaseParams["apikey"] = "invalid-key"
+	health, err := pad.HealthCheck(context.Background())
+	if !errors.Is(err, etherpadlite.ErrWrongAPIKey) {
+		t.Errorf("expected ErrWrongAPIKey, got %v", err)
+	}
+	if !health.Reachable || health.APIKeyValid {
+		t.Errorf("expected a reachable server with an invalid API key, got %+v", health)
+	}
+}
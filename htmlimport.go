@@ -0,0 +1,208 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrUnsupportedCharset is returned by ConvertHTMLToUTF8 if the HTML declares
+// a charset that can't be converted.
+var ErrUnsupportedCharset = errors.New("unsupported charset")
+
+// HTMLImportOptions are the options for SetHTMLFromFile.
+type HTMLImportOptions struct {
+	// Charset, if not empty, is the charset of the file. Otherwise the
+	// charset is detected, see ConvertHTMLToUTF8.
+	Charset string
+
+	// Sanitize removes elements and attributes etherpad's importer can't
+	// handle, see SanitizeHTML.
+	Sanitize bool
+}
+
+// windows1252 maps the bytes 0x80 to 0x9f of windows-1252 to runes, all other
+// bytes are equal to their ISO-8859-1 (and thus Unicode) code point.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008d', 'Ž', '\u008f',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u009d', 'ž', 'Ÿ',
+}
+
+// metaCharsetPattern matches the charset of <meta charset="..."> and
+// <meta http-equiv="Content-Type" content="text/html; charset=...">.
+var metaCharsetPattern = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9_:.-]+)`)
+
+// sniffCharset returns the charset declared by a byte order mark or a meta
+// element, the empty string if there is none.
+func sniffCharset(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}):
+		return "utf-8"
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		return "utf-16le"
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		return "utf-16be"
+	}
+	// the declaration must be within the first 1024 bytes
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if match := metaCharsetPattern.FindSubmatch(head); match != nil {
+		return strings.ToLower(string(match[1]))
+	}
+	return ""
+}
+
+// decodeUTF16 decodes UTF-16 data (without BOM).
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// decodeSingleByte decodes ISO-8859-1 or (if cp1252 is true) windows-1252
+// data.
+func decodeSingleByte(data []byte, cp1252 bool) string {
+	var b strings.Builder
+	b.Grow(len(data))
+	for _, c := range data {
+		if cp1252 && c >= 0x80 && c <= 0x9f {
+			b.WriteRune(windows1252[c-0x80])
+		} else {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
+
+// decodeCharset converts data in the given charset to UTF-8.
+func decodeCharset(data []byte, charset string) (string, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8":
+		return string(bytes.TrimPrefix(data, []byte{0xef, 0xbb, 0xbf})), nil
+	case "utf-16le":
+		return decodeUTF16(bytes.TrimPrefix(data, []byte{0xff, 0xfe}), false), nil
+	case "utf-16be":
+		return decodeUTF16(bytes.TrimPrefix(data, []byte{0xfe, 0xff}), true), nil
+	case "utf-16":
+		if bytes.HasPrefix(data, []byte{0xff, 0xfe}) {
+			return decodeUTF16(data[2:], false), nil
+		}
+		return decodeUTF16(bytes.TrimPrefix(data, []byte{0xfe, 0xff}), true), nil
+	case "windows-1252", "cp1252", "x-cp1252":
+		return decodeSingleByte(data, true), nil
+	case "iso-8859-1", "iso8859-1", "latin1", "l1", "us-ascii", "ascii":
+		// browsers treat all of these as windows-1252
+		return decodeSingleByte(data, true), nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedCharset, charset)
+}
+
+// ConvertHTMLToUTF8 converts HTML to UTF-8. The charset is taken from a byte
+// order mark or a <meta> charset declaration. Without declaration the data is
+// used as is if it is valid UTF-8 and decoded as windows-1252 otherwise.
+// Supported are UTF-8, UTF-16 and windows-1252 (including ISO-8859-1 and
+// ASCII), other declared charsets return an error matching
+// ErrUnsupportedCharset.
+// The charset declaration in the HTML is not changed.
+func ConvertHTMLToUTF8(data []byte) (string, error) {
+	charset := sniffCharset(data)
+	if charset == "" {
+		if utf8.Valid(data) {
+			return string(data), nil
+		}
+		charset = "windows-1252"
+	}
+	return decodeCharset(data, charset)
+}
+
+var (
+	// dangerousElements are the elements removed by SanitizeHTML, including
+	// their content.
+	dangerousElements = []string{"script", "style", "iframe"}
+
+	// tagPattern matches start tags.
+	tagPattern = regexp.MustCompile(`<[a-zA-Z][^>]*>`)
+
+	// eventAttributePattern matches event handler attributes like onclick.
+	eventAttributePattern = regexp.MustCompile(`(?i)\s+on[a-z]+\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+)
+
+// elementPatterns contains for each element in dangerousElements a pattern
+// matching the element with its content and a pattern matching single start
+// or end tags (for unclosed elements).
+var elementPatterns = func() [][2]*regexp.Regexp {
+	res := make([][2]*regexp.Regexp, len(dangerousElements))
+	for i, name := range dangerousElements {
+		res[i] = [2]*regexp.Regexp{
+			regexp.MustCompile(`(?is)<` + name + `\b[^>]*>.*?</` + name + `\s*>`),
+			regexp.MustCompile(`(?i)</?` + name + `\b[^>]*>`),
+		}
+	}
+	return res
+}()
+
+// SanitizeHTML removes script, style and iframe elements (including their
+// content) and event handler attributes (like onclick) from HTML.
+// It works on the text of the HTML without parsing it, it is meant to make
+// documents importable, not to make untrusted HTML safe for a browser.
+func SanitizeHTML(html string) string {
+	for _, patterns := range elementPatterns {
+		html = patterns[0].ReplaceAllString(html, "")
+		html = patterns[1].ReplaceAllString(html, "")
+	}
+	return tagPattern.ReplaceAllStringFunc(html, func(tag string) string {
+		return eventAttributePattern.ReplaceAllString(tag, "")
+	})
+}
+
+// SetHTMLFromFile reads an HTML file, converts it to UTF-8 (see
+// ConvertHTMLToUTF8), optionally sanitizes it (see SanitizeHTML) and sets it
+// as content of the pad (setHTML).
+func (pad *EtherpadLite) SetHTMLFromFile(ctx context.Context, padID, path string, opts HTMLImportOptions) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var html string
+	if opts.Charset != "" {
+		html, err = decodeCharset(data, opts.Charset)
+	} else {
+		html, err = ConvertHTMLToUTF8(data)
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	if opts.Sanitize {
+		html = SanitizeHTML(html)
+	}
+	_, err = pad.callChecked(ctx, "setHTML", map[string]interface{}{"padID": padID, "html": html})
+	return err
+}
@@ -0,0 +1,166 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+)
+
+// Errors returned by ImportPad if etherpad reports that the import failed.
+var (
+	// ErrImportConverterFailed is returned if the file could not be converted,
+	// for example because the format requires abiword or soffice which are not
+	// configured.
+	ErrImportConverterFailed = errors.New("import failed: converting the file failed")
+
+	// ErrImportUploadFailed is returned if the upload itself failed.
+	ErrImportUploadFailed = errors.New("import failed: upload failed")
+
+	// ErrImportPadHasData is returned if an .etherpad file is imported into a
+	// pad that already has content.
+	ErrImportPadHasData = errors.New("import failed: pad already has data")
+
+	// ErrImportFileTooLarge is returned if the file exceeds the maximal file
+	// size configured in etherpad.
+	ErrImportFileTooLarge = errors.New("import failed: file too large")
+
+	// ErrImportPermission is returned if the import is not allowed.
+	ErrImportPermission = errors.New("import failed: permission denied")
+)
+
+// importStatusErrors maps the status strings etherpad returns to errors.
+var importStatusErrors = map[string]error{
+	"convertFailed": ErrImportConverterFailed,
+	"uploadFailed":  ErrImportUploadFailed,
+	"padHasData":    ErrImportPadHasData,
+	"maxFileSize":   ErrImportFileTooLarge,
+	"permission":    ErrImportPermission,
+}
+
+// importFramePattern matches the status in the HTML older etherpad versions
+// return after an import, for example
+// window.parent.padimpexp.handleFrameCall('false', 'ok');
+var importFramePattern = regexp.MustCompile(`handleFrameCall\(\s*'[^']*'\s*,\s*'([^']*)'\s*\)`)
+
+// parseImportResult returns the status of an import from the response body:
+// Newer etherpad versions return JSON (the status is the message), older ones
+// return HTML calling handleFrameCall.
+func parseImportResult(body []byte) (string, error) {
+	var jsonResult struct {
+		Code    *int   `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &jsonResult); err == nil && jsonResult.Code != nil {
+		if *jsonResult.Code == 0 {
+			return "ok", nil
+		}
+		return jsonResult.Message, nil
+	}
+	match := importFramePattern.FindSubmatch(body)
+	if match == nil {
+		return "", fmt.Errorf("can't parse import result")
+	}
+	return string(match[1]), nil
+}
+
+// importStatusError converts a status as returned by parseImportResult to an
+// error, nil for "ok".
+func importStatusError(status string) error {
+	if status == "ok" {
+		return nil
+	}
+	if err, known := importStatusErrors[status]; known {
+		return err
+	}
+	return fmt.Errorf("import failed: %s", status)
+}
+
+// ImportPad imports the content read from r into a pad, replacing its content.
+// The format is determined by etherpad from the extension of filename: txt,
+// html and etherpad are always supported, other formats (doc, odt, pdf ...)
+// require abiword or soffice to be configured in etherpad.
+//
+// The import is done by the etherpad frontend (/p/<padID>/import), see
+// ExportPad for details about the URL and authentication.
+// If etherpad reports a failure one of the ErrImport... errors is returned
+// (wrapped in a RequestError).
+func (pad *EtherpadLite) ImportPad(ctx context.Context, padID string, filename string, r io.Reader) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	const method = "import"
+	u, err := pad.padPath(padID, "/import")
+	if err != nil {
+		return &RequestError{Method: method, Err: err}
+	}
+	requestErr := func(err error, body []byte) error {
+		return &RequestError{Method: method, URL: RedactURL(u.String()), Err: err, Body: bodySnippet(body)}
+	}
+	// the body is buffered so that it can be sent again if the request is
+	// retried
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		return requestErr(err, nil)
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return requestErr(err, nil)
+	}
+	if err := form.Close(); err != nil {
+		return requestErr(err, nil)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return requestErr(err, nil)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	addSessionCookie(ctx, req)
+	release, err := pad.limiter.acquire(ctx, pad.MaxConcurrentRequests)
+	if err != nil {
+		return requestErr(err, nil)
+	}
+	defer release()
+	resp, err := pad.doRequest(ctx, req)
+	if err != nil {
+		return requestErr(err, nil)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	pad.dumpDebug(method, req, resp, body, err)
+	if err != nil {
+		return requestErr(err, nil)
+	}
+	status, err := parseImportResult(body)
+	if err != nil {
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("unexpected HTTP status %s", resp.Status)
+		}
+		return requestErr(err, body)
+	}
+	if err := importStatusError(status); err != nil {
+		return requestErr(err, nil)
+	}
+	return nil
+}
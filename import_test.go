@@ -0,0 +1,73 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	const text = "Hello\nWorld with ümlauts & <html>\n\nlast line"
+	if _, err := pad.CreatePad(ctx, "source", text); err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := server.Store.Text("source")
+	for _, format := range []etherpadlite.ExportFormat{etherpadlite.ExportText, etherpadlite.ExportHTML} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := pad.ExportPad(ctx, "source", format, &buf); err != nil {
+				t.Fatal(err)
+			}
+			target := "target-" + string(format)
+			if err := pad.ImportPad(ctx, target, "backup."+string(format), &buf); err != nil {
+				t.Fatal(err)
+			}
+			got, err := pad.GetTextContent(ctx, target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != expected {
+				t.Errorf("expected text %q after round trip, got %q", expected, got)
+			}
+			// importing again replaces the content
+			if err := pad.ImportPad(ctx, target, "other.txt", strings.NewReader("replaced")); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := server.Store.Text(target); got != "replaced\n" {
+				t.Errorf("expected the import to replace the text, got %q", got)
+			}
+		})
+	}
+}
+
+func TestImportPadConverterFailed(t *testing.T) {
+	_, pad := newTestClient(t)
+	err := pad.ImportPad(context.Background(), "pad", "document.odt", strings.NewReader("binary"))
+	if !errors.Is(err, etherpadlite.ErrImportConverterFailed) {
+		t.Errorf("expected ErrImportConverterFailed, got %v", err)
+	}
+	var reqErr *etherpadlite.RequestError
+	if !errors.As(err, &reqErr) || reqErr.Method != "import" {
+		t.Errorf("expected a RequestError for import, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// PadInfo describes a pad returned by Pads. Which fields are populated
+// depends on PadsOptions.
+type PadInfo struct {
+	// ID is the ID of the pad.
+	ID string
+
+	// LastEdited is the time the pad was last edited.
+	LastEdited time.Time
+
+	// Revisions is the number of revisions of the pad.
+	Revisions int
+
+	// ReadOnlyID is the read-only ID of the pad.
+	ReadOnlyID string
+
+	// Err is set if fetching the information failed, the other fields
+	// (except ID) may be incomplete in this case.
+	Err error
+}
+
+// PadsOptions are the options for Pads.
+type PadsOptions struct {
+	// GroupID, if not empty, only returns the pads of this group. Otherwise
+	// all pads are returned.
+	GroupID string
+
+	// LastEdited fetches PadInfo.LastEdited (getLastEdited).
+	LastEdited bool
+
+	// Revisions fetches PadInfo.Revisions (getRevisionsCount).
+	Revisions bool
+
+	// ReadOnlyID fetches PadInfo.ReadOnlyID (getReadOnlyID).
+	ReadOnlyID bool
+
+	// Concurrency is the maximal number of pads fetched concurrently,
+	// DefaultConcurrency if <= 0.
+	Concurrency int
+}
+
+// fetch populates the fields of info requested by opts, it stops at the first
+// error.
+func (opts *PadsOptions) fetch(ctx context.Context, pad *EtherpadLite, info *PadInfo) error {
+	var err error
+	if opts.LastEdited {
+		if info.LastEdited, err = pad.LastEdited(ctx, info.ID); err != nil {
+			return err
+		}
+	}
+	if opts.Revisions {
+		if info.Revisions, err = pad.RevisionsCount(ctx, info.ID); err != nil {
+			return err
+		}
+	}
+	if opts.ReadOnlyID {
+		if info.ReadOnlyID, err = pad.ReadOnlyID(ctx, info.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pads walks all pads (or all pads of a group) and returns a channel that
+// receives a PadInfo for each pad, in no particular order. The information
+// requested by opts is fetched lazily using at most opts.Concurrency
+// concurrent workers.
+//
+// If fetching the information of a pad fails PadInfo.Err is set and the walk
+// continues. The channel is closed once all pads are processed or ctx is
+// done, in the latter case the workers stop promptly and the remaining pads
+// are skipped.
+// The caller must either receive until the channel is closed or cancel ctx,
+// otherwise the workers block forever and leak. So if the caller may stop
+// early (for example break out of the loop) it must pass a context it
+// cancels afterwards, a nil or never cancelled context is only safe if the
+// channel is always drained:
+//
+//	ctx, cancel := context.WithCancel(ctx)
+//	defer cancel()
+//	pads, err := pad.Pads(ctx, opts)
+//
+// An error is returned if the pads could not be listed.
+func (pad *EtherpadLite) Pads(ctx context.Context, opts PadsOptions) (<-chan PadInfo, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var (
+		ids []string
+		err error
+	)
+	if opts.GroupID != "" {
+		ids, err = pad.ListGroupPadIDs(ctx, opts.GroupID)
+	} else {
+		ids, err = pad.ListAllPadIDs(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	res := make(chan PadInfo)
+	go func() {
+		defer close(res)
+		forEach(ctx, len(ids), opts.Concurrency, func(ctx context.Context, i int) error {
+			info := PadInfo{ID: ids[i]}
+			info.Err = opts.fetch(ctx, pad, &info)
+			select {
+			case res <- info:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+	return res, nil
+}
+
+// ListPadsModifiedSince returns the pads that were edited after since, with
+// PadInfo.LastEdited set. The last edited times are fetched with at most
+// concurrency concurrent requests (DefaultConcurrency if <= 0).
+// Pads whose last edited time could not be fetched are contained as well,
+// with PadInfo.Err set. The result is sorted by pad ID.
+// If ctx is cancelled the pads found so far are returned together with
+// ctx.Err().
+func (pad *EtherpadLite) ListPadsModifiedSince(ctx context.Context, since time.Time, concurrency int) ([]PadInfo, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	pads, err := pad.Pads(ctx, PadsOptions{LastEdited: true, Concurrency: concurrency})
+	if err != nil {
+		return nil, err
+	}
+	res := []PadInfo{}
+	for info := range pads {
+		if info.Err != nil || info.LastEdited.After(since) {
+			res = append(res, info)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res, ctx.Err()
+}
@@ -0,0 +1,55 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+func TestPadsStopEarly(t *testing.T) {
+	server, pad := newTestClient(t)
+	for i := 0; i < 10; i++ {
+		if err := server.Store.AddPad(fmt.Sprintf("pad%d", i), "text"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pads, err := pad.Pads(ctx, etherpadlite.PadsOptions{Revisions: true, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := <-pads; info.Err != nil {
+		t.Fatal(info.Err)
+	}
+	// the caller stops receiving, cancelling ctx must stop the workers
+	cancel()
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case _, ok := <-pads:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("channel was not closed after cancel")
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"sync"
+)
+
+// requestLimiter is a semaphore limiting the number of requests in flight.
+// The underlying channel is created on first use, the zero value is ready to
+// use and it's safe for concurrent use.
+type requestLimiter struct {
+	mutex sync.Mutex
+	slots chan struct{}
+}
+
+// noRelease is returned by acquire if there is no limit.
+func noRelease() {}
+
+// acquire waits for a free slot (at most max requests are in flight) or until
+// ctx is done. On success it returns a function that must be called to
+// release the slot again.
+// If max <= 0 there is no limit and acquire returns immediately.
+func (l *requestLimiter) acquire(ctx context.Context, max int) (func(), error) {
+	if max <= 0 {
+		return noRelease, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	l.mutex.Lock()
+	if l.slots == nil || cap(l.slots) != max {
+		l.slots = make(chan struct{}, max)
+	}
+	slots := l.slots
+	l.mutex.Unlock()
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
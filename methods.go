@@ -0,0 +1,129 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+// MethodInfo describes an API method of etherpad, see Methods.
+type MethodInfo struct {
+	// Name is the name of the method, for example "createPad".
+	Name string
+
+	// Required are the names of the required parameters in the order of the
+	// API documentation.
+	Required []string
+
+	// Optional are the names of the optional parameters.
+	Optional []string
+
+	// MinVersion is the API version that introduced the method.
+	MinVersion string
+}
+
+// apiMethods are all API methods known to this package, in the order of the
+// API documentation.
+var apiMethods = []MethodInfo{
+	// groups
+	{Name: "createGroup"},
+	{Name: "createGroupIfNotExistsFor", Required: []string{"groupMapper"}},
+	{Name: "deleteGroup", Required: []string{"groupID"}},
+	{Name: "listPads", Required: []string{"groupID"}},
+	{Name: "createGroupPad", Required: []string{"groupID", "padName"}, Optional: []string{"text"}},
+	{Name: "listAllGroups"},
+	// author
+	{Name: "createAuthor", Optional: []string{"name"}},
+	{Name: "createAuthorIfNotExistsFor", Required: []string{"authorMapper"}, Optional: []string{"name"}},
+	{Name: "listPadsOfAuthor", Required: []string{"authorID"}},
+	{Name: "getAuthorName", Required: []string{"authorID"}},
+	// session
+	{Name: "createSession", Required: []string{"groupID", "authorID", "validUntil"}},
+	{Name: "deleteSession", Required: []string{"sessionID"}},
+	{Name: "getSessionInfo", Required: []string{"sessionID"}},
+	{Name: "listSessionsOfGroup", Required: []string{"groupID"}},
+	{Name: "listSessionsOfAuthor", Required: []string{"authorID"}},
+	// pad content
+	{Name: "getText", Required: []string{"padID"}, Optional: []string{"rev"}},
+	{Name: "setText", Required: []string{"padID", "text"}, Optional: []string{"authorId"}},
+	{Name: "appendText", Required: []string{"padID", "text"}, Optional: []string{"authorId"}},
+	{Name: "getHTML", Required: []string{"padID"}, Optional: []string{"rev"}},
+	{Name: "setHTML", Required: []string{"padID", "html"}, Optional: []string{"authorId"}},
+	{Name: "getAttributePool", Required: []string{"padID"}},
+	{Name: "getRevisionChangeset", Required: []string{"padID"}, Optional: []string{"rev"}},
+	{Name: "createDiffHTML", Required: []string{"padID", "startRev", "endRev"}},
+	{Name: "restoreRevision", Required: []string{"padID", "rev"}, Optional: []string{"authorId"}},
+	// chat
+	{Name: "getChatHistory", Required: []string{"padID"}, Optional: []string{"start", "end"}},
+	{Name: "getChatHead", Required: []string{"padID"}},
+	{Name: "appendChatMessage", Required: []string{"padID", "text", "authorID"}, Optional: []string{"time"}},
+	// pad
+	{Name: "createPad", Required: []string{"padID"}, Optional: []string{"text", "authorId"}},
+	{Name: "getRevisionsCount", Required: []string{"padID"}},
+	{Name: "getSavedRevisionsCount", Required: []string{"padID"}},
+	{Name: "listSavedRevisions", Required: []string{"padID"}},
+	{Name: "saveRevision", Required: []string{"padID"}, Optional: []string{"rev"}},
+	{Name: "padUsersCount", Required: []string{"padID"}},
+	{Name: "padUsers", Required: []string{"padID"}},
+	{Name: "deletePad", Required: []string{"padID"}},
+	{Name: "copyPad", Required: []string{"sourceID", "destinationID"}, Optional: []string{"force"}},
+	{Name: "copyPadWithoutHistory", Required: []string{"sourceID", "destinationID"}, Optional: []string{"force"}},
+	{Name: "movePad", Required: []string{"sourceID", "destinationID"}, Optional: []string{"force"}},
+	{Name: "getReadOnlyID", Required: []string{"padID"}},
+	{Name: "getPadID", Required: []string{"readOnlyID"}},
+	{Name: "setPublicStatus", Required: []string{"padID", "publicStatus"}},
+	{Name: "getPublicStatus", Required: []string{"padID"}},
+	{Name: "setPassword", Required: []string{"padID", "password"}},
+	{Name: "isPasswordProtected", Required: []string{"padID"}},
+	{Name: "listAuthorsOfPad", Required: []string{"padID"}},
+	{Name: "getLastEdited", Required: []string{"padID"}},
+	{Name: "sendClientsMessage", Required: []string{"padID", "msg"}},
+	{Name: "checkToken"},
+	// pads
+	{Name: "listAllPads"},
+	// global
+	{Name: "getStats"},
+}
+
+// methodInfo returns the info of method with MinVersion set.
+func methodInfo(m MethodInfo) MethodInfo {
+	res := MethodInfo{
+		Name:       m.Name,
+		Required:   append([]string(nil), m.Required...),
+		Optional:   append([]string(nil), m.Optional...),
+		MinVersion: "1",
+	}
+	if version, has := minAPIVersions[m.Name]; has {
+		res.MinVersion = version
+	}
+	return res
+}
+
+// Methods returns all API methods known to this package in the order of the
+// API documentation. Tools like command line interfaces can use it to offer
+// all methods without listing them again.
+func Methods() []MethodInfo {
+	res := make([]MethodInfo, len(apiMethods))
+	for i, m := range apiMethods {
+		res[i] = methodInfo(m)
+	}
+	return res
+}
+
+// LookupMethod returns the info of the API method with the given name.
+func LookupMethod(name string) (MethodInfo, bool) {
+	for _, m := range apiMethods {
+		if m.Name == name {
+			return methodInfo(m), true
+		}
+	}
+	return MethodInfo{}, false
+}
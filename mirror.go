@@ -0,0 +1,238 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultMirrorInterval is the poll interval of a Mirror if no interval is
+// given.
+const DefaultMirrorInterval = time.Minute
+
+// MirrorPadState is the state of a pad the last time it was mirrored.
+type MirrorPadState struct {
+	Revisions  int       `json:"revisions"`
+	LastEdited time.Time `json:"lastEdited"`
+
+	// ChatMessages is the number of chat messages of the source pad that
+	// were copied, only used if SyncOptions.Chat is set.
+	ChatMessages int `json:"chatMessages"`
+}
+
+// MirrorState persists the state of a Mirror, so that a restarted mirror
+// doesn't copy all pads again.
+type MirrorState interface {
+	// Load returns the saved state, mapping pad IDs to their state.
+	Load() (map[string]MirrorPadState, error)
+
+	// Save saves the state after each poll.
+	Save(state map[string]MirrorPadState) error
+}
+
+// FileMirrorState is a MirrorState that stores the state as JSON in a file.
+type FileMirrorState string
+
+// Load reads the file, a missing file is an empty state.
+func (f FileMirrorState) Load() (map[string]MirrorPadState, error) {
+	data, err := os.ReadFile(string(f))
+	if os.IsNotExist(err) {
+		return map[string]MirrorPadState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state map[string]MirrorPadState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Save writes the state to a temporary file and renames it, so the file is
+// never left half written.
+func (f FileMirrorState) Save(state map[string]MirrorPadState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := string(f) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, string(f))
+}
+
+// Mirror replicates all pads of a source server to a destination server: It
+// periodically lists all pads on the source and copies the pads that changed
+// since the last poll (detected by getRevisionsCount and getLastEdited) with
+// SyncPad. Pads deleted on the source are not deleted on the destination.
+type Mirror struct {
+	// Source is the server the pads are copied from.
+	Source API
+
+	// Destination is the server the pads are copied to.
+	Destination API
+
+	// Interval is the time between two polls, DefaultMirrorInterval if <= 0.
+	Interval time.Duration
+
+	// State, if not nil, is used to persist the state between restarts.
+	State MirrorState
+
+	// SyncOptions are the options passed to SyncPad, Overwrite is always
+	// set. If Chat is set only the chat messages added since the last poll
+	// are appended on the destination (see MirrorPadState.ChatMessages).
+	SyncOptions SyncOptions
+
+	// OnError, if not nil, is called for each pad that could not be
+	// mirrored. If a poll fails as a whole (for example because the pads
+	// can't be listed) it is called with an empty pad ID.
+	OnError func(padID string, err error)
+
+	// Concurrency is the maximal number of concurrent requests to each
+	// server, DefaultConcurrency if <= 0.
+	Concurrency int
+
+	// Clock is used to wait between polls, RealClock if nil.
+	Clock Clock
+
+	mutex sync.Mutex
+	state map[string]MirrorPadState
+}
+
+// reportError calls OnError if it is set.
+func (m *Mirror) reportError(padID string, err error) {
+	if m.OnError != nil {
+		m.OnError(padID, err)
+	}
+}
+
+// Poll mirrors all pads that changed since the last poll once. The state is
+// loaded before the first poll and saved after each poll.
+// Errors of single pads are reported to OnError, those pads are retried in
+// the next poll. An error is returned if the pads could not be listed or the
+// state could not be loaded or saved.
+func (m *Mirror) Poll(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.state == nil {
+		m.state = map[string]MirrorPadState{}
+		if m.State != nil {
+			state, err := m.State.Load()
+			if err != nil {
+				return err
+			}
+			if state != nil {
+				m.state = state
+			}
+		}
+	}
+	pads, err := m.Source.Pads(ctx, PadsOptions{LastEdited: true, Revisions: true, Concurrency: m.Concurrency})
+	if err != nil {
+		return err
+	}
+	current := make(map[string]MirrorPadState)
+	var changed []string
+	for info := range pads {
+		if info.Err != nil {
+			m.reportError(info.ID, info.Err)
+			continue
+		}
+		padState := MirrorPadState{Revisions: info.Revisions, LastEdited: info.LastEdited}
+		if old, has := m.state[info.ID]; has && old.Revisions == padState.Revisions && old.LastEdited.Equal(padState.LastEdited) {
+			current[info.ID] = old
+			continue
+		}
+		padState.ChatMessages = m.state[info.ID].ChatMessages
+		current[info.ID] = padState
+		changed = append(changed, info.ID)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	opts := m.SyncOptions
+	opts.Overwrite = true
+	// the chat is synced below, SyncPad would append the whole history again
+	opts.Chat = false
+	var currentMutex sync.Mutex
+	ctxErr := forEach(ctx, len(changed), m.Concurrency, func(ctx context.Context, i int) error {
+		padID := changed[i]
+		old, has := m.state[padID]
+		chatMessages := old.ChatMessages
+		err := SyncPad(ctx, m.Source, m.Destination, padID, opts)
+		if err == nil && m.SyncOptions.Chat {
+			chatMessages, err = syncChat(ctx, m.Source, m.Destination, padID, &m.SyncOptions, chatMessages)
+		}
+		if err != nil {
+			m.reportError(padID, err)
+		}
+		currentMutex.Lock()
+		defer currentMutex.Unlock()
+		switch {
+		case err == nil:
+			padState := current[padID]
+			padState.ChatMessages = chatMessages
+			current[padID] = padState
+		case has || chatMessages > 0:
+			// retry in the next poll, but don't append the copied chat
+			// messages again
+			old.ChatMessages = chatMessages
+			current[padID] = old
+		default:
+			delete(current, padID)
+		}
+		return nil
+	})
+	if ctxErr != nil {
+		return ctxErr
+	}
+	m.state = current
+	if m.State != nil {
+		return m.State.Save(current)
+	}
+	return nil
+}
+
+// Run polls until ctx is done, it returns nil once ctx is done. Errors of a
+// poll are reported to OnError with an empty pad ID.
+func (m *Mirror) Run(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	interval := m.Interval
+	if interval <= 0 {
+		interval = DefaultMirrorInterval
+	}
+	ticker := clockOrReal(m.Clock).NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := m.Poll(ctx); err != nil && ctx.Err() == nil {
+			m.reportError("", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C():
+		}
+	}
+}
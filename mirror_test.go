@@ -0,0 +1,83 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// chatTexts returns the texts of the chat messages of a pad.
+func chatTexts(t *testing.T, pad *etherpadlite.EtherpadLite, padID string) []string {
+	t.Helper()
+	messages, err := pad.FullChatHistory(context.Background(), padID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := make([]string, len(messages))
+	for i, msg := range messages {
+		texts[i] = msg.Text
+	}
+	return texts
+}
+
+func TestMirrorChat(t *testing.T) {
+	srcServer, src := newTestClient(t)
+	dstServer, dst := newTestClient(t)
+	authorID := srcServer.Store.AddAuthor("mapper", "Alice")
+	if err := srcServer.Store.AddPad("pad", "text"); err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"one", "two"} {
+		if err := srcServer.Store.AddChatMessage("pad", authorID, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mirror := &etherpadlite.Mirror{
+		Source:      src,
+		Destination: dst,
+		SyncOptions: etherpadlite.SyncOptions{Chat: true},
+		OnError: func(padID string, err error) {
+			t.Errorf("mirroring %q failed: %v", padID, err)
+		},
+	}
+	ctx := context.Background()
+	if err := mirror.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if texts := chatTexts(t, dst, "pad"); len(texts) != 2 {
+		t.Fatalf("expected 2 chat messages after the first poll, got %v", texts)
+	}
+
+	// change the pad, the next poll syncs it again
+	if _, err := src.SetText(ctx, "pad", "changed"); err != nil {
+		t.Fatal(err)
+	}
+	if err := srcServer.Store.AddChatMessage("pad", authorID, "three"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mirror.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	texts := chatTexts(t, dst, "pad")
+	if len(texts) != 3 || texts[0] != "one" || texts[1] != "two" || texts[2] != "three" {
+		t.Errorf("expected chat messages [one two three], got %v", texts)
+	}
+	if text, _ := dstServer.Store.Text("pad"); text != "changed\n" {
+		t.Errorf("expected text %q, got %q", "changed\n", text)
+	}
+}
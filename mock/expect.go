@@ -0,0 +1,249 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// Call is an API call received by a store.
+type Call struct {
+	Method string
+
+	// Params are the parameters of the call without the API key.
+	Params url.Values
+}
+
+func (c Call) String() string {
+	return fmt.Sprintf("%s(%s)", c.Method, c.Params.Encode())
+}
+
+// CannedResponse is a response returned instead of calling the API method,
+// see QueueResponse and Expectation.Return.
+type CannedResponse struct {
+	Code    etherpadlite.ReturnCode
+	Message string
+
+	// Data is encoded as JSON.
+	Data interface{}
+}
+
+// Matcher matches the value of a parameter.
+type Matcher interface {
+	Match(value string) bool
+}
+
+// MatcherFunc is a function implementing Matcher.
+type MatcherFunc func(value string) bool
+
+// Match implements Matcher.
+func (f MatcherFunc) Match(value string) bool {
+	return f(value)
+}
+
+// paramMatcher matches a single parameter.
+type paramMatcher struct {
+	key         string
+	matcher     Matcher
+	description string
+}
+
+// Expectation describes expected calls of an API method, see
+// Store.ExpectCall.
+type Expectation struct {
+	method   string
+	params   []paramMatcher
+	min, max int
+	response *CannedResponse
+	calls    int
+}
+
+// ExpectCall adds an expectation for calls to method. By default the method
+// is expected to be called at least once with arbitrary parameters, use
+// WithParam and Times to narrow the expectation:
+//
+//	store.ExpectCall("setText").WithParam("padID", "foo").Times(1)
+//
+// Each call is counted for the first expectation it matches (in the order
+// they were added) that is not saturated yet. Verify reports expectations
+// that were not met.
+func (s *Store) ExpectCall(method string) *Expectation {
+	e := &Expectation{method: method, min: 1, max: -1}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.expectations = append(s.expectations, e)
+	return e
+}
+
+// WithParam adds a condition on the parameter key, the call must have the
+// parameter and its value must match. value is either a string (the value
+// must be equal), a *regexp.Regexp, a func(string) bool or a Matcher. Other
+// values are formatted with fmt.Sprint and compared as strings.
+func (e *Expectation) WithParam(key string, value interface{}) *Expectation {
+	m := paramMatcher{key: key}
+	switch v := value.(type) {
+	case string:
+		m.matcher = MatcherFunc(func(s string) bool { return s == v })
+		m.description = fmt.Sprintf("%s=%q", key, v)
+	case *regexp.Regexp:
+		m.matcher = MatcherFunc(v.MatchString)
+		m.description = fmt.Sprintf("%s=~/%s/", key, v)
+	case func(string) bool:
+		m.matcher = MatcherFunc(v)
+		m.description = key + " matching func"
+	case Matcher:
+		m.matcher = v
+		m.description = fmt.Sprintf("%s matching %v", key, v)
+	default:
+		return e.WithParam(key, fmt.Sprint(value))
+	}
+	e.params = append(e.params, m)
+	return e
+}
+
+// Times sets the number of expected calls.
+func (e *Expectation) Times(n int) *Expectation {
+	e.min, e.max = n, n
+	return e
+}
+
+// AnyTimes allows any number of calls, including none.
+func (e *Expectation) AnyTimes() *Expectation {
+	e.min, e.max = 0, -1
+	return e
+}
+
+// Return answers matching calls with resp instead of calling the method, the
+// state of the store is not changed.
+func (e *Expectation) Return(resp CannedResponse) *Expectation {
+	e.response = &resp
+	return e
+}
+
+// matches returns true if the call matches e.
+func (e *Expectation) matches(method string, p params) bool {
+	if e.method != method {
+		return false
+	}
+	for _, m := range e.params {
+		if !p.has(m.key) || !m.matcher.Match(p.get(m.key)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Expectation) String() string {
+	conditions := make([]string, len(e.params))
+	for i, m := range e.params {
+		conditions[i] = m.description
+	}
+	var times string
+	switch {
+	case e.max < 0:
+		times = fmt.Sprintf("at least %d times", e.min)
+	default:
+		times = fmt.Sprintf("%d times", e.min)
+	}
+	return fmt.Sprintf("%s(%s) %s", e.method, strings.Join(conditions, ", "), times)
+}
+
+// QueueResponse queues a response for the next call of method, the method is
+// not called. Multiple responses are returned in the order they were queued.
+func (s *Store) QueueResponse(method string, resp CannedResponse) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.queued == nil {
+		s.queued = make(map[string][]CannedResponse)
+	}
+	s.queued[method] = append(s.queued[method], resp)
+}
+
+// Calls returns all API calls received so far, in order.
+func (s *Store) Calls() []Call {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// recordCall records a call, counts it for the first matching expectation
+// and returns the canned response for it (nil if the method should be
+// called). s.mutex must be locked.
+func (s *Store) recordCall(method string, p params) *CannedResponse {
+	values := make(url.Values, len(p))
+	for key, value := range p {
+		if !strings.EqualFold(key, "apikey") {
+			values[key] = append([]string(nil), value...)
+		}
+	}
+	call := Call{Method: method, Params: values}
+	s.calls = append(s.calls, call)
+	var expected *Expectation
+	for _, e := range s.expectations {
+		if e.matches(method, p) && (e.max < 0 || e.calls < e.max) {
+			expected = e
+			break
+		}
+	}
+	if expected == nil {
+		for _, e := range s.expectations {
+			if e.method == method {
+				// the method is expected, but not with these parameters
+				s.unexpected = append(s.unexpected, call)
+				break
+			}
+		}
+	} else {
+		expected.calls++
+	}
+	if queue := s.queued[method]; len(queue) > 0 {
+		s.queued[method] = queue[1:]
+		return &queue[0]
+	}
+	if expected != nil {
+		return expected.response
+	}
+	return nil
+}
+
+// Verify fails the test if an expectation was not met or if there were
+// unexpected calls. A call is unexpected if there are expectations for its
+// method but none of them matches the call (or all matching ones are
+// saturated). Calls to methods without expectations are not reported.
+func (s *Store) Verify(t testing.TB) {
+	t.Helper()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var problems []string
+	for _, e := range s.expectations {
+		if e.calls < e.min || (e.max >= 0 && e.calls > e.max) {
+			problems = append(problems, fmt.Sprintf("expected %s, got %d calls", e, e.calls))
+		}
+	}
+	for _, call := range s.unexpected {
+		problems = append(problems, "unexpected call "+call.String())
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		t.Errorf("mock expectations not met:\n\t%s", strings.Join(problems, "\n\t"))
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock_test
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/mock"
+)
+
+// recordingTB is a testing.TB that records errors instead of failing the
+// test. Only the methods used by Store.Verify are implemented.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// verify runs Verify and returns the reported problems, one per line.
+func verify(store *mock.Store) []string {
+	tb := &recordingTB{}
+	store.Verify(tb)
+	if len(tb.errors) == 0 {
+		return nil
+	}
+	lines := strings.Split(strings.Join(tb.errors, "\n"), "\n")
+	problems := make([]string, 0, len(lines))
+	for _, line := range lines[1:] {
+		problems = append(problems, strings.TrimSpace(line))
+	}
+	return problems
+}
+
+// prefixMatcher is a Matcher for values with a prefix.
+type prefixMatcher string
+
+func (p prefixMatcher) Match(value string) bool {
+	return strings.HasPrefix(value, string(p))
+}
+
+func call(t *testing.T, m *mock.Mock, method string, params map[string]interface{}) *etherpadlite.Response {
+	t.Helper()
+	resp, err := m.Call(context.Background(), method, params)
+	if err != nil {
+		t.Fatalf("%s: %v", method, err)
+	}
+	return resp
+}
+
+func TestExpectationMatching(t *testing.T) {
+	m := mock.New()
+	ok := mock.CannedResponse{Data: map[string]interface{}{"result": "ok"}}
+	m.Store.ExpectCall("byString").WithParam("padID", "pad").Times(1).Return(ok)
+	m.Store.ExpectCall("byRegexp").WithParam("padID", regexp.MustCompile(`^g\.[a-z]+\$pad$`)).Times(1).Return(ok)
+	m.Store.ExpectCall("byFunc").WithParam("text", func(s string) bool { return len(s) > 3 }).Times(1).Return(ok)
+	m.Store.ExpectCall("byMatcher").WithParam("padID", prefixMatcher("foo")).Times(1).Return(ok)
+	m.Store.ExpectCall("byInt").WithParam("rev", 42).Times(1).Return(ok)
+	m.Store.ExpectCall("byTwo").WithParam("padID", "pad").WithParam("rev", "1").Times(1).Return(ok)
+
+	call(t, m, "byString", map[string]interface{}{"padID": "pad"})
+	call(t, m, "byRegexp", map[string]interface{}{"padID": "g.abc$pad"})
+	call(t, m, "byFunc", map[string]interface{}{"text": "long text"})
+	call(t, m, "byMatcher", map[string]interface{}{"padID": "foobar"})
+	call(t, m, "byInt", map[string]interface{}{"rev": 42})
+	call(t, m, "byTwo", map[string]interface{}{"padID": "pad", "rev": 1, "extra": "ignored"})
+	if problems := verify(m.Store); len(problems) != 0 {
+		t.Errorf("expected all expectations to be met, got %q", problems)
+	}
+
+	// calls not matching a condition
+	call(t, m, "byString", map[string]interface{}{"padID": "other"})
+	call(t, m, "byRegexp", map[string]interface{}{"padID": "g.ABC$pad"})
+	call(t, m, "byFunc", map[string]interface{}{"text": "abc"})
+	call(t, m, "byMatcher", map[string]interface{}{"padID": "barfoo"})
+	call(t, m, "byInt", map[string]interface{}{"rev": 43})
+	call(t, m, "byTwo", map[string]interface{}{"padID": "pad"})
+	expected := []string{
+		"unexpected call byFunc(text=abc)",
+		"unexpected call byInt(rev=43)",
+		"unexpected call byMatcher(padID=barfoo)",
+		"unexpected call byRegexp(padID=g.ABC%24pad)",
+		"unexpected call byString(padID=other)",
+		"unexpected call byTwo(padID=pad)",
+	}
+	if problems := verify(m.Store); strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected problems\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(problems, "\n"))
+	}
+}
+
+func TestExpectationTimes(t *testing.T) {
+	m := mock.New()
+	ctx := context.Background()
+	m.Store.ExpectCall("createPad").Times(1)
+	m.Store.ExpectCall("getText").AnyTimes()
+	if _, err := m.CreatePad(ctx, "a", etherpadlite.OptionalParam); err != nil {
+		t.Fatal(err)
+	}
+	if problems := verify(m.Store); len(problems) != 0 {
+		t.Errorf("expected Times(1) and AnyTimes without calls to be met, got %q", problems)
+	}
+	// the saturated expectation doesn't count the second call
+	if _, err := m.CreatePad(ctx, "b", etherpadlite.OptionalParam); err != nil {
+		t.Fatal(err)
+	}
+	problems := verify(m.Store)
+	if len(problems) != 1 || problems[0] != "unexpected call createPad(padID=b)" {
+		t.Errorf("expected the second createPad to be unexpected, got %q", problems)
+	}
+	if _, ok := m.Store.Text("b"); !ok {
+		t.Error("expected an unexpected call to be executed anyway")
+	}
+
+	// a later expectation takes the calls once the first one is saturated
+	m = mock.New()
+	m.Store.ExpectCall("createPad").Times(1)
+	m.Store.ExpectCall("createPad").Times(2)
+	for _, padID := range []string{"a", "b", "c"} {
+		if _, err := m.CreatePad(ctx, padID, etherpadlite.OptionalParam); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if problems := verify(m.Store); len(problems) != 0 {
+		t.Errorf("expected both expectations to be met, got %q", problems)
+	}
+}
+
+func TestQueueResponse(t *testing.T) {
+	m := mock.New()
+	ctx := context.Background()
+	if err := m.Store.AddPad("pad", "stored\n"); err != nil {
+		t.Fatal(err)
+	}
+	m.Store.QueueResponse("getText", mock.CannedResponse{Data: map[string]interface{}{"text": "first"}})
+	m.Store.QueueResponse("getText", mock.CannedResponse{Code: etherpadlite.WrongParameters, Message: "second"})
+	m.Store.ExpectCall("getText").Return(mock.CannedResponse{Data: map[string]interface{}{"text": "expected"}})
+
+	resp, err := m.GetText(ctx, "pad", etherpadlite.OptionalParam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := resp.GetString("text"); text != "first" {
+		t.Errorf("expected the first queued response, got %q", text)
+	}
+	resp, err = m.GetText(ctx, "pad", etherpadlite.OptionalParam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Code != etherpadlite.WrongParameters || resp.Message != "second" {
+		t.Errorf("expected the second queued response, got %+v", resp)
+	}
+	// queued responses take precedence over Return, afterwards it's used
+	resp, err = m.GetText(ctx, "pad", etherpadlite.OptionalParam)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := resp.GetString("text"); text != "expected" {
+		t.Errorf("expected the response of the expectation, got %q", text)
+	}
+	if calls := m.Store.Calls(); len(calls) != 3 {
+		t.Errorf("expected 3 recorded calls, got %v", calls)
+	}
+	if problems := verify(m.Store); len(problems) != 0 {
+		t.Errorf("expected the expectation to count all calls, got %q", problems)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	m := mock.New()
+	ctx := context.Background()
+	m.Store.ExpectCall("createPad").WithParam("padID", "a").Times(2)
+	m.Store.ExpectCall("deletePad")
+	m.Store.ExpectCall("getText").WithParam("padID", "a").AnyTimes()
+	if _, err := m.CreatePad(ctx, "a", etherpadlite.OptionalParam); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.CreatePad(ctx, "b", etherpadlite.OptionalParam); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetText(ctx, "b", etherpadlite.OptionalParam); err != nil {
+		t.Fatal(err)
+	}
+	// methods without expectations are never unexpected
+	if _, err := m.GetRevisionsCount(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	tb := &recordingTB{}
+	m.Store.Verify(tb)
+	if len(tb.errors) != 1 {
+		t.Fatalf("expected Verify to report one error, got %q", tb.errors)
+	}
+	expected := []string{
+		`expected createPad(padID="a") 2 times, got 1 calls`,
+		`expected deletePad() at least 1 times, got 0 calls`,
+		"unexpected call createPad(padID=b)",
+		"unexpected call getText(padID=b)",
+	}
+	if problems := verify(m.Store); strings.Join(problems, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected problems\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(problems, "\n"))
+	}
+	if !strings.HasPrefix(tb.errors[0], "mock expectations not met:") {
+		t.Errorf("unexpected error message %q", tb.errors[0])
+	}
+}
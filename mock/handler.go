@@ -0,0 +1,347 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// params are the parameters of an API call.
+type params url.Values
+
+func (p params) get(key string) string {
+	return url.Values(p).Get(key)
+}
+
+func (p params) has(key string) bool {
+	_, has := p[key]
+	return has
+}
+
+// str returns the parameter key, an error if it is missing.
+func (p params) str(key string) (string, error) {
+	if !p.has(key) {
+		return "", wrongParameters("%s is not a string", key)
+	}
+	return p.get(key), nil
+}
+
+// boolean parses a boolean parameter, etherpad accepts "true" and "false".
+func (p params) boolean(key string) (bool, error) {
+	switch p.get(key) {
+	case "true":
+		return true, nil
+	case "false", "":
+		return false, nil
+	default:
+		return false, wrongParameters("%s must be true or false", key)
+	}
+}
+
+// integer parses an integer parameter.
+func (p params) integer(key string) (int, error) {
+	n, err := strconv.Atoi(p.get(key))
+	if err != nil {
+		return 0, wrongParameters("%s is not a number", key)
+	}
+	if n < 0 {
+		return 0, wrongParameters("%s is not a negative number", key)
+	}
+	return n, nil
+}
+
+// handlerFunc implements an API method, the result is encoded as the data of
+// the response.
+type handlerFunc func(s *Store, p params) (interface{}, error)
+
+// methods maps the API methods to their implementations.
+var methods = map[string]handlerFunc{
+	// groups
+	"createGroup":               (*Store).apiCreateGroup,
+	"createGroupIfNotExistsFor": (*Store).apiCreateGroupIfNotExistsFor,
+	"deleteGroup":               (*Store).apiDeleteGroup,
+	"listPads":                  (*Store).apiListPads,
+	"createGroupPad":            (*Store).apiCreateGroupPad,
+	"listAllGroups":             (*Store).apiListAllGroups,
+
+	// authors
+	"createAuthor":               (*Store).apiCreateAuthor,
+	"createAuthorIfNotExistsFor": (*Store).apiCreateAuthorIfNotExistsFor,
+	"listPadsOfAuthor":           (*Store).apiListPadsOfAuthor,
+	"getAuthorName":              (*Store).apiGetAuthorName,
+
+	// sessions
+	"createSession":        (*Store).apiCreateSession,
+	"deleteSession":        (*Store).apiDeleteSession,
+	"getSessionInfo":       (*Store).apiGetSessionInfo,
+	"listSessionsOfGroup":  (*Store).apiListSessionsOfGroup,
+	"listSessionsOfAuthor": (*Store).apiListSessionsOfAuthor,
+
+	// pad content
+	"getText":              (*Store).apiGetText,
+	"setText":              (*Store).apiSetText,
+	"appendText":           (*Store).apiAppendText,
+	"getHTML":              (*Store).apiGetHTML,
+	"setHTML":              (*Store).apiSetHTML,
+	"getAttributePool":     (*Store).apiGetAttributePool,
+	"getRevisionChangeset": (*Store).apiGetRevisionChangeset,
+	"createDiffHTML":       (*Store).apiCreateDiffHTML,
+	"restoreRevision":      (*Store).apiRestoreRevision,
+
+	// chat
+	"getChatHistory":    (*Store).apiGetChatHistory,
+	"getChatHead":       (*Store).apiGetChatHead,
+	"appendChatMessage": (*Store).apiAppendChatMessage,
+
+	// pads
+	"createPad":              (*Store).apiCreatePad,
+	"getRevisionsCount":      (*Store).apiGetRevisionsCount,
+	"getSavedRevisionsCount": (*Store).apiGetSavedRevisionsCount,
+	"listSavedRevisions":     (*Store).apiListSavedRevisions,
+	"saveRevision":           (*Store).apiSaveRevision,
+	"padUsersCount":          (*Store).apiPadUsersCount,
+	"padUsers":               (*Store).apiPadUsers,
+	"deletePad":              (*Store).apiDeletePad,
+	"copyPad":                (*Store).apiCopyPad,
+	"copyPadWithoutHistory":  (*Store).apiCopyPadWithoutHistory,
+	"movePad":                (*Store).apiMovePad,
+	"getReadOnlyID":          (*Store).apiGetReadOnlyID,
+	"getPadID":               (*Store).apiGetPadID,
+	"setPublicStatus":        (*Store).apiSetPublicStatus,
+	"getPublicStatus":        (*Store).apiGetPublicStatus,
+	"setPassword":            (*Store).apiSetPassword,
+	"isPasswordProtected":    (*Store).apiIsPasswordProtected,
+	"listAuthorsOfPad":       (*Store).apiListAuthorsOfPad,
+	"getLastEdited":          (*Store).apiGetLastEdited,
+	"sendClientsMessage":     (*Store).apiSendClientsMessage,
+	"checkToken":             (*Store).apiCheckToken,
+	"listAllPads":            (*Store).apiListAllPads,
+	"getStats":               (*Store).apiGetStats,
+}
+
+// response is the JSON response of the API.
+type response struct {
+	Code    etherpadlite.ReturnCode `json:"code"`
+	Message string                  `json:"message"`
+	Data    interface{}             `json:"data"`
+}
+
+// writeJSON writes v as JSON with status 200, etherpad always uses status 200
+// for API errors.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(v)
+}
+
+// ServeHTTP implements http.Handler. The paths are the ones of an etherpad
+// server: /api returns the current API version, /api/<version>/<method>
+// calls an API method, /p/<padID>/export/<format> exports a pad (txt and
+// html only) and /p/<padID>/import imports a text or HTML file.
+// Paths are matched by their suffix, so the handler can be mounted under a
+// prefix.
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if i := strings.LastIndex(path, "/p/"); i >= 0 {
+		s.serveFrontend(w, r, path[i+len("/p/"):])
+		return
+	}
+	path = strings.TrimSuffix(path, "/")
+	if strings.HasSuffix(path, "/api") {
+		writeJSON(w, map[string]string{"currentVersion": etherpadlite.CurrentVersion})
+		return
+	}
+	i := strings.LastIndex(path, "/api/")
+	if i < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	parts := strings.Split(path[i+len("/api/"):], "/")
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, s.call(parts[1], params(r.Form)))
+}
+
+// call calls the API method and returns the response.
+func (s *Store) call(method string, p params) *response {
+	if s.APIKey != "" && p.get("apikey") != s.APIKey {
+		return &response{Code: etherpadlite.WrongAPIKey, Message: "no or wrong API Key"}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if canned := s.recordCall(method, p); canned != nil {
+		message := canned.Message
+		if message == "" {
+			message = "ok"
+			if canned.Code != etherpadlite.EverythingOk {
+				message = canned.Code.String()
+			}
+		}
+		return &response{Code: canned.Code, Message: message, Data: canned.Data}
+	}
+	fn, known := methods[method]
+	if !known {
+		return &response{Code: etherpadlite.NoSuchFunction, Message: "no such function"}
+	}
+	data, err := fn(s, p)
+	if err != nil {
+		var apiErr *apiError
+		if errors.As(err, &apiErr) {
+			return &response{Code: apiErr.code, Message: apiErr.message}
+		}
+		return &response{Code: etherpadlite.InternalError, Message: err.Error()}
+	}
+	return &response{Code: etherpadlite.EverythingOk, Message: "ok", Data: data}
+}
+
+// serveFrontend handles export and import of a pad, rest is the path after
+// "/p/".
+func (s *Store) serveFrontend(w http.ResponseWriter, r *http.Request, rest string) {
+	slash := strings.LastIndex(rest, "/")
+	if slash < 0 {
+		http.NotFound(w, r)
+		return
+	}
+	action := rest[slash+1:]
+	rest = rest[:slash]
+	if action == "import" {
+		s.serveImport(w, r, rest)
+		return
+	}
+	if !strings.HasSuffix(rest, "/export") {
+		http.NotFound(w, r)
+		return
+	}
+	padID := strings.TrimSuffix(rest, "/export")
+	s.mutex.Lock()
+	p, err := s.getPad(padID)
+	var text string
+	if err == nil {
+		text = p.head().text
+	}
+	s.mutex.Unlock()
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch action {
+	case "txt":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, text)
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, textToHTML(text))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveImport imports a txt or html file uploaded as multipart form, the
+// result is reported as JSON like newer etherpad versions do.
+func (s *Store) serveImport(w http.ResponseWriter, r *http.Request, padID string) {
+	status := func(code int, message string) {
+		writeJSON(w, map[string]interface{}{"code": code, "message": message})
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		status(1, "uploadFailed")
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		status(1, "uploadFailed")
+		return
+	}
+	text := string(content)
+	switch {
+	case strings.HasSuffix(header.Filename, ".txt"):
+	case strings.HasSuffix(header.Filename, ".html"), strings.HasSuffix(header.Filename, ".htm"):
+		text = htmlToText(text)
+	default:
+		status(1, "convertFailed")
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p, has := s.pads[padID]
+	if !has {
+		var createErr error
+		if p, createErr = s.createPad(padID, nil, ""); createErr != nil {
+			status(1, "permission")
+			return
+		}
+	}
+	s.setText(p, text, "")
+	status(0, "ok")
+}
+
+// textToHTML converts text to the HTML returned by getHTML.
+func textToHTML(text string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = html.EscapeString(line)
+	}
+	return "<!DOCTYPE HTML><html><body>" + strings.Join(lines, "<br>") + "<br></body></html>"
+}
+
+var (
+	lineBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|h[1-6])>`)
+	tagPattern       = regexp.MustCompile(`<[^>]*>`)
+)
+
+// htmlToText converts HTML to text, line breaks and block elements are
+// converted to newlines and all other tags are removed.
+func htmlToText(source string) string {
+	if i := strings.Index(strings.ToLower(source), "<body"); i >= 0 {
+		source = source[i:]
+	}
+	source = lineBreakPattern.ReplaceAllString(source, "\n")
+	source = tagPattern.ReplaceAllString(source, "")
+	return html.UnescapeString(source)
+}
+
+// utf16Len returns the length of s in UTF-16 code units.
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// changeset returns a changeset that replaces old by text.
+func changeset(old, text string) string {
+	cs := etherpadlite.Changeset{OldLen: utf16Len(old), NewLen: utf16Len(text), CharBank: text}
+	if old != "" {
+		cs.Ops = append(cs.Ops, etherpadlite.ChangesetOp{Type: etherpadlite.OpRemove, Chars: cs.OldLen, Lines: strings.Count(old, "\n")})
+	}
+	if text != "" {
+		cs.Ops = append(cs.Ops, etherpadlite.ChangesetOp{Type: etherpadlite.OpInsert, Chars: cs.NewLen, Lines: strings.Count(text, "\n")})
+	}
+	return cs.String()
+}
@@ -0,0 +1,731 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+// This file contains the implementations of the API methods, they are called
+// with the mutex of the store locked.
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// millis returns t in milliseconds since the Unix epoch.
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// padFor returns the pad given by the parameter padID.
+func (s *Store) padFor(p params) (*pad, error) {
+	padID, err := p.str("padID")
+	if err != nil {
+		return nil, err
+	}
+	return s.getPad(padID)
+}
+
+// groupFor returns the group given by the parameter groupID.
+func (s *Store) groupFor(p params) (string, error) {
+	groupID := p.get("groupID")
+	if !s.groups[groupID] {
+		return "", errGroupNotFound
+	}
+	return groupID, nil
+}
+
+// authorFor returns the author given by the parameter authorID.
+func (s *Store) authorFor(p params) (string, error) {
+	authorID := p.get("authorID")
+	if _, has := s.authors[authorID]; !has {
+		return "", errAuthorNotFound
+	}
+	return authorID, nil
+}
+
+// revFor returns the revision given by the parameter rev, the head revision
+// if it is not given.
+func (s *Store) revFor(pad *pad, p params) (int, error) {
+	head := len(pad.revisions) - 1
+	if p.get("rev") == "" {
+		return head, nil
+	}
+	rev, err := p.integer("rev")
+	if err != nil {
+		return 0, err
+	}
+	if rev > head {
+		return 0, wrongParameters("rev is higher than the head revision of the pad")
+	}
+	return rev, nil
+}
+
+// Groups
+
+func (s *Store) apiCreateGroup(p params) (interface{}, error) {
+	return map[string]interface{}{"groupID": s.group("")}, nil
+}
+
+func (s *Store) apiCreateGroupIfNotExistsFor(p params) (interface{}, error) {
+	mapper, err := p.str("groupMapper")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"groupID": s.group(mapper)}, nil
+}
+
+func (s *Store) apiDeleteGroup(p params) (interface{}, error) {
+	groupID, err := s.groupFor(p)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range s.padIDs(groupID + "$") {
+		s.deletePad(s.pads[id])
+	}
+	for id, session := range s.sessions {
+		if session.GroupID == groupID {
+			delete(s.sessions, id)
+		}
+	}
+	for mapper, id := range s.groupMappers {
+		if id == groupID {
+			delete(s.groupMappers, mapper)
+		}
+	}
+	delete(s.groups, groupID)
+	return nil, nil
+}
+
+func (s *Store) apiListPads(p params) (interface{}, error) {
+	groupID, err := s.groupFor(p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"padIDs": s.padIDs(groupID + "$")}, nil
+}
+
+func (s *Store) apiCreateGroupPad(p params) (interface{}, error) {
+	groupID, err := s.groupFor(p)
+	if err != nil {
+		return nil, err
+	}
+	padID := groupID + "$" + p.get("padName")
+	if _, has := s.pads[padID]; has {
+		return nil, wrongParameters("padName does already exist")
+	}
+	if _, err := s.createPad(padID, optionalText(p), p.get("authorId")); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"padID": padID}, nil
+}
+
+func (s *Store) apiListAllGroups(p params) (interface{}, error) {
+	ids := []string{}
+	for id := range s.groups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return map[string]interface{}{"groupIDs": ids}, nil
+}
+
+// Authors
+
+// optionalName returns the parameter name, nil if it's not given.
+func optionalName(p params) *string {
+	if !p.has("name") {
+		return nil
+	}
+	name := p.get("name")
+	return &name
+}
+
+func (s *Store) apiCreateAuthor(p params) (interface{}, error) {
+	return map[string]interface{}{"authorID": s.author("", optionalName(p))}, nil
+}
+
+func (s *Store) apiCreateAuthorIfNotExistsFor(p params) (interface{}, error) {
+	mapper, err := p.str("authorMapper")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"authorID": s.author(mapper, optionalName(p))}, nil
+}
+
+func (s *Store) apiListPadsOfAuthor(p params) (interface{}, error) {
+	authorID, err := s.authorFor(p)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, id := range s.padIDs("") {
+		for _, contributor := range s.pads[id].authors() {
+			if contributor == authorID {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return map[string]interface{}{"padIDs": ids}, nil
+}
+
+func (s *Store) apiGetAuthorName(p params) (interface{}, error) {
+	authorID, err := s.authorFor(p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"authorName": s.authors[authorID].name}, nil
+}
+
+// Sessions
+
+func (s *Store) apiCreateSession(p params) (interface{}, error) {
+	validUntil, err := p.integer("validUntil")
+	if err != nil {
+		return nil, err
+	}
+	if int64(validUntil) < s.now().Unix() {
+		return nil, wrongParameters("validUntil is in the past")
+	}
+	id, err := s.createSession(p.get("groupID"), p.get("authorID"), int64(validUntil))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"sessionID": id}, nil
+}
+
+func (s *Store) apiDeleteSession(p params) (interface{}, error) {
+	id := p.get("sessionID")
+	if _, has := s.sessions[id]; !has {
+		return nil, errSessionNotFound
+	}
+	delete(s.sessions, id)
+	return nil, nil
+}
+
+// sessionInfo returns the JSON representation of a session.
+func sessionInfo(session *etherpadlite.Session) map[string]interface{} {
+	return map[string]interface{}{
+		"groupID":    session.GroupID,
+		"authorID":   session.AuthorID,
+		"validUntil": session.ValidUntil.Unix(),
+	}
+}
+
+func (s *Store) apiGetSessionInfo(p params) (interface{}, error) {
+	session, has := s.sessions[p.get("sessionID")]
+	if !has {
+		return nil, errSessionNotFound
+	}
+	return sessionInfo(session), nil
+}
+
+// sessionsWhere returns the sessions for which match returns true, as
+// returned by listSessionsOfGroup and listSessionsOfAuthor.
+func (s *Store) sessionsWhere(match func(session *etherpadlite.Session) bool) map[string]interface{} {
+	res := make(map[string]interface{})
+	for id, session := range s.sessions {
+		if match(session) {
+			res[id] = sessionInfo(session)
+		}
+	}
+	return res
+}
+
+func (s *Store) apiListSessionsOfGroup(p params) (interface{}, error) {
+	groupID, err := s.groupFor(p)
+	if err != nil {
+		return nil, err
+	}
+	return s.sessionsWhere(func(session *etherpadlite.Session) bool {
+		return session.GroupID == groupID
+	}), nil
+}
+
+func (s *Store) apiListSessionsOfAuthor(p params) (interface{}, error) {
+	authorID, err := s.authorFor(p)
+	if err != nil {
+		return nil, err
+	}
+	return s.sessionsWhere(func(session *etherpadlite.Session) bool {
+		return session.AuthorID == authorID
+	}), nil
+}
+
+// Pad content
+
+func (s *Store) apiGetText(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	rev, err := s.revFor(pad, p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"text": pad.revisions[rev].text}, nil
+}
+
+func (s *Store) apiSetText(p params) (interface{}, error) {
+	text, err := p.str("text")
+	if err != nil {
+		return nil, err
+	}
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	s.setText(pad, text, p.get("authorId"))
+	return nil, nil
+}
+
+func (s *Store) apiAppendText(p params) (interface{}, error) {
+	text, err := p.str("text")
+	if err != nil {
+		return nil, err
+	}
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	s.setText(pad, pad.head().text+text, p.get("authorId"))
+	return nil, nil
+}
+
+func (s *Store) apiGetHTML(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	rev, err := s.revFor(pad, p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"html": textToHTML(pad.revisions[rev].text)}, nil
+}
+
+func (s *Store) apiSetHTML(p params) (interface{}, error) {
+	source, err := p.str("html")
+	if err != nil {
+		return nil, err
+	}
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	s.setText(pad, htmlToText(source), p.get("authorId"))
+	return nil, nil
+}
+
+func (s *Store) apiGetAttributePool(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	numToAttrib := make(map[string][2]string)
+	attribToNum := make(map[string]int)
+	for i, authorID := range pad.authors() {
+		numToAttrib[strconv.Itoa(i)] = [2]string{"author", authorID}
+		attribToNum["author,"+authorID] = i
+	}
+	pool := map[string]interface{}{
+		"numToAttrib": numToAttrib,
+		"attribToNum": attribToNum,
+		"nextNum":     len(numToAttrib),
+	}
+	return map[string]interface{}{"pool": pool}, nil
+}
+
+func (s *Store) apiGetRevisionChangeset(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	rev, err := s.revFor(pad, p)
+	if err != nil {
+		return nil, err
+	}
+	// revision 0 is created from the initial "\n" of a new pad
+	old := "\n"
+	if rev > 0 {
+		old = pad.revisions[rev-1].text
+	}
+	return changeset(old, pad.revisions[rev].text), nil
+}
+
+func (s *Store) apiCreateDiffHTML(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	start, err := p.integer("startRev")
+	if err != nil {
+		return nil, err
+	}
+	end, err := p.integer("endRev")
+	if err != nil {
+		return nil, err
+	}
+	head := len(pad.revisions) - 1
+	if end > head {
+		end = head
+	}
+	if start > end {
+		return nil, wrongParameters("startRev is higher than endRev")
+	}
+	authors := []string{}
+	seen := make(map[string]bool)
+	for _, rev := range pad.revisions[start : end+1] {
+		if rev.authorID != "" && !seen[rev.authorID] {
+			seen[rev.authorID] = true
+			authors = append(authors, rev.authorID)
+		}
+	}
+	return map[string]interface{}{"html": textToHTML(pad.revisions[end].text), "authors": authors}, nil
+}
+
+func (s *Store) apiRestoreRevision(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	if p.get("rev") == "" {
+		return nil, wrongParameters("rev is not a number")
+	}
+	rev, err := s.revFor(pad, p)
+	if err != nil {
+		return nil, err
+	}
+	s.setText(pad, pad.revisions[rev].text, p.get("authorId"))
+	return nil, nil
+}
+
+// Chat
+
+func (s *Store) apiGetChatHistory(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	hasStart, hasEnd := p.get("start") != "", p.get("end") != ""
+	if !hasStart && !hasEnd {
+		return map[string]interface{}{"messages": pad.chat}, nil
+	}
+	if hasStart != hasEnd {
+		return nil, wrongParameters("start and end must both be set or both be unset")
+	}
+	start, err := p.integer("start")
+	if err != nil {
+		return nil, err
+	}
+	end, err := p.integer("end")
+	if err != nil {
+		return nil, err
+	}
+	head := len(pad.chat) - 1
+	switch {
+	case start > end:
+		return nil, wrongParameters("start is higher than end")
+	case start > head:
+		return nil, wrongParameters("start is higher or equal to the current chatHead")
+	case end > head:
+		return nil, wrongParameters("end is higher than the current chatHead")
+	}
+	return map[string]interface{}{"messages": pad.chat[start : end+1]}, nil
+}
+
+func (s *Store) apiGetChatHead(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"chatHead": len(pad.chat) - 1}, nil
+}
+
+func (s *Store) apiAppendChatMessage(p params) (interface{}, error) {
+	text, err := p.str("text")
+	if err != nil {
+		return nil, err
+	}
+	authorID, err := p.str("authorID")
+	if err != nil {
+		return nil, err
+	}
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	t := s.now()
+	if p.get("time") != "" {
+		// the time is given in milliseconds
+		ms, err := strconv.ParseInt(p.get("time"), 10, 64)
+		if err != nil {
+			return nil, wrongParameters("time is not a number")
+		}
+		t = time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))
+	}
+	msg := etherpadlite.ChatMessage{Text: text, AuthorID: authorID, Time: t}
+	if a, has := s.authors[authorID]; has {
+		msg.UserName = a.name
+	}
+	pad.chat = append(pad.chat, msg)
+	return nil, nil
+}
+
+// Pads
+
+// optionalText returns the parameter text, nil if it's not given.
+func optionalText(p params) *string {
+	if !p.has("text") {
+		return nil
+	}
+	text := p.get("text")
+	return &text
+}
+
+func (s *Store) apiCreatePad(p params) (interface{}, error) {
+	padID, err := p.str("padID")
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(padID, "$") {
+		return nil, wrongParameters("createPad can't create group pads")
+	}
+	if _, err := s.createPad(padID, optionalText(p), p.get("authorId")); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *Store) apiGetRevisionsCount(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"revisions": len(pad.revisions) - 1}, nil
+}
+
+func (s *Store) apiGetSavedRevisionsCount(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"savedRevisions": len(pad.savedRevisions)}, nil
+}
+
+func (s *Store) apiListSavedRevisions(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"savedRevisions": append([]int{}, pad.savedRevisions...)}, nil
+}
+
+func (s *Store) apiSaveRevision(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	rev, err := s.revFor(pad, p)
+	if err != nil {
+		return nil, err
+	}
+	pad.savedRevisions = append(pad.savedRevisions, rev)
+	return nil, nil
+}
+
+func (s *Store) apiPadUsersCount(p params) (interface{}, error) {
+	if _, err := s.padFor(p); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"padUsersCount": 0}, nil
+}
+
+func (s *Store) apiPadUsers(p params) (interface{}, error) {
+	if _, err := s.padFor(p); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"padUsers": []interface{}{}}, nil
+}
+
+func (s *Store) apiDeletePad(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	s.deletePad(pad)
+	return nil, nil
+}
+
+// copyPad copies the pad sourceID to destinationID, if withHistory is false
+// only the head revision is copied.
+func (s *Store) copyPad(p params, withHistory bool) (*pad, error) {
+	src, err := s.getPad(p.get("sourceID"))
+	if err != nil {
+		return nil, err
+	}
+	force, err := p.boolean("force")
+	if err != nil {
+		return nil, err
+	}
+	destinationID := p.get("destinationID")
+	if prefix := groupPrefix(destinationID); prefix != "" && !s.groups[strings.TrimSuffix(prefix, "$")] {
+		return nil, errGroupNotFound
+	}
+	if dst, has := s.pads[destinationID]; has {
+		if !force {
+			return nil, wrongParameters("destinationID already exists")
+		}
+		s.deletePad(dst)
+	}
+	text := src.head().text
+	dst, err := s.createPad(destinationID, &text, "")
+	if err != nil {
+		return nil, err
+	}
+	if withHistory {
+		dst.revisions = append([]revision(nil), src.revisions...)
+		dst.savedRevisions = append([]int(nil), src.savedRevisions...)
+		dst.chat = append([]etherpadlite.ChatMessage(nil), src.chat...)
+	}
+	return src, nil
+}
+
+func (s *Store) apiCopyPad(p params) (interface{}, error) {
+	_, err := s.copyPad(p, true)
+	return nil, err
+}
+
+func (s *Store) apiCopyPadWithoutHistory(p params) (interface{}, error) {
+	_, err := s.copyPad(p, false)
+	return nil, err
+}
+
+func (s *Store) apiMovePad(p params) (interface{}, error) {
+	if p.get("sourceID") == p.get("destinationID") {
+		return nil, wrongParameters("sourceID and destinationID are the same")
+	}
+	src, err := s.copyPad(p, true)
+	if err != nil {
+		return nil, err
+	}
+	s.deletePad(src)
+	return nil, nil
+}
+
+func (s *Store) apiGetReadOnlyID(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"readOnlyID": pad.readOnlyID}, nil
+}
+
+func (s *Store) apiGetPadID(p params) (interface{}, error) {
+	padID, has := s.readOnly[p.get("readOnlyID")]
+	if !has {
+		return nil, errPadNotFound
+	}
+	return map[string]interface{}{"padID": padID}, nil
+}
+
+// groupPadFor is like padFor but the pad must be a group pad.
+func (s *Store) groupPadFor(p params, what string) (*pad, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	if groupPrefix(pad.id) == "" {
+		return nil, wrongParameters("You can only get/set the %s of pads that belong to a group", what)
+	}
+	return pad, nil
+}
+
+func (s *Store) apiSetPublicStatus(p params) (interface{}, error) {
+	pad, err := s.groupPadFor(p, "publicStatus")
+	if err != nil {
+		return nil, err
+	}
+	if pad.public, err = p.boolean("publicStatus"); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *Store) apiGetPublicStatus(p params) (interface{}, error) {
+	pad, err := s.groupPadFor(p, "publicStatus")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"publicStatus": pad.public}, nil
+}
+
+func (s *Store) apiSetPassword(p params) (interface{}, error) {
+	pad, err := s.groupPadFor(p, "password")
+	if err != nil {
+		return nil, err
+	}
+	pad.password = p.get("password")
+	return nil, nil
+}
+
+func (s *Store) apiIsPasswordProtected(p params) (interface{}, error) {
+	pad, err := s.groupPadFor(p, "password")
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"isPasswordProtected": pad.password != ""}, nil
+}
+
+func (s *Store) apiListAuthorsOfPad(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"authorIDs": pad.authors()}, nil
+}
+
+func (s *Store) apiGetLastEdited(p params) (interface{}, error) {
+	pad, err := s.padFor(p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"lastEdited": millis(pad.head().time)}, nil
+}
+
+func (s *Store) apiSendClientsMessage(p params) (interface{}, error) {
+	if _, err := s.padFor(p); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *Store) apiCheckToken(p params) (interface{}, error) {
+	return nil, nil
+}
+
+func (s *Store) apiListAllPads(p params) (interface{}, error) {
+	return map[string]interface{}{"padIDs": s.padIDs("")}, nil
+}
+
+func (s *Store) apiGetStats(p params) (interface{}, error) {
+	return map[string]interface{}{
+		"totalPads":       len(s.pads),
+		"totalSessions":   len(s.sessions),
+		"totalActivePads": 0,
+	}, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// BaseURL is the BaseURL of the client returned by New, requests are never
+// sent over the network.
+const BaseURL = "http://etherpad.mock/api"
+
+// Mock is an etherpadlite client connected to an in-memory Store. All methods
+// of the embedded EtherpadLite are available, the configuration (for example
+// RaiseEtherpadErrors) can be changed as usual.
+type Mock struct {
+	*etherpadlite.EtherpadLite
+
+	// Store is the state of the mocked etherpad.
+	Store *Store
+}
+
+var _ etherpadlite.API = (*Mock)(nil)
+
+// New returns a mock connected to a new empty store.
+func New() *Mock {
+	return NewWithStore(NewStore())
+}
+
+// NewWithStore returns a mock connected to the given store. The API key of
+// the client is the API key of the store.
+func NewWithStore(store *Store) *Mock {
+	pad := etherpadlite.NewEtherpadLite(store.APIKey)
+	pad.BaseURL = BaseURL
+	pad.Client = &http.Client{Transport: Transport(store)}
+	return &Mock{EtherpadLite: pad, Store: store}
+}
+
+// handlerTransport is a http.RoundTripper that lets a handler serve the
+// requests directly.
+type handlerTransport struct {
+	handler http.Handler
+}
+
+// Transport returns a http.RoundTripper that serves all requests with handler
+// (for example a Store) without sending them over the network.
+func Transport(handler http.Handler) http.RoundTripper {
+	return handlerTransport{handler: handler}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	recorder := httptest.NewRecorder()
+	t.handler.ServeHTTP(recorder, req)
+	resp := recorder.Result()
+	resp.Request = req
+	return resp, nil
+}
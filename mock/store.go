@@ -0,0 +1,453 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mock provides an in-memory etherpad for unit tests.
+//
+// Store keeps the state (pads, groups, authors, sessions and chat messages)
+// and speaks etherpad's HTTP API (it implements http.Handler), Mock is an
+// etherpadlite client that sends its requests directly to a Store without
+// network. Mock implements etherpadlite.API, so it can be used wherever the
+// code under test accepts the interface:
+//
+//	m := mock.New()
+//	m.Store.AddPad("foo", "Hello")
+//	codeUnderTest(m)
+//	text, _ := m.Store.Text("foo")
+//
+// The store returns the same return codes and error messages as etherpad for
+// the common error cases (pad does not exist, pad does already exist, wrong
+// API key etc.), IDs are generated deterministically.
+//
+// Tests can also assert on the calls and script responses:
+//
+//	m.Store.ExpectCall("setText").WithParam("padID", "foo").Times(1)
+//	m.Store.QueueResponse("getText", mock.CannedResponse{Code: etherpadlite.InternalError})
+//	codeUnderTest(m)
+//	m.Store.Verify(t)
+package mock
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+)
+
+// DefaultPadText is the text of new pads if no text is given.
+const DefaultPadText = "Welcome to Etherpad!\n"
+
+// revision is a revision of a pad.
+type revision struct {
+	text     string
+	authorID string
+	time     time.Time
+}
+
+// pad is a pad in the store.
+type pad struct {
+	id             string
+	revisions      []revision
+	savedRevisions []int
+	chat           []etherpadlite.ChatMessage
+	public         bool
+	password       string
+	readOnlyID     string
+}
+
+func (p *pad) head() *revision {
+	return &p.revisions[len(p.revisions)-1]
+}
+
+// authors returns the IDs of all authors that contributed to the pad.
+func (p *pad) authors() []string {
+	seen := make(map[string]bool)
+	res := []string{}
+	for _, rev := range p.revisions {
+		if rev.authorID != "" && !seen[rev.authorID] {
+			seen[rev.authorID] = true
+			res = append(res, rev.authorID)
+		}
+	}
+	return res
+}
+
+// author is an author in the store.
+type author struct {
+	id     string
+	name   string
+	mapper string
+}
+
+// Store is an in-memory etherpad, see the package documentation.
+// All methods are safe for concurrent use.
+type Store struct {
+	// APIKey is the API key the HTTP handler accepts. If it is empty all keys
+	// are accepted.
+	APIKey string
+
+	// DefaultPadText is the text of pads created without text, DefaultPadText
+	// if empty.
+	DefaultPadText string
+
+	// RandomIDs generates random IDs instead of the deterministic IDs
+	// "g.0000000000000001", "a.0000000000000002" etc.
+	RandomIDs bool
+
+	// Clock is used for the time of revisions, chat messages and the expiry
+	// check of new sessions, etherpadlite.RealClock if nil.
+	Clock etherpadlite.Clock
+
+	mutex         sync.Mutex
+	counter       int
+	pads          map[string]*pad
+	readOnly      map[string]string
+	groups        map[string]bool
+	groupMappers  map[string]string
+	authors       map[string]*author
+	authorMappers map[string]string
+	sessions      map[string]*etherpadlite.Session
+
+	// see expect.go
+	calls        []Call
+	expectations []*Expectation
+	unexpected   []Call
+	queued       map[string][]CannedResponse
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{
+		pads:          make(map[string]*pad),
+		readOnly:      make(map[string]string),
+		groups:        make(map[string]bool),
+		groupMappers:  make(map[string]string),
+		authors:       make(map[string]*author),
+		authorMappers: make(map[string]string),
+		sessions:      make(map[string]*etherpadlite.Session),
+	}
+}
+
+// now returns the current time of the store.
+func (s *Store) now() time.Time {
+	if s.Clock != nil {
+		return s.Clock.Now()
+	}
+	return etherpadlite.RealClock.Now()
+}
+
+// newID returns a new ID with the given prefix (for example "g.").
+func (s *Store) newID(prefix string) string {
+	if s.RandomIDs {
+		buf := make([]byte, 8)
+		if _, err := rand.Read(buf); err == nil {
+			return prefix + hex.EncodeToString(buf)
+		}
+	}
+	s.counter++
+	return fmt.Sprintf("%s%016d", prefix, s.counter)
+}
+
+// apiError is an error returned by the API.
+type apiError struct {
+	code    etherpadlite.ReturnCode
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// wrongParameters returns an apiError with the code WrongParameters.
+func wrongParameters(format string, args ...interface{}) error {
+	return &apiError{code: etherpadlite.WrongParameters, message: fmt.Sprintf(format, args...)}
+}
+
+var (
+	errPadNotFound     = wrongParameters("padID does not exist")
+	errPadExists       = wrongParameters("padID does already exist")
+	errGroupNotFound   = wrongParameters("groupID does not exist")
+	errAuthorNotFound  = wrongParameters("authorID does not exist")
+	errSessionNotFound = wrongParameters("sessionID does not exist")
+)
+
+// normalizeText appends a newline to text if it doesn't end with one, as
+// etherpad does.
+func normalizeText(text string) string {
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return text
+}
+
+// getPad returns the pad with the given ID.
+func (s *Store) getPad(padID string) (*pad, error) {
+	p, has := s.pads[padID]
+	if !has {
+		return nil, errPadNotFound
+	}
+	return p, nil
+}
+
+// padIDPattern matches valid pad IDs, it is the pattern etherpad uses.
+var padIDPattern = regexp.MustCompile(`^(g\.[a-zA-Z0-9]{16}\$)?[^$]{1,50}$`)
+
+// createPad creates a new pad, text may be nil for the default text.
+func (s *Store) createPad(padID string, text *string, authorID string) (*pad, error) {
+	if !padIDPattern.MatchString(padID) {
+		return nil, wrongParameters("malformed padID: Remove special characters")
+	}
+	if _, has := s.pads[padID]; has {
+		return nil, errPadExists
+	}
+	initial := s.DefaultPadText
+	if initial == "" {
+		initial = DefaultPadText
+	}
+	if text != nil {
+		initial = *text
+	}
+	p := &pad{
+		id:         padID,
+		revisions:  []revision{{text: normalizeText(initial), authorID: authorID, time: s.now()}},
+		readOnlyID: s.newID("r."),
+	}
+	s.pads[padID] = p
+	s.readOnly[p.readOnlyID] = padID
+	return p, nil
+}
+
+// groupPrefix returns the "groupID$" prefix of a group pad ID, the empty
+// string for other pads.
+func groupPrefix(padID string) string {
+	if strings.HasPrefix(padID, "g.") {
+		if i := strings.IndexByte(padID, '$'); i >= 0 {
+			return padID[:i+1]
+		}
+	}
+	return ""
+}
+
+// setText adds a new revision with the given text.
+func (s *Store) setText(p *pad, text, authorID string) {
+	p.revisions = append(p.revisions, revision{text: normalizeText(text), authorID: authorID, time: s.now()})
+}
+
+// deletePad removes a pad.
+func (s *Store) deletePad(p *pad) {
+	delete(s.readOnly, p.readOnlyID)
+	delete(s.pads, p.id)
+}
+
+// AddPad creates a pad with the given text, an error is returned if it
+// already exists.
+func (s *Store) AddPad(padID, text string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, err := s.createPad(padID, &text, "")
+	return err
+}
+
+// AddGroup creates a group for the mapper (a new group if mapper is empty)
+// and returns its ID.
+func (s *Store) AddGroup(mapper string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.group(mapper)
+}
+
+// group returns the group of the mapper, creating it if necessary.
+func (s *Store) group(mapper string) string {
+	if id, has := s.groupMappers[mapper]; has && mapper != "" {
+		return id
+	}
+	id := s.newID("g.")
+	s.groups[id] = true
+	if mapper != "" {
+		s.groupMappers[mapper] = id
+	}
+	return id
+}
+
+// AddAuthor creates an author for the mapper (a new author if mapper is
+// empty) with the given name and returns its ID.
+func (s *Store) AddAuthor(mapper, name string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.author(mapper, &name)
+}
+
+// author returns the author of the mapper, creating it if necessary. If name
+// is not nil the name is set.
+func (s *Store) author(mapper string, name *string) string {
+	id, has := s.authorMappers[mapper]
+	if !has || mapper == "" {
+		id = s.newID("a.")
+		s.authors[id] = &author{id: id, mapper: mapper}
+		if mapper != "" {
+			s.authorMappers[mapper] = id
+		}
+	}
+	if name != nil {
+		s.authors[id].name = *name
+	}
+	return id
+}
+
+// AddSession creates a session and returns its ID, the group and author must
+// exist.
+func (s *Store) AddSession(groupID, authorID string, validUntil time.Time) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.createSession(groupID, authorID, validUntil.Unix())
+}
+
+// createSession creates a session.
+func (s *Store) createSession(groupID, authorID string, validUntil int64) (string, error) {
+	if !s.groups[groupID] {
+		return "", errGroupNotFound
+	}
+	if _, has := s.authors[authorID]; !has {
+		return "", errAuthorNotFound
+	}
+	id := s.newID("s.")
+	s.sessions[id] = &etherpadlite.Session{
+		ID:         id,
+		GroupID:    groupID,
+		AuthorID:   authorID,
+		ValidUntil: time.Unix(validUntil, 0),
+	}
+	return id, nil
+}
+
+// AddChatMessage appends a chat message to a pad.
+func (s *Store) AddChatMessage(padID, authorID, text string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p, err := s.getPad(padID)
+	if err != nil {
+		return err
+	}
+	msg := etherpadlite.ChatMessage{Text: text, AuthorID: authorID, Time: s.now()}
+	if a, has := s.authors[authorID]; has {
+		msg.UserName = a.name
+	}
+	p.chat = append(p.chat, msg)
+	return nil
+}
+
+// Text returns the current text of a pad, false if the pad doesn't exist.
+func (s *Store) Text(padID string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p, has := s.pads[padID]
+	if !has {
+		return "", false
+	}
+	return p.head().text, true
+}
+
+// Revisions returns the head revision of a pad (0 after creation), false if
+// the pad doesn't exist.
+func (s *Store) Revisions(padID string) (int, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p, has := s.pads[padID]
+	if !has {
+		return 0, false
+	}
+	return len(p.revisions) - 1, true
+}
+
+// PadIDs returns the IDs of all pads, sorted.
+func (s *Store) PadIDs() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.padIDs("")
+}
+
+// padIDs returns the sorted IDs of all pads with the given prefix.
+func (s *Store) padIDs(prefix string) []string {
+	res := []string{}
+	for id := range s.pads {
+		if strings.HasPrefix(id, prefix) {
+			res = append(res, id)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// GroupIDs returns the IDs of all groups, sorted.
+func (s *Store) GroupIDs() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	res := []string{}
+	for id := range s.groups {
+		res = append(res, id)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// AuthorName returns the name of an author, false if the author doesn't
+// exist.
+func (s *Store) AuthorName(authorID string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	a, has := s.authors[authorID]
+	if !has {
+		return "", false
+	}
+	return a.name, true
+}
+
+// Sessions returns all sessions, sorted by ID.
+func (s *Store) Sessions() []etherpadlite.Session {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	res := make([]etherpadlite.Session, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		res = append(res, *session)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// ChatMessages returns the chat messages of a pad, false if the pad doesn't
+// exist.
+func (s *Store) ChatMessages(padID string) ([]etherpadlite.ChatMessage, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	p, has := s.pads[padID]
+	if !has {
+		return nil, false
+	}
+	return append([]etherpadlite.ChatMessage(nil), p.chat...), true
+}
+
+// IsNotFound returns true if err is returned by a Store method because a pad,
+// group, author or session doesn't exist.
+func IsNotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && strings.HasSuffix(apiErr.message, "does not exist")
+}
@@ -0,0 +1,92 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// AsInt64 converts a number from Response.Data to an int64.
+// It accepts json.Number (see EtherpadLite.UseJSONNumber), float64 (the
+// default representation of numbers), all Go integer types and strings
+// containing an integer.
+// An error is returned if v is not an integer or doesn't fit into an int64.
+func AsInt64(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		if i, err := n.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return 0, fmt.Errorf("not a number: %q", n.String())
+		}
+		return float64ToInt64(f)
+	case float64:
+		return float64ToInt64(n)
+	case float32:
+		return float64ToInt64(float64(n))
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case int64:
+		return n, nil
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint:
+		if uint64(n) > math.MaxInt64 {
+			return 0, fmt.Errorf("integer %d overflows int64", n)
+		}
+		return int64(n), nil
+	case uint64:
+		if n > math.MaxInt64 {
+			return 0, fmt.Errorf("integer %d overflows int64", n)
+		}
+		return int64(n), nil
+	case string:
+		i, err := strconv.ParseInt(n, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("not an integer: %q", n)
+		}
+		return i, nil
+	default:
+		return 0, fmt.Errorf("can't convert value of type %T to int64", v)
+	}
+}
+
+// float64ToInt64 converts f to an int64 if it is an integer in the range of
+// int64.
+func float64ToInt64(f float64) (int64, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) || f != math.Trunc(f) {
+		return 0, fmt.Errorf("not an integer: %v", f)
+	}
+	// float64(math.MaxInt64) is 2^63 which is out of range
+	if f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, fmt.Errorf("integer %v overflows int64", f)
+	}
+	return int64(f), nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 2^53 + 1 is the smallest positive integer that can't be represented as a
+// float64.
+const (
+	above53     int64 = 1<<53 + 1
+	above53JSON       = "9007199254740993"
+)
+
+func TestAsInt64(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected int64
+		wantErr  bool
+	}{
+		{"json number", json.Number("42"), 42, false},
+		{"json number above 2^53", json.Number(above53JSON), above53, false},
+		{"json number max int64", json.Number("9223372036854775807"), math.MaxInt64, false},
+		{"json number min int64", json.Number("-9223372036854775808"), math.MinInt64, false},
+		{"json number exponent", json.Number("1e3"), 1000, false},
+		{"json number overflow", json.Number("9223372036854775808"), 0, true},
+		{"json number fraction", json.Number("1.5"), 0, true},
+		{"float64", float64(1 << 60), 1 << 60, false},
+		{"float64 fraction", 0.5, 0, true},
+		{"float64 overflow", math.Pow(2, 63), 0, true},
+		{"float64 NaN", math.NaN(), 0, true},
+		{"float32", float32(16), 16, false},
+		{"int", 7, 7, false},
+		{"int64", above53, above53, false},
+		{"uint64", uint64(above53), above53, false},
+		{"uint64 overflow", uint64(math.MaxUint64), 0, true},
+		{"string", above53JSON, above53, false},
+		{"invalid string", "abc", 0, true},
+		{"bool", true, 0, true},
+		{"nil", nil, 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := AsInt64(test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %d", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.expected {
+				t.Errorf("expected %d, got %d", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetInt64Above53Bits(t *testing.T) {
+	body := `{"code": 0, "message": "ok", "data": {"lastEdited": ` + above53JSON + `}}`
+	for _, useNumber := range []bool{false, true} {
+		pad := NewEtherpadLite("key")
+		pad.UseJSONNumber = useNumber
+		res, err := pad.DecodeResponse(strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, isNumber := res.Data["lastEdited"].(json.Number); isNumber != useNumber {
+			t.Errorf("UseJSONNumber=%v: expected json.Number to be %v, got %T", useNumber, useNumber, res.Data["lastEdited"])
+		}
+		got, err := res.GetInt64("lastEdited")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != above53 {
+			t.Errorf("UseJSONNumber=%v: expected %d, got %d", useNumber, above53, got)
+		}
+		var data struct {
+			LastEdited int64 `json:"lastEdited"`
+		}
+		if err := res.DecodeData(&data); err != nil {
+			t.Fatal(err)
+		}
+		if data.LastEdited != above53 {
+			t.Errorf("UseJSONNumber=%v: expected DecodeData to return %d, got %d", useNumber, above53, data.LastEdited)
+		}
+	}
+}
+
+func TestLastEditedAbove53Bits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"code": 0, "message": "ok", "data": {"lastEdited": `+above53JSON+`}}`)
+	}))
+	defer server.Close()
+	pad := NewEtherpadLite("key")
+	pad.BaseURL = server.URL + "/api"
+	lastEdited, err := pad.LastEdited(context.Background(), "pad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lastEdited.Unix()*1000 + int64(lastEdited.Nanosecond())/1e6; got != above53 {
+		t.Errorf("expected %d milliseconds, got %d", above53, got)
+	}
+}
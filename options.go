@@ -0,0 +1,194 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidConfig is wrapped by all errors returned by Validate.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// apiVersionPattern matches valid API versions like "1", "1.2" or "1.2.13".
+var apiVersionPattern = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+
+// Validate checks the configuration of pad without sending a request: The API
+// key must not be empty, BaseURL must be an absolute http or https URL and
+// APIVersion must look like "1.2.13".
+// The errors returned wrap ErrInvalidConfig.
+func (pad *EtherpadLite) Validate() error {
+	apiKey, _ := pad.BaseParams["apikey"].(string)
+	if strings.TrimSpace(apiKey) == "" {
+		return fmt.Errorf("%w: the API key is empty", ErrInvalidConfig)
+	}
+	baseURL, err := url.Parse(pad.BaseURL)
+	if err != nil {
+		return fmt.Errorf("%w: invalid base URL: %v", ErrInvalidConfig, err)
+	}
+	if (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		return fmt.Errorf("%w: base URL %q must be an absolute http or https URL", ErrInvalidConfig, pad.BaseURL)
+	}
+	if !apiVersionPattern.MatchString(pad.APIVersion) {
+		return fmt.Errorf("%w: invalid API version %q", ErrInvalidConfig, pad.APIVersion)
+	}
+	if pad.Client == nil {
+		return fmt.Errorf("%w: no HTTP client", ErrInvalidConfig)
+	}
+	return nil
+}
+
+// Verify validates the configuration (see Validate) and then calls checkToken
+// to verify that the API key is accepted by the server. If the server rejects
+// the key the EtherpadError is returned (wrapped).
+func (pad *EtherpadLite) Verify(ctx context.Context) error {
+	if err := pad.Validate(); err != nil {
+		return err
+	}
+	resp, err := pad.CheckToken(ctx)
+	if err == nil {
+		err = ResponseToError(resp)
+	}
+	if err != nil {
+		return fmt.Errorf("verifying the API key failed: %w", err)
+	}
+	return nil
+}
+
+// Option is an option for New.
+type Option func(pad *EtherpadLite) error
+
+// New creates a new EtherpadLite instance like NewEtherpadLite, applies the
+// options and validates the result with Validate.
+func New(apiKey string, options ...Option) (*EtherpadLite, error) {
+	pad := NewEtherpadLite(apiKey)
+	for _, option := range options {
+		if err := option(pad); err != nil {
+			return nil, err
+		}
+	}
+	if err := pad.Validate(); err != nil {
+		return nil, err
+	}
+	return pad, nil
+}
+
+// WithBaseURL sets EtherpadLite.BaseURL, for example "http://pad.domain/api".
+func WithBaseURL(baseURL string) Option {
+	return func(pad *EtherpadLite) error {
+		pad.BaseURL = strings.TrimSuffix(baseURL, "/")
+		return nil
+	}
+}
+
+// WithAPIVersion sets EtherpadLite.APIVersion.
+func WithAPIVersion(version string) Option {
+	return func(pad *EtherpadLite) error {
+		pad.APIVersion = version
+		return nil
+	}
+}
+
+// WithHTTPClient sets EtherpadLite.Client.
+func WithHTTPClient(client *http.Client) Option {
+	return func(pad *EtherpadLite) error {
+		pad.Client = client
+		return nil
+	}
+}
+
+// WithRaiseErrors sets EtherpadLite.RaiseEtherpadErrors.
+func WithRaiseErrors(raise bool) Option {
+	return func(pad *EtherpadLite) error {
+		pad.RaiseEtherpadErrors = raise
+		return nil
+	}
+}
+
+// configureTLS applies configure to the TLS configuration of the transport of
+// pad.Client. Other settings of the client and the transport are kept, but
+// both are copied: http.DefaultClient, http.DefaultTransport and clients
+// shared with other code are never modified.
+// The transport must be a *http.Transport (or nil for the default transport).
+func configureTLS(pad *EtherpadLite, configure func(config *tls.Config)) error {
+	client := &http.Client{}
+	if pad.Client != nil {
+		*client = *pad.Client
+	}
+	var transport *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		defaultTransport, ok := http.DefaultTransport.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("%w: http.DefaultTransport of type %T has no TLS configuration", ErrInvalidConfig, http.DefaultTransport)
+		}
+		transport = defaultTransport.Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return fmt.Errorf("%w: transport of type %T has no TLS configuration", ErrInvalidConfig, client.Transport)
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	configure(transport.TLSClientConfig)
+	client.Transport = transport
+	pad.Client = client
+	return nil
+}
+
+// WithRootCAs sets the certificate authorities used to verify the certificate
+// of the server, for example a pool containing an internal CA.
+// The option changes the transport of EtherpadLite.Client (see
+// WithHTTPClient), so it must be given after WithHTTPClient. The transport
+// must be a *http.Transport, its other settings are kept.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(pad *EtherpadLite) error {
+		return configureTLS(pad, func(config *tls.Config) {
+			config.RootCAs = pool
+		})
+	}
+}
+
+// WithClientCertificate adds a client certificate that is sent to servers
+// requiring mutual TLS. See WithRootCAs for the requirements on the client.
+func WithClientCertificate(cert tls.Certificate) Option {
+	return func(pad *EtherpadLite) error {
+		return configureTLS(pad, func(config *tls.Config) {
+			config.Certificates = append(config.Certificates, cert)
+		})
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of the server
+// certificate. This makes the connection vulnerable to man-in-the-middle
+// attacks, the API key can be stolen!
+// Only use it in tests, never in production. Prefer WithRootCAs for servers
+// with self-signed certificates.
+// See WithRootCAs for the requirements on the client.
+func WithInsecureSkipVerify() Option {
+	return func(pad *EtherpadLite) error {
+		return configureTLS(pad, func(config *tls.Config) {
+			config.InsecureSkipVerify = true
+		})
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// PadExists checks if a pad exists. It returns false (and no error) if
+// etherpad reports that the pad does not exist, all other errors (for example
+// a wrong API key or network problems) are returned.
+func (pad *EtherpadLite) PadExists(ctx context.Context, padID string) (bool, error) {
+	_, err := pad.RevisionsCount(ctx, padID)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrPadNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// EnsurePad creates a pad with the given initial text if it doesn't exist yet.
+// created is true if the pad was created and false if it already existed, in
+// this case the content is not changed. If initialText is empty etherpad's
+// default text is used for new pads.
+// Concurrent calls for the same pad are safe, exactly one of them creates the
+// pad.
+func (pad *EtherpadLite) EnsurePad(ctx context.Context, padID, initialText string) (created bool, err error) {
+	params := map[string]interface{}{"padID": padID}
+	if initialText != "" {
+		params["text"] = initialText
+	}
+	_, err = pad.callChecked(ctx, "createPad", params)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, ErrPadExists):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// EnsureGroupPad creates a pad in a group if it doesn't exist yet and returns
+// its ID ("groupID$padName"). created is true if the pad was created and false
+// if it already existed, in this case the content is not changed. If text is
+// empty etherpad's default text is used for new pads.
+// If the group does not exist an EtherpadError matching ErrGroupNotFound is
+// returned.
+func (pad *EtherpadLite) EnsureGroupPad(ctx context.Context, groupID, padName, text string) (padID string, created bool, err error) {
+	params := map[string]interface{}{"groupID": groupID, "padName": padName}
+	if text != "" {
+		params["text"] = text
+	}
+	// older versions don't return the pad ID, so always build it ourselves
+	padID = groupID + "$" + padName
+	_, err = pad.callChecked(ctx, "createGroupPad", params)
+	switch {
+	case err == nil:
+		return padID, true, nil
+	case errors.Is(err, ErrPadExists):
+		return padID, false, nil
+	default:
+		return "", false, err
+	}
+}
+
+// DeletePadsError is returned by the helpers that delete multiple pads if
+// some of the pads could not be deleted.
+type DeletePadsError struct {
+	// Failed maps the IDs of the pads that could not be deleted to the error.
+	Failed map[string]error
+
+	// Err is set if the deletion was aborted, for example because the context
+	// was cancelled. Pads that were not processed are not in Failed.
+	Err error
+}
+
+// Error returns the error as a string.
+func (e *DeletePadsError) Error() string {
+	return fmt.Sprintf("failed to delete %d pad(s): %s", len(e.Failed), joinFailures(e.Err, e.Failed))
+}
+
+// Unwrap returns Err.
+func (e *DeletePadsError) Unwrap() error {
+	return e.Err
+}
+
+// deletePads deletes the pads with the given IDs using at most workers
+// concurrent requests. It returns the number of deleted pads and a
+// *DeletePadsError if a deletion failed or ctx was cancelled.
+func (pad *EtherpadLite) deletePads(ctx context.Context, ids []string, workers int) (int, error) {
+	deleted, failed, err := pad.deleteEach(ctx, "deletePad", "padID", ids, workers)
+	if err != nil || len(failed) > 0 {
+		return deleted, &DeletePadsError{Failed: failed, Err: err}
+	}
+	return deleted, nil
+}
+
+// ErrDestinationExists is returned by MovePadSafe and RenamePad if the
+// destination pad already exists.
+var ErrDestinationExists = errors.New("destination pad does already exist")
+
+// MovePadSafe moves the pad sourceID to destinationID. It checks that the
+// source exists first, if not an error matching ErrPadNotFound is returned.
+// If the destination exists and overwrite is false an error matching
+// ErrDestinationExists is returned, otherwise the destination is replaced.
+func (pad *EtherpadLite) MovePadSafe(ctx context.Context, sourceID, destinationID string, overwrite bool) error {
+	exists, err := pad.PadExists(ctx, sourceID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("source %s: %w", sourceID, ErrPadNotFound)
+	}
+	if !overwrite {
+		exists, err := pad.PadExists(ctx, destinationID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%w: %s", ErrDestinationExists, destinationID)
+		}
+	}
+	_, err = pad.callChecked(ctx, "movePad", map[string]interface{}{
+		"sourceID":      sourceID,
+		"destinationID": destinationID,
+		"force":         overwrite,
+	})
+	if errors.Is(err, ErrPadExists) {
+		// created concurrently
+		return fmt.Errorf("%w: %s", ErrDestinationExists, destinationID)
+	}
+	return err
+}
+
+// RenamePad renames a pad and returns the new pad ID. Group pads stay in their
+// group, that is newName replaces only the name after the "$".
+// newName must be a valid pad name (see JoinGroupPadID), the pad is never
+// overwritten: If a pad with the new ID exists an error matching
+// ErrDestinationExists is returned. See MovePadSafe.
+func (pad *EtherpadLite) RenamePad(ctx context.Context, padID, newName string) (string, error) {
+	var (
+		newID string
+		err   error
+	)
+	if groupID, _, splitErr := SplitGroupPadID(padID); splitErr == nil {
+		newID, err = JoinGroupPadID(groupID, newName)
+	} else {
+		newID, err = newName, validatePadName(newName)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := pad.MovePadSafe(ctx, padID, newID, false); err != nil {
+		return "", err
+	}
+	return newID, nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+)
+
+func TestPadExists(t *testing.T) {
+	server := etherpadtest.NewServer(t)
+	if err := server.Store.AddPad("pad", "text"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	// the result must not depend on RaiseEtherpadErrors
+	for _, raise := range []bool{false, true} {
+		pad := server.Client()
+		pad.RaiseEtherpadErrors = raise
+
+		exists, err := pad.PadExists(ctx, "pad")
+		if err != nil || !exists {
+			t.Errorf("RaiseEtherpadErrors=%v: expected pad to exist, got %v (%v)", raise, exists, err)
+		}
+
+		exists, err = pad.PadExists(ctx, "missing")
+		if err != nil || exists {
+			t.Errorf("RaiseEtherpadErrors=%v: expected pad not to exist, got %v (%v)", raise, exists, err)
+		}
+
+		pad.BaseParams["apikey"] = "wrong"
+		_, err = pad.PadExists(ctx, "pad")
+		if !errors.Is(err, etherpadlite.ErrWrongAPIKey) {
+			t.Errorf("RaiseEtherpadErrors=%v: expected ErrWrongAPIKey, got %v", raise, err)
+		}
+	}
+}
+
+func TestPadExistsNetworkError(t *testing.T) {
+	server, pad := newTestClient(t)
+	if err := server.Store.AddPad("pad", "text"); err != nil {
+		t.Fatal(err)
+	}
+	server.FailNext("getRevisionsCount", etherpadtest.Failure{Drop: true})
+	exists, err := pad.PadExists(context.Background(), "pad")
+	var reqErr *etherpadlite.RequestError
+	if !errors.As(err, &reqErr) {
+		t.Errorf("expected a RequestError, got %v (exists=%v)", err, exists)
+	}
+	// other errors with code WrongParameters are not reported as missing pad
+	server.FailNext("getRevisionsCount", etherpadtest.Failure{
+		Code:    etherpadlite.WrongParameters,
+		Message: "rev is not a number",
+	})
+	_, err = pad.PadExists(context.Background(), "pad")
+	if !errors.Is(err, etherpadlite.ErrWrongParameters) {
+		t.Errorf("expected ErrWrongParameters, got %v", err)
+	}
+}
+
+func TestCopyPadWithoutHistory(t *testing.T) {
+	server, pad := newTestClient(t)
+	pad.APIVersion = "1.2.15"
+	ctx := context.Background()
+	if _, err := pad.CreatePad(ctx, "source", "first"); err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"second", "third"} {
+		if _, err := pad.SetText(ctx, "source", text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := pad.CopyPadWithoutHistory(ctx, "source", "copy", etherpadlite.OptionalParam); err != nil {
+		t.Fatal(err)
+	}
+	if got := lastParams(t, server, "copyPadWithoutHistory").Get("force"); got != "false" {
+		t.Errorf("expected force=false if omitted, got %q", got)
+	}
+	text, err := pad.GetTextContent(ctx, "copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "third\n" {
+		t.Errorf("expected the current text to be copied, got %q", text)
+	}
+	revs, err := pad.RevisionsCount(ctx, "copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sourceRevs, _ := pad.RevisionsCount(ctx, "source"); revs >= sourceRevs {
+		t.Errorf("expected the copy to have less revisions than the source (%d), got %d", sourceRevs, revs)
+	}
+	if _, err := pad.GetTextContent(ctx, "copy", 2); err == nil {
+		t.Error("expected revisions of the source not to be copied")
+	}
+
+	// the destination exists, only overwritten with force
+	if _, err := pad.SetText(ctx, "source", "fourth"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = pad.CopyPadWithoutHistory(ctx, "source", "copy", false)
+	if !errors.Is(err, etherpadlite.ErrPadExists) {
+		t.Errorf("expected an error copying to an existing pad, got %v", err)
+	}
+	if _, err := pad.CopyPadWithoutHistory(ctx, "source", "copy", true); err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := server.Store.Text("copy"); text != "fourth\n" {
+		t.Errorf("expected the copy to be overwritten, got %q", text)
+	}
+	_, err = pad.CopyPadWithoutHistory(ctx, "missing", "other", etherpadlite.OptionalParam)
+	if !errors.Is(err, etherpadlite.ErrPadNotFound) {
+		t.Errorf("expected ErrPadNotFound for a missing source, got %v", err)
+	}
+}
+
+func TestMovePadSafe(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	for _, padID := range []string{"source", "existing"} {
+		if err := server.Store.AddPad(padID, padID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pad.MovePadSafe(ctx, "source", "existing", false); !errors.Is(err, etherpadlite.ErrDestinationExists) {
+		t.Errorf("expected ErrDestinationExists, got %v", err)
+	}
+	if n := len(server.RequestsFor("movePad")); n != 0 {
+		t.Errorf("expected movePad not to be called, got %d requests", n)
+	}
+	if err := pad.MovePadSafe(ctx, "missing", "other", false); !errors.Is(err, etherpadlite.ErrPadNotFound) {
+		t.Errorf("expected ErrPadNotFound, got %v", err)
+	}
+	if err := pad.MovePadSafe(ctx, "source", "moved", false); err != nil {
+		t.Fatal(err)
+	}
+	if text, ok := server.Store.Text("moved"); !ok || text != "source\n" {
+		t.Errorf("expected the pad to be moved, got %q (%v)", text, ok)
+	}
+}
+
+func TestMovePadSafeRace(t *testing.T) {
+	server, pad := newTestClient(t)
+	if err := server.Store.AddPad("source", "text"); err != nil {
+		t.Fatal(err)
+	}
+	// the destination is created between the check and movePad
+	server.FailNext("movePad", etherpadtest.Failure{
+		Code:    etherpadlite.WrongParameters,
+		Message: "destinationID already exists",
+	})
+	err := pad.MovePadSafe(context.Background(), "source", "destination", false)
+	if !errors.Is(err, etherpadlite.ErrDestinationExists) {
+		t.Errorf("expected ErrDestinationExists, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PadContentStats contains content metrics of a pad, see PadStats.
+type PadContentStats struct {
+	// Characters is the number of characters (runes) of the text.
+	Characters int
+
+	// Words is the number of words, a word is a sequence of letters, digits,
+	// marks and connector punctuation (like "_").
+	Words int
+
+	// Lines is the number of lines.
+	Lines int
+
+	// LastEdited is the time the pad was last edited.
+	LastEdited time.Time
+}
+
+// isWordRune returns true if r is part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || unicode.Is(unicode.Pc, r)
+}
+
+// countWords returns the number of words in text.
+func countWords(text string) int {
+	words := 0
+	inWord := false
+	for _, r := range text {
+		wordRune := isWordRune(r)
+		if wordRune && !inWord {
+			words++
+		}
+		inWord = wordRune
+	}
+	return words
+}
+
+// countLines returns the number of lines in text, a last line without
+// newline is counted as well.
+func countLines(text string) int {
+	lines := strings.Count(text, "\n")
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		lines++
+	}
+	return lines
+}
+
+// TextStats computes the character, word and line counts of a text.
+// LastEdited is not set.
+func TextStats(text string) *PadContentStats {
+	return &PadContentStats{
+		Characters: utf8.RuneCountInString(text),
+		Words:      countWords(text),
+		Lines:      countLines(text),
+	}
+}
+
+// PadStats returns content metrics of a pad: The counts are computed from the
+// text of the pad (see TextStats), the text and the last edited time are
+// fetched concurrently.
+// Note that etherpad always ends the text with a newline.
+func (pad *EtherpadLite) PadStats(ctx context.Context, padID string) (*PadContentStats, error) {
+	var (
+		lastEdited    time.Time
+		lastEditedErr error
+		wg            sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		lastEdited, lastEditedErr = pad.LastEdited(ctx, padID)
+	}()
+	text, err := pad.GetTextContent(ctx, padID)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if lastEditedErr != nil {
+		return nil, lastEditedErr
+	}
+	stats := TextStats(text)
+	stats.LastEdited = lastEdited
+	return stats, nil
+}
+
+// PadStatsBatch returns the content metrics of many pads (see PadStats) using
+// at most concurrency pads concurrently (DefaultConcurrency if <= 0).
+// As in GetTexts each pad ID is either in stats or in errs, pads that were not
+// processed because ctx was cancelled have ctx.Err() as error.
+func (pad *EtherpadLite) PadStatsBatch(ctx context.Context, padIDs []string, concurrency int) (stats map[string]*PadContentStats, errs map[string]error) {
+	stats = make(map[string]*PadContentStats, len(padIDs))
+	errs = make(map[string]error)
+	var mutex sync.Mutex
+	ctxErr := forEach(ctx, len(padIDs), concurrency, func(ctx context.Context, i int) error {
+		padStats, err := pad.PadStats(ctx, padIDs[i])
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil {
+			errs[padIDs[i]] = err
+		} else {
+			stats[padIDs[i]] = padStats
+		}
+		return nil
+	})
+	if ctxErr != nil {
+		for _, id := range padIDs {
+			if _, done := stats[id]; done {
+				continue
+			}
+			if _, done := errs[id]; !done {
+				errs[id] = ctxErr
+			}
+		}
+	}
+	return stats, errs
+}
@@ -0,0 +1,81 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// TimeEncoding describes how time.Time and time.Duration parameters are
+// encoded.
+type TimeEncoding int
+
+const (
+	// UnixSeconds encodes a time.Time as seconds since the Unix epoch and a
+	// time.Duration as (truncated) seconds. This is what etherpad expects for
+	// validUntil in createSession.
+	UnixSeconds TimeEncoding = iota
+
+	// UnixMilliseconds encodes a time.Time as milliseconds since the Unix epoch
+	// and a time.Duration as (truncated) milliseconds.
+	UnixMilliseconds
+)
+
+// encodeParam converts the value of a parameter to the string that is sent
+// to the API.
+//
+// Supported are strings, booleans, all int, uint and float types (and types
+// based on them), time.Time and time.Duration (encoded as described by enc)
+// and fmt.Stringer.
+// All other types (for example slices or nil) return an error.
+func encodeParam(value interface{}, enc TimeEncoding) (string, error) {
+	switch v := value.(type) {
+	case time.Time:
+		if enc == UnixMilliseconds {
+			return strconv.FormatInt(v.UnixNano()/int64(time.Millisecond), 10), nil
+		}
+		return strconv.FormatInt(v.Unix(), 10), nil
+	case time.Duration:
+		if enc == UnixMilliseconds {
+			return strconv.FormatInt(int64(v/time.Millisecond), 10), nil
+		}
+		return strconv.FormatInt(int64(v/time.Second), 10), nil
+	}
+	if value == nil {
+		return "", fmt.Errorf("can't encode nil as parameter")
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), nil
+	}
+	if stringer, ok := value.(fmt.Stringer); ok {
+		return stringer.String(), nil
+	}
+	return "", fmt.Errorf("can't encode value of type %T as parameter", value)
+}
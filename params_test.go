@@ -0,0 +1,135 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testStringer struct{}
+
+func (testStringer) String() string { return "stringer" }
+
+type testString string
+
+type testInt int
+
+func (testInt) String() string { return "ignored" }
+
+func TestEncodeParam(t *testing.T) {
+	date := time.Date(2019, 3, 4, 5, 6, 7, 890*int(time.Millisecond), time.UTC)
+	tests := []struct {
+		name     string
+		value    interface{}
+		enc      TimeEncoding
+		expected string
+	}{
+		{"string", "foo bar", UnixSeconds, "foo bar"},
+		{"empty string", "", UnixSeconds, ""},
+		{"named string", testString("named"), UnixSeconds, "named"},
+		{"true", true, UnixSeconds, "true"},
+		{"false", false, UnixSeconds, "false"},
+		{"int", 42, UnixSeconds, "42"},
+		{"negative int", -1, UnixSeconds, "-1"},
+		{"int8", int8(-8), UnixSeconds, "-8"},
+		{"int16", int16(16), UnixSeconds, "16"},
+		{"int32", int32(32), UnixSeconds, "32"},
+		{"int64", int64(1) << 62, UnixSeconds, "4611686018427387904"},
+		{"uint", uint(7), UnixSeconds, "7"},
+		{"uint8", uint8(255), UnixSeconds, "255"},
+		{"uint16", uint16(65535), UnixSeconds, "65535"},
+		{"uint32", uint32(1) << 31, UnixSeconds, "2147483648"},
+		{"uint64", uint64(1<<64 - 1), UnixSeconds, "18446744073709551615"},
+		{"float32", float32(1.5), UnixSeconds, "1.5"},
+		{"float64", 0.1, UnixSeconds, "0.1"},
+		{"large float64", 1e21, UnixSeconds, "1000000000000000000000"},
+		{"integral float64", float64(3), UnixSeconds, "3"},
+		// types based on numbers are encoded as numbers even if they implement
+		// fmt.Stringer
+		{"named int", testInt(3), UnixSeconds, "3"},
+		{"return code", WrongAPIKey, UnixSeconds, "4"},
+		{"time seconds", date, UnixSeconds, "1551675967"},
+		{"time milliseconds", date, UnixMilliseconds, "1551675967890"},
+		{"time other zone", date.In(time.FixedZone("x", 3600)), UnixSeconds, "1551675967"},
+		{"duration seconds", 90*time.Second + 500*time.Millisecond, UnixSeconds, "90"},
+		{"duration milliseconds", 90*time.Second + 500*time.Millisecond, UnixMilliseconds, "90500"},
+		{"stringer", testStringer{}, UnixSeconds, "stringer"},
+		{"pointer stringer", &testStringer{}, UnixSeconds, "stringer"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := encodeParam(test.value, test.enc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestEncodeParamUnsupported(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		msg   string
+	}{
+		{"nil", nil, "nil"},
+		{"slice", []string{"a", "b"}, "[]string"},
+		{"map", map[string]string{}, "map[string]string"},
+		{"struct", struct{}{}, "struct {}"},
+		{"pointer", new(int), "*int"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := encodeParam(test.value, UnixSeconds)
+			if err == nil {
+				t.Fatalf("expected an error, got %q", got)
+			}
+			if !strings.Contains(err.Error(), test.msg) {
+				t.Errorf("expected error to mention %q, got %v", test.msg, err)
+			}
+		})
+	}
+}
+
+func TestCreateSessionTimeParam(t *testing.T) {
+	pad := NewEtherpadLite("key")
+	validUntil := time.Unix(1551675967, 0)
+	params := map[string]interface{}{
+		"groupID":    "g.1",
+		"authorID":   "a.1",
+		"validUntil": validUntil,
+	}
+	req, err := pad.BuildRequest(context.Background(), "createSession", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.URL.Query().Get("validUntil"); got != "1551675967" {
+		t.Errorf("expected validUntil 1551675967, got %q", got)
+	}
+	pad.TimeEncoding = UnixMilliseconds
+	req, err = pad.BuildRequest(context.Background(), "createSession", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.URL.Query().Get("validUntil"); got != "1551675967000" {
+		t.Errorf("expected validUntil 1551675967000, got %q", got)
+	}
+}
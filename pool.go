@@ -0,0 +1,345 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPoolFailureThreshold is the number of consecutive failures after
+	// which a backend of a Pool is marked unhealthy.
+	DefaultPoolFailureThreshold = 3
+
+	// DefaultPoolProbeInterval is the interval in which a Pool probes its
+	// unhealthy backends.
+	DefaultPoolProbeInterval = 30 * time.Second
+)
+
+// poolBackend is a backend server of a Pool.
+type poolBackend struct {
+	baseURL   *url.URL
+	transport http.RoundTripper
+
+	// failures and healthy are protected by the mutex of the pool
+	failures int
+	healthy  bool
+}
+
+// Pool distributes requests over multiple etherpad servers that share the
+// same database, failing over to the next backend if a backend is down.
+//
+// Pool embeds an EtherpadLite, so all methods (including the frontend
+// methods like ExportPad) can be called on the pool. A request is sent to the
+// primary (first healthy) backend, if it fails with a network error or an HTTP
+// status >= 500 it is sent to the next healthy backend. Note that a failed
+// request may have been processed by the backend, so in rare cases a change
+// can be applied twice.
+// After FailureThreshold consecutive failures a backend is marked unhealthy
+// and skipped, Run probes unhealthy backends with checkToken and restores
+// them. If no backend is healthy all backends are tried.
+//
+// The embedded EtherpadLite must not be replaced, its Client and BaseURL are
+// managed by the pool.
+type Pool struct {
+	*EtherpadLite
+
+	// FailureThreshold is the number of consecutive failures after which a
+	// backend is marked unhealthy, DefaultPoolFailureThreshold if <= 0.
+	FailureThreshold int
+
+	// ProbeInterval is the interval in which Run probes unhealthy backends,
+	// DefaultPoolProbeInterval if <= 0.
+	ProbeInterval time.Duration
+
+	// RoundRobinReads distributes read calls (the API methods that don't
+	// change anything and pad exports) over all healthy backends. Writes are
+	// always sent to the primary backend.
+	RoundRobinReads bool
+
+	mutex    sync.Mutex
+	backends []*poolBackend
+	next     int
+}
+
+// NewPool creates a pool from the given instances, the first one is the
+// primary backend. The configuration (API key, API version, retries etc.) is
+// taken from the first instance, only BaseURL and the transport of the
+// Client are used from the others. The circuit breaker of the pool is
+// disabled, the pool does its own failure tracking.
+func NewPool(pads ...*EtherpadLite) (*Pool, error) {
+	if len(pads) == 0 {
+		return nil, fmt.Errorf("%w: pool without backends", ErrInvalidConfig)
+	}
+	pool := &Pool{}
+	for _, pad := range pads {
+		u, err := url.Parse(strings.TrimSuffix(pad.BaseURL, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid BaseURL %q: %v", ErrInvalidConfig, pad.BaseURL, err)
+		}
+		transport := http.DefaultTransport
+		if pad.Client != nil && pad.Client.Transport != nil {
+			transport = pad.Client.Transport
+		}
+		pool.backends = append(pool.backends, &poolBackend{baseURL: u, transport: transport, healthy: true})
+	}
+	first := pads[0]
+	client := &http.Client{Transport: &poolTransport{pool: pool}}
+	if first.Client != nil {
+		client.Timeout = first.Client.Timeout
+		client.Jar = first.Client.Jar
+	}
+	pool.EtherpadLite = &EtherpadLite{
+		APIVersion:            first.APIVersion,
+		BaseParams:            first.BaseParams,
+		BaseURL:               first.BaseURL,
+		Client:                client,
+		RaiseEtherpadErrors:   first.RaiseEtherpadErrors,
+		MaxRetries:            first.MaxRetries,
+		MaxConcurrentRequests: first.MaxConcurrentRequests,
+		UseJSONNumber:         first.UseJSONNumber,
+		TimeEncoding:          first.TimeEncoding,
+		StrictIDs:             first.StrictIDs,
+		NormalizeLineEndings:  first.NormalizeLineEndings,
+		MapperCache:           first.MapperCache,
+		Clock:                 first.Clock,
+		Debug:                 first.Debug,
+	}
+	return pool, nil
+}
+
+// NewPoolFromURLs creates a pool with the API key for the given base URLs
+// (like "http://localhost:9001/api"), see NewPool.
+func NewPoolFromURLs(apiKey string, baseURLs ...string) (*Pool, error) {
+	pads := make([]*EtherpadLite, len(baseURLs))
+	for i, baseURL := range baseURLs {
+		pads[i] = NewEtherpadLite(apiKey)
+		pads[i].BaseURL = baseURL
+	}
+	return NewPool(pads...)
+}
+
+// Healthy returns the base URLs of the backends that are currently healthy.
+func (p *Pool) Healthy() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var res []string
+	for _, b := range p.backends {
+		if b.healthy {
+			res = append(res, b.baseURL.String())
+		}
+	}
+	return res
+}
+
+// order returns the backends in the order they should be tried.
+func (p *Pool) order(read bool) []*poolBackend {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	var healthy, unhealthy []*poolBackend
+	for _, b := range p.backends {
+		if b.healthy {
+			healthy = append(healthy, b)
+		} else {
+			unhealthy = append(unhealthy, b)
+		}
+	}
+	if read && p.RoundRobinReads && len(healthy) > 1 {
+		start := p.next % len(healthy)
+		p.next++
+		healthy = append(healthy[start:], healthy[:start]...)
+	}
+	if len(healthy) == 0 {
+		return unhealthy
+	}
+	return healthy
+}
+
+// report records the result of a request to b.
+func (p *Pool) report(b *poolBackend, ok bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if ok {
+		b.failures, b.healthy = 0, true
+		return
+	}
+	threshold := p.FailureThreshold
+	if threshold <= 0 {
+		threshold = DefaultPoolFailureThreshold
+	}
+	b.failures++
+	if b.failures >= threshold {
+		b.healthy = false
+	}
+}
+
+// rewrite returns the URL u (a URL below the BaseURL of the embedded
+// EtherpadLite, either API or frontend) for the backend b.
+func (p *Pool) rewrite(u *url.URL, b *poolBackend) *url.URL {
+	primary, err := url.Parse(strings.TrimSuffix(p.EtherpadLite.BaseURL, "/"))
+	if err != nil {
+		return u
+	}
+	oldRoot := strings.TrimSuffix(primary.Path, "/api")
+	newRoot := strings.TrimSuffix(b.baseURL.Path, "/api")
+	res := *u
+	res.Scheme, res.Host, res.User = b.baseURL.Scheme, b.baseURL.Host, b.baseURL.User
+	if strings.HasPrefix(u.Path, oldRoot) {
+		res.Path = newRoot + strings.TrimPrefix(u.Path, oldRoot)
+		res.RawPath = ""
+		if u.RawPath != "" {
+			res.RawPath = newRoot + strings.TrimPrefix(u.RawPath, oldRoot)
+		}
+	}
+	return &res
+}
+
+// readPrefixes are the prefixes of API methods that don't change anything.
+var readPrefixes = []string{"get", "list", "is", "pad", "checkToken"}
+
+// isReadRequest returns true if req doesn't change anything.
+func isReadRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	if strings.Contains(req.URL.Path, "/export/") {
+		return true
+	}
+	method := path.Base(req.URL.Path)
+	for _, prefix := range readPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// poolTransport sends requests to the backends of a pool.
+type poolTransport struct {
+	pool *Pool
+}
+
+// errNoBackend is returned if a request can't be sent to any backend.
+var errNoBackend = errors.New("no backend available")
+
+func (t *poolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	backends := t.pool.order(isReadRequest(req))
+	lastErr := errNoBackend
+	for i, b := range backends {
+		// the request of the caller must not be modified
+		attempt := req.Clone(req.Context())
+		if i > 0 && req.Body != nil {
+			// the body was consumed by the previous attempt
+			if req.GetBody == nil {
+				return nil, lastErr
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+		attempt.URL = t.pool.rewrite(req.URL, b)
+		attempt.Host = ""
+		resp, err := b.transport.RoundTrip(attempt)
+		if req.Context().Err() != nil {
+			// not the fault of the backend
+			return resp, err
+		}
+		failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+		t.pool.report(b, !failed)
+		if !failed || i == len(backends)-1 {
+			return resp, err
+		}
+		if err != nil {
+			lastErr = err
+		} else {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("backend %s: %s", b.baseURL, resp.Status)
+		}
+	}
+	return nil, lastErr
+}
+
+// Probe sends checkToken to all unhealthy backends and marks them healthy if
+// they answer (with any HTTP status below 500). Backends that don't answer
+// stay unhealthy, an error is only returned if the request can't be built
+// (for example because of an invalid configuration).
+func (p *Pool) Probe(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	p.mutex.Lock()
+	var unhealthy []*poolBackend
+	for _, b := range p.backends {
+		if !b.healthy {
+			unhealthy = append(unhealthy, b)
+		}
+	}
+	p.mutex.Unlock()
+	for _, b := range unhealthy {
+		req, err := p.EtherpadLite.BuildRequest(ctx, "checkToken", nil)
+		if err != nil {
+			return err
+		}
+		req.URL = p.rewrite(req.URL, b)
+		req.Host = ""
+		resp, err := b.transport.RoundTrip(req)
+		if err != nil {
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode < http.StatusInternalServerError {
+			p.report(b, true)
+		}
+	}
+	return nil
+}
+
+// Run probes the unhealthy backends every ProbeInterval until ctx is done, it
+// returns nil once ctx is done. If a probe returns an error (see Probe) Run
+// stops and returns it.
+func (p *Pool) Run(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	interval := p.ProbeInterval
+	if interval <= 0 {
+		interval = DefaultPoolProbeInterval
+	}
+	ticker := p.clock().NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C():
+			if err := p.Probe(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+	"github.com/FabianWe/etherpadlite-golang/mock"
+)
+
+// newTestPool returns a pool of two fake servers sharing a store.
+func newTestPool(t *testing.T) (primary, secondary *etherpadtest.Server, pool *etherpadlite.Pool) {
+	t.Helper()
+	store := mock.NewStore()
+	primary = etherpadtest.NewServer(t, etherpadtest.WithStore(store))
+	secondary = etherpadtest.NewServer(t, etherpadtest.WithStore(store))
+	pool, err := etherpadlite.NewPool(primary.Client(), secondary.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.RaiseEtherpadErrors = true
+	return primary, secondary, pool
+}
+
+func TestPoolFailoverKeepsRequest(t *testing.T) {
+	primary, secondary, pool := newTestPool(t)
+	if err := primary.Store.AddPad("pad", "text"); err != nil {
+		t.Fatal(err)
+	}
+	primary.FailNext("setText", etherpadtest.Failure{Status: http.StatusInternalServerError})
+	u := primary.BaseURL() + "/" + etherpadlite.CurrentVersion + "/setText?apikey=" + etherpadtest.DefaultAPIKey
+	req, err := http.NewRequest(http.MethodPost, u, strings.NewReader("padID=pad&text=failover"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body := req.Body
+	resp, err := pool.Client.Transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if req.Body != body || req.URL.String() != u {
+		t.Error("the request of the caller was modified")
+	}
+	if n := len(secondary.RequestsFor("setText")); n != 1 {
+		t.Fatalf("expected the request to be sent to the secondary backend, got %d requests", n)
+	}
+	if text, _ := secondary.Store.Text("pad"); text != "failover\n" {
+		t.Errorf("expected the body to be sent again, got text %q", text)
+	}
+}
+
+func TestPoolProbeError(t *testing.T) {
+	primary, _, pool := newTestPool(t)
+	pool.FailureThreshold = 1
+	primary.FailNext("checkToken", etherpadtest.Failure{Status: http.StatusInternalServerError})
+	ctx := context.Background()
+	if _, err := pool.CheckToken(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if healthy := pool.Healthy(); len(healthy) != 1 {
+		t.Fatalf("expected the primary backend to be unhealthy, got %v", healthy)
+	}
+	if err := pool.Probe(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if healthy := pool.Healthy(); len(healthy) != 2 {
+		t.Fatalf("expected the primary backend to be restored, got %v", healthy)
+	}
+
+	// the probe request can't be built
+	primary.FailNext("checkToken", etherpadtest.Failure{Status: http.StatusInternalServerError})
+	if _, err := pool.CheckToken(ctx); err != nil {
+		t.Fatal(err)
+	}
+	pool.BaseParams["invalid"] = make(chan int)
+	if err := pool.Probe(ctx); err == nil {
+		t.Error("expected an error if the probe request can't be built")
+	}
+	clock := etherpadtest.NewFakeClock(time.Now())
+	pool.Clock = clock
+	pool.ProbeInterval = time.Minute
+	done := make(chan error, 1)
+	go func() {
+		done <- pool.Run(ctx)
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("expected Run to return the error of Probe")
+		}
+	case <-time.After(testTimeout):
+		t.Fatal("Run did not return")
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PurgeAction is the action PurgeOldPads took for a pad.
+type PurgeAction string
+
+const (
+	// PurgeKept means the pad was edited after the cutoff.
+	PurgeKept PurgeAction = "kept"
+
+	// PurgeExcluded means the pad was excluded by PurgeOptions.
+	PurgeExcluded PurgeAction = "excluded"
+
+	// PurgeDeleted means the pad was deleted.
+	PurgeDeleted PurgeAction = "deleted"
+
+	// PurgeWouldDelete means the pad would have been deleted but DryRun was
+	// set.
+	PurgeWouldDelete PurgeAction = "would delete"
+
+	// PurgeCapped means the pad is old but was not deleted because
+	// PurgeOptions.MaxDeletions was reached.
+	PurgeCapped PurgeAction = "capped"
+
+	// PurgeFailed means the last edited time could not be fetched or the pad
+	// could not be deleted, see PurgeEntry.Err.
+	PurgeFailed PurgeAction = "failed"
+)
+
+// PurgeEntry describes a pad considered by PurgeOldPads.
+type PurgeEntry struct {
+	PadID string
+
+	// LastEdited is the time the pad was last edited, zero for excluded pads.
+	LastEdited time.Time
+
+	Action PurgeAction
+
+	// Err is set if Action is PurgeFailed.
+	Err error
+}
+
+// PurgeReport is returned by PurgeOldPads.
+type PurgeReport struct {
+	// Cutoff is the time pads must have been edited after to be kept.
+	Cutoff time.Time
+
+	// Pads contains an entry for each pad, sorted by pad ID.
+	Pads []PurgeEntry
+}
+
+// Count returns the number of pads with the given action.
+func (r *PurgeReport) Count(action PurgeAction) int {
+	n := 0
+	for _, entry := range r.Pads {
+		if entry.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// PurgeOptions are the options for PurgeOldPads.
+type PurgeOptions struct {
+	// DryRun only reports the pads that would be deleted.
+	DryRun bool
+
+	// Exclude, if not nil, is called for each pad ID, pads for which it
+	// returns true are never deleted.
+	Exclude func(padID string) bool
+
+	// ExcludePattern, if not nil, excludes all pads with an ID matching the
+	// pattern.
+	ExcludePattern *regexp.Regexp
+
+	// MaxDeletions is the maximal number of pads deleted in one run, the
+	// oldest pads are deleted first. 0 means no limit.
+	MaxDeletions int
+
+	// Concurrency is the maximal number of concurrent requests,
+	// DefaultConcurrency if <= 0.
+	Concurrency int
+}
+
+// excluded returns true if padID is excluded by the options.
+func (opts *PurgeOptions) excluded(padID string) bool {
+	if opts.ExcludePattern != nil && opts.ExcludePattern.MatchString(padID) {
+		return true
+	}
+	return opts.Exclude != nil && opts.Exclude(padID)
+}
+
+// PurgeOldPads deletes all pads that were not edited within olderThan (based
+// on getLastEdited).
+// The report lists every pad and the action taken, pads that could not be
+// checked or deleted don't abort the run. An error is returned if the pads
+// could not be listed or ctx is cancelled (in this case no further pads are
+// deleted, the report contains the pads processed so far).
+func (pad *EtherpadLite) PurgeOldPads(ctx context.Context, olderThan time.Duration, opts PurgeOptions) (*PurgeReport, error) {
+	report := &PurgeReport{Cutoff: pad.clock().Now().Add(-olderThan)}
+	padIDs, err := pad.ListAllPadIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(padIDs)
+	entries := make([]PurgeEntry, len(padIDs))
+	var candidates []int
+	for i, id := range padIDs {
+		entries[i].PadID = id
+		if opts.excluded(id) {
+			entries[i].Action = PurgeExcluded
+		} else {
+			candidates = append(candidates, i)
+		}
+	}
+	ctxErr := forEach(ctx, len(candidates), opts.Concurrency, func(ctx context.Context, i int) error {
+		entry := &entries[candidates[i]]
+		lastEdited, err := pad.LastEdited(ctx, entry.PadID)
+		switch {
+		case err != nil:
+			entry.Action, entry.Err = PurgeFailed, err
+		case lastEdited.Before(report.Cutoff):
+			entry.LastEdited = lastEdited
+		default:
+			entry.LastEdited, entry.Action = lastEdited, PurgeKept
+		}
+		return nil
+	})
+	// entries without action are old, delete the oldest first
+	var old []*PurgeEntry
+	for i := range entries {
+		if entries[i].Action == "" && !entries[i].LastEdited.IsZero() {
+			old = append(old, &entries[i])
+		}
+	}
+	sort.SliceStable(old, func(i, j int) bool {
+		return old[i].LastEdited.Before(old[j].LastEdited)
+	})
+	if opts.MaxDeletions > 0 && len(old) > opts.MaxDeletions {
+		for _, entry := range old[opts.MaxDeletions:] {
+			entry.Action = PurgeCapped
+		}
+		old = old[:opts.MaxDeletions]
+	}
+	if ctxErr == nil {
+		ctxErr = forEach(ctx, len(old), opts.Concurrency, func(ctx context.Context, i int) error {
+			entry := old[i]
+			if opts.DryRun {
+				entry.Action = PurgeWouldDelete
+				return nil
+			}
+			_, err := pad.callChecked(ctx, "deletePad", map[string]interface{}{"padID": entry.PadID})
+			if err != nil {
+				entry.Action, entry.Err = PurgeFailed, err
+			} else {
+				entry.Action = PurgeDeleted
+			}
+			return nil
+		})
+	}
+	for _, entry := range entries {
+		if entry.Action != "" {
+			report.Pads = append(report.Pads, entry)
+		}
+	}
+	return report, ctxErr
+}
+
+// ErrNotConfirmed is returned by DeletePadsMatching if the confirmation
+// callback rejected the deletion.
+var ErrNotConfirmed = errors.New("deletion not confirmed")
+
+// DeleteMatchingOptions are the options for DeletePadsMatching.
+type DeleteMatchingOptions struct {
+	// DryRun only returns the matching pads without deleting them.
+	DryRun bool
+
+	// Confirm, if not nil, is called with the IDs of the matching pads before
+	// anything is deleted. If it returns false nothing is deleted and
+	// ErrNotConfirmed is returned. It is not called if DryRun is set.
+	Confirm func(padIDs []string) bool
+
+	// Concurrency is the maximal number of concurrent delete requests,
+	// DefaultConcurrency if <= 0.
+	Concurrency int
+}
+
+// DeletePadsMatching deletes all pads with an ID matching re and returns the
+// IDs of the deleted pads (sorted). With DryRun the matching IDs are returned
+// without deleting them. Use the Confirm callback to guard against patterns
+// that match more than intended.
+// If some pads could not be deleted the IDs of the pads that were deleted are
+// returned together with a *DeletePadsError.
+func (pad *EtherpadLite) DeletePadsMatching(ctx context.Context, re *regexp.Regexp, opts ...DeleteMatchingOptions) ([]string, error) {
+	var options DeleteMatchingOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	padIDs, err := pad.ListAllPadIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matches := []string{}
+	for _, id := range padIDs {
+		if re.MatchString(id) {
+			matches = append(matches, id)
+		}
+	}
+	sort.Strings(matches)
+	if options.DryRun {
+		return matches, nil
+	}
+	if options.Confirm != nil && !options.Confirm(matches) {
+		return nil, ErrNotConfirmed
+	}
+	var (
+		mutex  sync.Mutex
+		failed = make(map[string]error)
+	)
+	deleted := make([]bool, len(matches))
+	err = forEach(ctx, len(matches), options.Concurrency, func(ctx context.Context, i int) error {
+		_, err := pad.callChecked(ctx, "deletePad", map[string]interface{}{"padID": matches[i]})
+		if err != nil {
+			mutex.Lock()
+			failed[matches[i]] = err
+			mutex.Unlock()
+		} else {
+			deleted[i] = true
+		}
+		return nil
+	})
+	res := []string{}
+	for i, id := range matches {
+		if deleted[i] {
+			res = append(res, id)
+		}
+	}
+	if err != nil || len(failed) > 0 {
+		return res, &DeletePadsError{Failed: failed, Err: err}
+	}
+	return res, nil
+}
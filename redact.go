@@ -0,0 +1,74 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted is the value that replaces the API key in URLs that are part of
+// errors or debug output.
+const Redacted = "REDACTED"
+
+// apiKeyPattern is used to redact the API key from URLs that can't be parsed
+// and from URL encoded request bodies.
+var apiKeyPattern = regexp.MustCompile(`(?i)((?:^|[?&])apikey=)[^&#\s]*`)
+
+// RedactURL replaces the value of the apikey query parameter in rawURL by
+// Redacted. It should be used whenever a URL is printed or logged.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redactQuery(rawURL)
+	}
+	query := u.Query()
+	changed := false
+	for key := range query {
+		if isAPIKeyParam(key) {
+			query.Set(key, Redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return rawURL
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// redactQuery replaces the value of the apikey parameter in an URL encoded
+// query string (or an URL that can't be parsed) by Redacted.
+func redactQuery(query string) string {
+	return apiKeyPattern.ReplaceAllString(query, "${1}"+Redacted)
+}
+
+// isAPIKeyParam returns true if the query parameter key contains the API key.
+func isAPIKeyParam(key string) bool {
+	return strings.EqualFold(key, "apikey")
+}
+
+// redactError removes the API key from the URL contained in a *url.Error as
+// returned by http.Client.Do. The error is modified in place, other errors
+// are returned unchanged.
+func redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = RedactURL(urlErr.URL)
+	}
+	return err
+}
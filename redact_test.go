@@ -0,0 +1,77 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+const secretAPIKey = "s3cr3t-api-key"
+
+// refusingURL returns the URL of a port nothing listens on.
+func refusingURL(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return "http://" + addr + "/api"
+}
+
+func TestRedactConnectionRefused(t *testing.T) {
+	var debug bytes.Buffer
+	pad := NewEtherpadLite(secretAPIKey)
+	pad.BaseURL = refusingURL(t)
+	pad.Debug = &debug
+	_, err := pad.CreatePad(context.Background(), "pad", "text")
+	if err == nil {
+		t.Fatal("expected an error connecting to a closed port")
+	}
+	if msg := err.Error(); strings.Contains(msg, secretAPIKey) {
+		t.Errorf("API key found in error: %s", msg)
+	} else if !strings.Contains(msg, Redacted) {
+		t.Errorf("expected %s in error, got %s", Redacted, msg)
+	}
+	if strings.Contains(debug.String(), secretAPIKey) {
+		t.Errorf("API key found in debug output: %s", debug.String())
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		in, expected string
+	}{
+		{"http://localhost/api/1/getText?apikey=" + secretAPIKey + "&padID=foo",
+			"http://localhost/api/1/getText?apikey=REDACTED&padID=foo"},
+		{"http://localhost/api/1/getText?padID=foo&APIKEY=" + secretAPIKey,
+			"http://localhost/api/1/getText?APIKEY=REDACTED&padID=foo"},
+		{"http://localhost/api/1/getText?padID=foo",
+			"http://localhost/api/1/getText?padID=foo"},
+		// can't be parsed, the query is redacted anyway
+		{"http://local host/?apikey=" + secretAPIKey + "&x=%zz",
+			"http://local host/?apikey=REDACTED&x=%zz"},
+	}
+	for _, test := range tests {
+		if got := RedactURL(test.in); got != test.expected {
+			t.Errorf("RedactURL(%q): expected %q, got %q", test.in, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PadReport contains all metadata of a pad, see EtherpadLite.PadReport.
+// Fields whose request failed have their zero value, the error is in Errors.
+type PadReport struct {
+	PadID string
+
+	// Revisions is the number of revisions.
+	Revisions int
+
+	// SavedRevisions are the numbers of the saved revisions.
+	SavedRevisions []int
+
+	// LastEdited is the time the pad was last edited.
+	LastEdited time.Time
+
+	// Users are the users currently connected to the pad.
+	Users []PadUser
+
+	// Authors are the IDs of all authors that contributed to the pad.
+	Authors []string
+
+	// Public is the public status, only available for group pads.
+	Public bool
+
+	// PasswordProtected is true if the pad has a password.
+	PasswordProtected bool
+
+	// ReadOnlyID is the read-only ID of the pad.
+	ReadOnlyID string
+
+	// Errors maps the names of the fields that could not be fetched (for
+	// example "Public") to the error.
+	Errors map[string]error
+}
+
+// PadReport fetches all metadata of a pad concurrently. If a request fails
+// the field is annotated in PadReport.Errors, an error is only returned if
+// all requests failed (for example because the pad does not exist).
+func (pad *EtherpadLite) PadReport(ctx context.Context, padID string) (*PadReport, error) {
+	report := &PadReport{PadID: padID, Errors: make(map[string]error)}
+	fields := []struct {
+		name  string
+		fetch func() error
+	}{
+		{"Revisions", func() (err error) {
+			report.Revisions, err = pad.RevisionsCount(ctx, padID)
+			return
+		}},
+		{"SavedRevisions", func() (err error) {
+			report.SavedRevisions, err = pad.SavedRevisions(ctx, padID)
+			return
+		}},
+		{"LastEdited", func() (err error) {
+			report.LastEdited, err = pad.LastEdited(ctx, padID)
+			return
+		}},
+		{"Users", func() (err error) {
+			report.Users, err = pad.PadUsersList(ctx, padID)
+			return
+		}},
+		{"Authors", func() (err error) {
+			report.Authors, err = pad.AuthorsOfPad(ctx, padID)
+			return
+		}},
+		{"Public", func() (err error) {
+			report.Public, err = pad.PublicStatus(ctx, padID)
+			return
+		}},
+		{"PasswordProtected", func() (err error) {
+			report.PasswordProtected, err = pad.PasswordProtected(ctx, padID)
+			return
+		}},
+		{"ReadOnlyID", func() (err error) {
+			report.ReadOnlyID, err = pad.ReadOnlyID(ctx, padID)
+			return
+		}},
+	}
+	errs := make([]error, len(fields))
+	var wg sync.WaitGroup
+	for i := range fields {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = fields[i].fetch()
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			report.Errors[fields[i].name] = err
+		}
+	}
+	if len(report.Errors) == len(fields) {
+		return nil, errs[0]
+	}
+	return report, nil
+}
+
+// ServerReport contains the reports of all pads, see EtherpadLite.ServerReport.
+type ServerReport struct {
+	// Pads are the reports of the pads, sorted by pad ID.
+	Pads []*PadReport
+
+	// Failed maps the IDs of the pads whose report failed to the error.
+	Failed map[string]error
+}
+
+// ServerReport creates a PadReport for each pad on the server, processing at
+// most concurrency pads concurrently (DefaultConcurrency if <= 0).
+// If ctx is cancelled the reports created so far are returned together with
+// ctx.Err().
+func (pad *EtherpadLite) ServerReport(ctx context.Context, concurrency int) (*ServerReport, error) {
+	padIDs, err := pad.ListAllPadIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(padIDs)
+	reports := make([]*PadReport, len(padIDs))
+	res := &ServerReport{Pads: []*PadReport{}, Failed: make(map[string]error)}
+	var mutex sync.Mutex
+	ctxErr := forEach(ctx, len(padIDs), concurrency, func(ctx context.Context, i int) error {
+		report, err := pad.PadReport(ctx, padIDs[i])
+		if err != nil {
+			mutex.Lock()
+			res.Failed[padIDs[i]] = err
+			mutex.Unlock()
+		}
+		reports[i] = report
+		return nil
+	})
+	for _, report := range reports {
+		if report != nil {
+			res.Pads = append(res.Pads, report)
+		}
+	}
+	return res, ctxErr
+}
@@ -0,0 +1,100 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestBuildRequestMatchesSent checks that sendRequest sends exactly the
+// request built by BuildRequest.
+func TestBuildRequestMatchesSent(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		io.WriteString(w, `{"code": 0, "message": "ok", "data": null}`)
+	}))
+	defer server.Close()
+	pad := NewEtherpadLite("key")
+	pad.BaseURL = server.URL + "/api"
+	pad.BaseParams["extra"] = "base"
+
+	tests := []struct {
+		method string
+		params map[string]interface{}
+	}{
+		{"checkToken", nil},
+		{"getText", map[string]interface{}{"padID": "foo bar", "rev": 2}},
+		{"setText", map[string]interface{}{"padID": "ä&=?", "text": "line\r\nline"}},
+		{"createSession", map[string]interface{}{"groupID": "g.1", "authorID": "a.1", "validUntil": time.Unix(42, 0)}},
+		// parameters of the call override BaseParams
+		{"getHTML", map[string]interface{}{"padID": "foo", "extra": "call"}},
+	}
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.method, func(t *testing.T) {
+			built, err := pad.BuildRequest(ctx, test.method, test.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := pad.sendRequest(ctx, test.method, test.params); err != nil {
+				t.Fatal(err)
+			}
+			sent := <-received
+			if sent.Method != built.Method {
+				t.Errorf("expected HTTP method %s, got %s", built.Method, sent.Method)
+			}
+			if sent.URL.Path != built.URL.Path {
+				t.Errorf("expected path %s, got %s", built.URL.Path, sent.URL.Path)
+			}
+			if sent.URL.RawQuery != built.URL.RawQuery {
+				t.Errorf("expected query %s, got %s", built.URL.RawQuery, sent.URL.RawQuery)
+			}
+			if sent.Host != built.URL.Host {
+				t.Errorf("expected host %s, got %s", built.URL.Host, sent.Host)
+			}
+		})
+	}
+}
+
+// TestBaseParamsCollision is a regression test: a parameter that is part of
+// BaseParams and of the call must only be sent once, with the value of the
+// call.
+func TestBaseParamsCollision(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		io.WriteString(w, `{"code": 0, "message": "ok", "data": {"text": "foo"}}`)
+	}))
+	defer server.Close()
+	pad := NewEtherpadLite("key")
+	pad.BaseURL = server.URL + "/api"
+	pad.BaseParams["padID"] = "base"
+	if _, err := pad.GetText(context.Background(), "call", OptionalParam); err != nil {
+		t.Fatal(err)
+	}
+	query := (<-received).URL.Query()
+	if values := query["padID"]; len(values) != 1 || values[0] != "call" {
+		t.Errorf("expected padID to be sent once with value call, got %v", values)
+	}
+	if values := query["apikey"]; len(values) != 1 {
+		t.Errorf("expected apikey to be sent once, got %v", values)
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrMissingReturnCode is returned if a response contains a message but no
+// (or a null) code. Some proxies and forks answer like this in case of an
+// error, so it must not be interpreted as EverythingOk.
+var ErrMissingReturnCode = errors.New("response contains a message but no return code")
+
+// UnmarshalJSON decodes a return code. Etherpad returns the code as a number,
+// but some forks and proxies return it as a string (for example "0"), both
+// representations are accepted.
+// null leaves the code unchanged.
+func (c *ReturnCode) UnmarshalJSON(data []byte) error {
+	raw := strings.TrimSpace(string(data))
+	if raw == "null" {
+		return nil
+	}
+	if strings.HasPrefix(raw, `"`) {
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		raw = strings.TrimSpace(str)
+	}
+	code, err := strconv.Atoi(raw)
+	if err != nil {
+		return fmt.Errorf("invalid return code %s", string(data))
+	}
+	*c = ReturnCode(code)
+	return nil
+}
+
+// MissingFieldError is returned by the field accessors of Response (and the
+// typed wrappers) if the data object doesn't contain the requested key.
+type MissingFieldError struct {
+	Key string
+}
+
+// Error returns the error as a string.
+func (e *MissingFieldError) Error() string {
+	return fmt.Sprintf("response data has no field %q", e.Key)
+}
+
+// WrongTypeError is returned by the field accessors of Response (and the
+// typed wrappers) if a field in the data object doesn't have the expected
+// type.
+type WrongTypeError struct {
+	// Key is the key of the field in the data object.
+	Key string
+
+	// Expected describes the expected type, for example "string".
+	Expected string
+
+	// Actual is the Go type of the value found, for example "float64".
+	Actual string
+}
+
+// Error returns the error as a string.
+func (e *WrongTypeError) Error() string {
+	return fmt.Sprintf("response data field %q has type %s, expected %s", e.Key, e.Actual, e.Expected)
+}
+
+// newWrongTypeError returns a WrongTypeError for the value found.
+func newWrongTypeError(key, expected string, value interface{}) *WrongTypeError {
+	return &WrongTypeError{Key: key, Expected: expected, Actual: fmt.Sprintf("%T", value)}
+}
+
+// responseEnvelope is used to decode a Response. The code is a pointer to
+// detect a missing code.
+type responseEnvelope struct {
+	Code    *ReturnCode
+	Message string
+	Data    json.RawMessage
+}
+
+// decodeResponse decodes the body of a response returned by the API.
+// It respects pad.UseJSONNumber.
+func (pad *EtherpadLite) decodeResponse(body []byte) (*Response, error) {
+	var envelope responseEnvelope
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if pad.UseJSONNumber {
+		decoder.UseNumber()
+	}
+	if err := decoder.Decode(&envelope); err != nil {
+		return nil, err
+	}
+	res := &Response{Message: envelope.Message, rawData: envelope.Data}
+	// data should always be an object, but some (older) methods return
+	// other values, for example getAuthorName returned the name directly.
+	// In this case Data is nil but the data can still be accessed with
+	// DecodeData.
+	if res.HasData() && bytes.HasPrefix(bytes.TrimSpace(envelope.Data), []byte("{")) {
+		dataDecoder := json.NewDecoder(bytes.NewReader(envelope.Data))
+		if pad.UseJSONNumber {
+			dataDecoder.UseNumber()
+		}
+		if err := dataDecoder.Decode(&res.Data); err != nil {
+			return nil, err
+		}
+	}
+	if envelope.Code != nil {
+		res.Code = *envelope.Code
+	} else if envelope.Message != "" {
+		return nil, fmt.Errorf("%w: %q", ErrMissingReturnCode, envelope.Message)
+	}
+	return res, nil
+}
+
+// HasData returns true if the response contains a data object, i.e. data is
+// neither missing nor null.
+// Note that an empty object ({}) counts as data, Data is an empty map then.
+// If HasData returns false Data is nil.
+func (r *Response) HasData() bool {
+	if r.rawData == nil {
+		// the response was not decoded by us
+		return r.Data != nil
+	}
+	return len(r.rawData) > 0 && string(bytes.TrimSpace(r.rawData)) != "null"
+}
+
+// DecodeData decodes the data object of the response into v, the same way as
+// json.Unmarshal does.
+// The original JSON returned by the API is used, so no precision of numbers
+// is lost and no type assertions on Data are needed. If the response was not
+// decoded by this package Data is encoded to JSON again.
+//
+// For example the text returned by getText can be decoded like this:
+//
+//	var content struct {
+//		Text string `json:"text"`
+//	}
+//	err := response.DecodeData(&content)
+//
+// The sessions returned by listSessionsOfGroup (null entries are sessions that
+// have been deleted):
+//
+//	var sessions map[string]*struct {
+//		AuthorID   string `json:"authorID"`
+//		ValidUntil int64  `json:"validUntil"`
+//	}
+//	err := response.DecodeData(&sessions)
+func (r *Response) DecodeData(v interface{}) error {
+	raw := []byte(r.rawData)
+	if raw == nil {
+		var err error
+		if raw, err = json.Marshal(r.Data); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// field returns the field key from the data object.
+func (r *Response) field(key string) (interface{}, error) {
+	value, has := r.Data[key]
+	if !has {
+		return nil, &MissingFieldError{Key: key}
+	}
+	return value, nil
+}
+
+// GetString returns the string field key from the data object.
+// A MissingFieldError is returned if there is no such field (or no data at
+// all) and a WrongTypeError if the field is not a string.
+func (r *Response) GetString(key string) (string, error) {
+	value, err := r.field(key)
+	if err != nil {
+		return "", err
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", newWrongTypeError(key, "string", value)
+	}
+	return str, nil
+}
+
+// GetInt64 returns the integer field key from the data object.
+// The value may be a json.Number or float64 (see EtherpadLite.UseJSONNumber),
+// if possible the value is taken from the original JSON, so no precision is
+// lost even if Data contains float64 values.
+// A MissingFieldError is returned if there is no such field (or no data at
+// all) and a WrongTypeError if the field is not an integer.
+func (r *Response) GetInt64(key string) (int64, error) {
+	value, err := r.field(key)
+	if err != nil {
+		return 0, err
+	}
+	if r.rawData != nil {
+		var fields map[string]json.RawMessage
+		var number json.Number
+		if r.DecodeData(&fields) == nil && json.Unmarshal(fields[key], &number) == nil {
+			value = number
+		}
+	}
+	n, convErr := AsInt64(value)
+	if convErr != nil {
+		return 0, newWrongTypeError(key, "integer", value)
+	}
+	return n, nil
+}
+
+// getInt is like GetInt64 but returns an int.
+func (r *Response) getInt(key string) (int, error) {
+	n, err := r.GetInt64(key)
+	if err != nil {
+		return 0, err
+	}
+	if int64(int(n)) != n {
+		return 0, fmt.Errorf("response data field %q: integer %d overflows int", key, n)
+	}
+	return int(n), nil
+}
+
+// GetBool returns the boolean field key from the data object.
+// A MissingFieldError is returned if there is no such field (or no data at
+// all) and a WrongTypeError if the field is not a boolean.
+func (r *Response) GetBool(key string) (bool, error) {
+	value, err := r.field(key)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, newWrongTypeError(key, "bool", value)
+	}
+	return b, nil
+}
+
+// GetStringSlice returns the field key, which must be a list of strings, from
+// the data object. null is treated as an empty list, the result is never nil.
+// A MissingFieldError is returned if there is no such field (or no data at
+// all) and a WrongTypeError if the field is not a list or an entry is not a
+// string, the key of the error contains the index of the entry then (for
+// example "padIDs[2]").
+func (r *Response) GetStringSlice(key string) ([]string, error) {
+	value, err := r.field(key)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return []string{}, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, newWrongTypeError(key, "[]string", value)
+	}
+	res := make([]string, len(list))
+	for i, entry := range list {
+		str, ok := entry.(string)
+		if !ok {
+			return nil, newWrongTypeError(fmt.Sprintf("%s[%d]", key, i), "string", entry)
+		}
+		res[i] = str
+	}
+	return res, nil
+}
@@ -0,0 +1,238 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeReturnCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		code    ReturnCode
+		wantErr bool
+		missing bool
+	}{
+		{"number", `{"code": 0, "message": "ok", "data": null}`, EverythingOk, false, false},
+		{"error number", `{"code": 1, "message": "padID does not exist", "data": null}`, WrongParameters, false, false},
+		{"string", `{"code": "0", "message": "ok", "data": null}`, EverythingOk, false, false},
+		{"error string", `{"code": "4", "message": "no or wrong API Key", "data": null}`, WrongAPIKey, false, false},
+		{"string with spaces", `{"code": " 2 ", "message": "internal error"}`, InternalError, false, false},
+		{"null without message", `{"code": null, "message": "", "data": {}}`, EverythingOk, false, false},
+		{"null with message", `{"code": null, "message": "bad gateway"}`, 0, true, true},
+		{"missing without message", `{"data": {"text": "foo"}}`, EverythingOk, false, false},
+		{"missing with message", `{"message": "bad gateway"}`, 0, true, true},
+		{"invalid string", `{"code": "zero", "message": "ok"}`, 0, true, false},
+		{"boolean", `{"code": true, "message": "ok"}`, 0, true, false},
+		{"float", `{"code": 1.5, "message": "ok"}`, 0, true, false},
+	}
+	pad := NewEtherpadLite("key")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := pad.DecodeResponse(strings.NewReader(test.body))
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got code %v", res.Code)
+				}
+				if got := errors.Is(err, ErrMissingReturnCode); got != test.missing {
+					t.Errorf("expected errors.Is(err, ErrMissingReturnCode) to be %v, got %v (%v)", test.missing, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Code != test.code {
+				t.Errorf("expected code %v, got %v", test.code, res.Code)
+			}
+		})
+	}
+}
+
+func TestResponseData(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		hasData bool
+		// size is the number of entries in Data, -1 if Data should be nil
+		size int
+	}{
+		{"null", `{"code": 0, "message": "ok", "data": null}`, false, -1},
+		{"missing", `{"code": 0, "message": "ok"}`, false, -1},
+		{"empty object", `{"code": 0, "message": "ok", "data": {}}`, true, 0},
+		{"populated", `{"code": 0, "message": "ok", "data": {"padID": "foo", "rev": 1}}`, true, 2},
+		// not an object, only accessible with DecodeData
+		{"string", `{"code": 0, "message": "ok", "data": "name"}`, true, -1},
+		{"list", `{"code": 0, "message": "ok", "data": ["a"]}`, true, -1},
+	}
+	pad := NewEtherpadLite("key")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := pad.DecodeResponse(strings.NewReader(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := res.HasData(); got != test.hasData {
+				t.Errorf("expected HasData() to be %v, got %v", test.hasData, got)
+			}
+			switch {
+			case test.size < 0 && res.Data != nil:
+				t.Errorf("expected Data to be nil, got %v", res.Data)
+			case test.size >= 0 && res.Data == nil:
+				t.Error("expected Data not to be nil")
+			case test.size >= 0 && len(res.Data) != test.size:
+				t.Errorf("expected %d entries in Data, got %v", test.size, res.Data)
+			}
+			// must not panic on nil data
+			padID, err := res.GetString("padID")
+			if test.size > 0 {
+				if err != nil || padID != "foo" {
+					t.Errorf("expected padID foo, got %q (%v)", padID, err)
+				}
+			} else {
+				var missing *MissingFieldError
+				if !errors.As(err, &missing) {
+					t.Errorf("expected a MissingFieldError, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestResponseDecodeData(t *testing.T) {
+	pad := NewEtherpadLite("key")
+	res, err := pad.DecodeResponse(strings.NewReader(`{"code": 0, "message": "ok", "data": "name"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var name string
+	if err := res.DecodeData(&name); err != nil {
+		t.Fatal(err)
+	}
+	if name != "name" {
+		t.Errorf("expected name, got %q", name)
+	}
+	// responses not decoded from JSON encode Data again
+	res = &Response{Data: map[string]interface{}{"text": "foo"}}
+	var content struct {
+		Text string `json:"text"`
+	}
+	if err := res.DecodeData(&content); err != nil {
+		t.Fatal(err)
+	}
+	if content.Text != "foo" {
+		t.Errorf("expected text foo, got %q", content.Text)
+	}
+	if !res.HasData() {
+		t.Error("expected HasData() to be true for a response with Data")
+	}
+}
+
+func TestResponseAccessors(t *testing.T) {
+	const body = `{"code": 0, "message": "ok", "data": {
+		"str": "foo", "int": 42, "big": 9007199254740993, "frac": 1.5, "numstr": "12",
+		"bool": true, "list": ["a", "b"], "empty": [], "null": null, "mixed": ["a", 1]
+	}}`
+	pad := NewEtherpadLite("key")
+	res, err := pad.DecodeResponse(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	noData, err := pad.DecodeResponse(strings.NewReader(`{"code": 0, "message": "ok", "data": null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a response not decoded by this package, numbers are float64
+	constructed := &Response{Data: map[string]interface{}{"int": float64(7)}}
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+	str := func(r *Response, key string) result { v, err := r.GetString(key); return result{v, err} }
+	i64 := func(r *Response, key string) result { v, err := r.GetInt64(key); return result{v, err} }
+	boolean := func(r *Response, key string) result { v, err := r.GetBool(key); return result{v, err} }
+	slice := func(r *Response, key string) result { v, err := r.GetStringSlice(key); return result{v, err} }
+
+	tests := []struct {
+		name     string
+		get      func(r *Response, key string) result
+		res      *Response
+		key      string
+		expected interface{}
+		// missing is true if a MissingFieldError is expected, errKey and
+		// actual describe an expected WrongTypeError
+		missing bool
+		errKey  string
+		actual  string
+	}{
+		{"string", str, res, "str", "foo", false, "", ""},
+		{"string no data", str, noData, "str", nil, true, "", ""},
+		{"string missing", str, res, "other", nil, true, "", ""},
+		{"string wrong type", str, res, "int", nil, false, "int", "float64"},
+		{"string null", str, res, "null", nil, false, "null", "<nil>"},
+		{"int64", i64, res, "int", int64(42), false, "", ""},
+		{"int64 above 2^53", i64, res, "big", int64(9007199254740993), false, "", ""},
+		{"int64 string", i64, res, "numstr", int64(12), false, "", ""},
+		{"int64 constructed", i64, constructed, "int", int64(7), false, "", ""},
+		{"int64 no data", i64, noData, "int", nil, true, "", ""},
+		{"int64 missing", i64, res, "other", nil, true, "", ""},
+		{"int64 fraction", i64, res, "frac", nil, false, "frac", "json.Number"},
+		{"int64 wrong type", i64, res, "str", nil, false, "str", "string"},
+		{"int64 bool", i64, res, "bool", nil, false, "bool", "bool"},
+		{"bool", boolean, res, "bool", true, false, "", ""},
+		{"bool no data", boolean, noData, "bool", nil, true, "", ""},
+		{"bool missing", boolean, res, "other", nil, true, "", ""},
+		{"bool wrong type", boolean, res, "str", nil, false, "str", "string"},
+		{"slice", slice, res, "list", []string{"a", "b"}, false, "", ""},
+		{"slice empty", slice, res, "empty", []string{}, false, "", ""},
+		{"slice null", slice, res, "null", []string{}, false, "", ""},
+		{"slice no data", slice, noData, "list", nil, true, "", ""},
+		{"slice missing", slice, res, "other", nil, true, "", ""},
+		{"slice wrong type", slice, res, "str", nil, false, "str", "string"},
+		{"slice wrong entry", slice, res, "mixed", nil, false, "mixed[1]", "float64"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.get(test.res, test.key)
+			var missing *MissingFieldError
+			var wrongType *WrongTypeError
+			switch {
+			case test.missing:
+				if !errors.As(got.err, &missing) || missing.Key != test.key {
+					t.Errorf("expected a MissingFieldError for %s, got %v", test.key, got.err)
+				}
+			case test.errKey != "":
+				if !errors.As(got.err, &wrongType) {
+					t.Fatalf("expected a WrongTypeError, got %v", got.err)
+				}
+				if wrongType.Key != test.errKey || wrongType.Actual != test.actual {
+					t.Errorf("expected key %s and type %s, got %+v", test.errKey, test.actual, wrongType)
+				}
+				if msg := wrongType.Error(); !strings.Contains(msg, test.errKey) || !strings.Contains(msg, test.actual) {
+					t.Errorf("expected error message to contain key and type, got %s", msg)
+				}
+			case got.err != nil:
+				t.Fatal(got.err)
+			case !reflect.DeepEqual(got.value, test.expected):
+				t.Errorf("expected %#v, got %#v", test.expected, got.value)
+			}
+		})
+	}
+}
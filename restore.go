@@ -0,0 +1,315 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ConflictPolicy describes what RestorePads does if a pad already exists.
+type ConflictPolicy int
+
+const (
+	// ConflictSkip keeps existing pads unchanged.
+	ConflictSkip ConflictPolicy = iota
+
+	// ConflictOverwrite replaces the content of existing pads. For backups in
+	// the ExportEtherpad format the existing pad is deleted first because
+	// etherpad only imports them into empty pads.
+	ConflictOverwrite
+
+	// ConflictFail reports existing pads as failed (with an error matching
+	// ErrPadExists).
+	ConflictFail
+)
+
+// RestoreOptions are the options for RestorePads.
+type RestoreOptions struct {
+	// Conflict describes what to do with pads that already exist, it defaults
+	// to ConflictSkip.
+	Conflict ConflictPolicy
+
+	// IDPrefix is prepended to the pad IDs from the backup to restore the
+	// pads into a different namespace. It is ignored if Rename is set.
+	IDPrefix string
+
+	// Rename, if not nil, maps a pad ID from the backup to the ID of the
+	// restored pad.
+	Rename func(padID string) string
+
+	// StateFile, if not empty, is a file that records the IDs (from the
+	// backup) of the restored pads, one per line. Pads recorded in this file
+	// are skipped, so an interrupted restore can be resumed by running it
+	// again with the same file.
+	StateFile string
+
+	// Concurrency is the maximal number of pads restored concurrently,
+	// DefaultConcurrency if <= 0.
+	Concurrency int
+}
+
+// targetID returns the ID of the restored pad.
+func (opts *RestoreOptions) targetID(padID string) string {
+	if opts.Rename != nil {
+		return opts.Rename(padID)
+	}
+	return opts.IDPrefix + padID
+}
+
+// RestoreReport is returned by RestorePads, all pads are identified by their
+// ID in the backup.
+type RestoreReport struct {
+	// Restored contains the pads that were restored.
+	Restored []string
+
+	// Skipped contains the pads that were skipped, either because they
+	// already existed or because they were recorded in the state file.
+	Skipped []string
+
+	// Failed maps the pads that could not be restored to the error.
+	Failed map[string]error
+}
+
+// backupFiles returns the files of a backup.
+type backupFiles func(name string) ([]byte, error)
+
+// openBackup opens a backup written by BackupAllPads: If src is a directory it
+// is read directly, otherwise src must be a gzip compressed tar archive which
+// is read into memory.
+func openBackup(src string) (backupFiles, error) {
+	stat, err := os.Stat(src)
+	if err != nil {
+		return nil, err
+	}
+	if stat.IsDir() {
+		return func(name string) ([]byte, error) {
+			return os.ReadFile(filepath.Join(src, filepath.FromSlash(name)))
+		}, nil
+	}
+	file, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(header.Name)] = data
+	}
+	return func(name string) ([]byte, error) {
+		data, has := files[path.Clean(name)]
+		if !has {
+			return nil, fmt.Errorf("file %s not found in backup %s", name, src)
+		}
+		return data, nil
+	}, nil
+}
+
+// restoreState records the restored pads in RestoreOptions.StateFile.
+type restoreState struct {
+	mutex sync.Mutex
+	done  map[string]bool
+	file  *os.File
+}
+
+// openRestoreState reads the state file (if it exists) and opens it for
+// appending. name may be empty, in this case nothing is recorded.
+func openRestoreState(name string) (*restoreState, error) {
+	state := &restoreState{done: make(map[string]bool)}
+	if name == "" {
+		return state, nil
+	}
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			state.done[line] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	state.file = file
+	return state, nil
+}
+
+func (s *restoreState) isDone(padID string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.done[padID]
+}
+
+func (s *restoreState) markDone(padID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.done[padID] = true
+	if s.file == nil {
+		return nil
+	}
+	_, err := fmt.Fprintln(s.file, padID)
+	return err
+}
+
+func (s *restoreState) close() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// errSkipped is returned by restorePad if the pad was skipped.
+var errSkipped = errors.New("skipped")
+
+// restorePad restores a single pad.
+func (pad *EtherpadLite) restorePad(ctx context.Context, files backupFiles, format ExportFormat, entry *BackupEntry, opts *RestoreOptions) error {
+	content, err := files(entry.File)
+	if err != nil {
+		return err
+	}
+	target := opts.targetID(entry.PadID)
+	exists, err := pad.PadExists(ctx, target)
+	if err != nil {
+		return err
+	}
+	if exists {
+		switch opts.Conflict {
+		case ConflictSkip:
+			return errSkipped
+		case ConflictFail:
+			return fmt.Errorf("%w: %s", ErrPadExists, target)
+		}
+	}
+	switch format {
+	case ExportText:
+		if !exists {
+			if _, err := pad.callChecked(ctx, "createPad", map[string]interface{}{"padID": target}); err != nil {
+				return err
+			}
+		}
+		_, err = pad.callChecked(ctx, "setText", map[string]interface{}{"padID": target, "text": string(content)})
+		return err
+	case ExportEtherpad:
+		if exists {
+			if _, err := pad.callChecked(ctx, "deletePad", map[string]interface{}{"padID": target}); err != nil {
+				return err
+			}
+		}
+	}
+	return pad.ImportPad(ctx, target, "pad."+string(format), bytes.NewReader(content))
+}
+
+// RestorePads restores the pads from a backup written by BackupAllPads (a
+// directory or a gzip compressed tar archive, which is read into memory).
+// Text backups are restored with createPad and setText, all other formats
+// with the import endpoint (see ImportPad). Chat histories are not restored.
+//
+// Pads that can't be restored are reported in RestoreReport.Failed and don't
+// abort the restore. An error is returned if the backup can't be read, the
+// state file can't be written or ctx is cancelled.
+func (pad *EtherpadLite) RestorePads(ctx context.Context, src string, opts RestoreOptions) (*RestoreReport, error) {
+	files, err := openBackup(src)
+	if err != nil {
+		return nil, err
+	}
+	data, err := files(BackupManifestName)
+	if err != nil {
+		return nil, err
+	}
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid backup manifest: %w", err)
+	}
+	if manifest.Format == "" {
+		manifest.Format = ExportText
+	}
+	state, err := openRestoreState(opts.StateFile)
+	if err != nil {
+		return nil, err
+	}
+	report := &RestoreReport{Restored: []string{}, Skipped: []string{}, Failed: make(map[string]error)}
+	var (
+		mutex    sync.Mutex
+		stateErr error
+	)
+	ctxErr := forEach(ctx, len(manifest.Pads), opts.Concurrency, func(ctx context.Context, i int) error {
+		entry := &manifest.Pads[i]
+		err := errSkipped
+		if !state.isDone(entry.PadID) {
+			err = pad.restorePad(ctx, files, manifest.Format, entry, &opts)
+		}
+		if err == nil {
+			if markErr := state.markDone(entry.PadID); markErr != nil {
+				mutex.Lock()
+				stateErr = markErr
+				mutex.Unlock()
+				return markErr
+			}
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch err {
+		case nil:
+			report.Restored = append(report.Restored, entry.PadID)
+		case errSkipped:
+			report.Skipped = append(report.Skipped, entry.PadID)
+		default:
+			report.Failed[entry.PadID] = err
+		}
+		return nil
+	})
+	if closeErr := state.close(); stateErr == nil {
+		stateErr = closeErr
+	}
+	if stateErr != nil {
+		return report, fmt.Errorf("writing restore state %s: %w", opts.StateFile, stateErr)
+	}
+	return report, ctxErr
+}
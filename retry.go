@@ -0,0 +1,136 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultRetryAfter is the time we wait before retrying a request that was
+// answered with 429 but without a (valid) Retry-After header.
+const defaultRetryAfter = time.Second
+
+// RateLimitedError is returned if the server answered with 429 Too Many
+// Requests (or 503 Service Unavailable with a Retry-After header) and all
+// retries as configured by EtherpadLite.MaxRetries are exhausted.
+type RateLimitedError struct {
+	// StatusCode is the HTTP status code of the last response.
+	StatusCode int
+
+	// RetryAfter is the duration the server asked us to wait in the last
+	// response.
+	RetryAfter time.Duration
+
+	// Attempts is the number of requests that were sent.
+	Attempts int
+}
+
+// Error returns the error as a string.
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limited by server (HTTP %d), retry after %v, gave up after %d attempt(s)",
+		e.StatusCode, e.RetryAfter, e.Attempts)
+}
+
+// parseRetryAfter parses the value of a Retry-After header.
+// Both forms are supported: delta-seconds and an HTTP-date.
+// now is used to compute the duration for an HTTP-date, dates in the past
+// result in a duration of 0.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	wait := date.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	return wait, true
+}
+
+// rateLimited checks if resp signals that we should back off and retry
+// later. If so it returns the duration to wait.
+// 429 is always considered rate limiting, 503 only if a Retry-After header
+// is present (otherwise it's just an unavailable server).
+func rateLimited(resp *http.Response, now time.Time) (time.Duration, bool) {
+	wait, hasHeader := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		if !hasHeader {
+			wait = defaultRetryAfter
+		}
+		return wait, true
+	case http.StatusServiceUnavailable:
+		return wait, hasHeader
+	default:
+		return 0, false
+	}
+}
+
+// doRetry sends req with the client of pad and handles rate limiting as
+// described in EtherpadLite.MaxRetries.
+// If an error is returned the response is always nil, otherwise the caller
+// must close the body of the response.
+func (pad *EtherpadLite) doRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			// the body was consumed by the previous attempt
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		resp, err := pad.Client.Do(req)
+		if err != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, redactError(err)
+		}
+		now := pad.clock().Now()
+		wait, limited := rateLimited(resp, now)
+		if !limited {
+			return resp, nil
+		}
+		resp.Body.Close()
+		limitErr := &RateLimitedError{StatusCode: resp.StatusCode, RetryAfter: wait, Attempts: attempt}
+		if attempt > pad.MaxRetries {
+			return nil, limitErr
+		}
+		// don't wait if we know that the context expires before we're allowed
+		// to try again
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline && now.Add(wait).After(deadline) {
+			return nil, limitErr
+		}
+		if sleepErr := sleepContext(ctx, pad.clock(), wait); sleepErr != nil {
+			return nil, sleepErr
+		}
+	}
+}
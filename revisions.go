@@ -0,0 +1,161 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidRevisionRange is returned by RevisionTexts if the revision range
+// is not within [0, head].
+var ErrInvalidRevisionRange = errors.New("invalid revision range")
+
+// revisionResult is the text of a revision fetched by a RevisionIterator.
+type revisionResult struct {
+	text string
+	err  error
+}
+
+// RevisionIterator iterates over the texts of a range of revisions in order,
+// see RevisionTexts.
+type RevisionIterator struct {
+	cancel  context.CancelFunc
+	pending chan chan revisionResult
+	sem     chan struct{}
+	rev     int
+	text    string
+	err     error
+}
+
+// RevisionTexts returns an iterator over the texts of the revisions fromRev
+// to toRev (both inclusive) of a pad. The texts are fetched with up to
+// DefaultConcurrency concurrent requests but returned strictly in order.
+// The range is validated with getRevisionsCount first, if it is not within
+// [0, head] an error matching ErrInvalidRevisionRange is returned.
+//
+// The iterator must be closed if it is not read until the end. Use it like
+// this:
+//
+//	it, err := pad.RevisionTexts(ctx, padID, 0, 10)
+//	if err != nil {
+//		...
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		fmt.Println(it.Revision(), it.Text())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+func (pad *EtherpadLite) RevisionTexts(ctx context.Context, padID string, fromRev, toRev int) (*RevisionIterator, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	head, err := pad.RevisionsCount(ctx, padID)
+	if err != nil {
+		return nil, err
+	}
+	if fromRev < 0 || fromRev > toRev || toRev > head {
+		return nil, fmt.Errorf("%w: [%d, %d] not within [0, %d]", ErrInvalidRevisionRange, fromRev, toRev, head)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	it := &RevisionIterator{
+		cancel:  cancel,
+		pending: make(chan chan revisionResult, DefaultConcurrency),
+		sem:     make(chan struct{}, DefaultConcurrency),
+		rev:     fromRev - 1,
+	}
+	go func() {
+		defer close(it.pending)
+		for rev := fromRev; rev <= toRev; rev++ {
+			// at most DefaultConcurrency revisions are fetched or waiting to
+			// be read
+			select {
+			case it.sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			result := make(chan revisionResult, 1)
+			go func(rev int) {
+				text, err := pad.GetTextContent(ctx, padID, rev)
+				result <- revisionResult{text: text, err: err}
+			}(rev)
+			it.pending <- result
+		}
+	}()
+	return it, nil
+}
+
+// Next advances to the next revision, it returns false if all revisions were
+// read or an error occurred (see Err).
+func (it *RevisionIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	result, ok := <-it.pending
+	if !ok {
+		it.Close()
+		return false
+	}
+	res := <-result
+	<-it.sem
+	if res.err != nil {
+		it.err = res.err
+		it.Close()
+		return false
+	}
+	it.rev++
+	it.text = res.text
+	return true
+}
+
+// Revision returns the current revision.
+func (it *RevisionIterator) Revision() int {
+	return it.rev
+}
+
+// Text returns the text of the current revision.
+func (it *RevisionIterator) Text() string {
+	return it.text
+}
+
+// Err returns the error that stopped the iteration.
+func (it *RevisionIterator) Err() error {
+	return it.err
+}
+
+// Close stops fetching revisions, it is safe to call Close multiple times.
+func (it *RevisionIterator) Close() {
+	it.cancel()
+}
+
+// ForEachRevision calls fn with the text of each revision from fromRev to
+// toRev (both inclusive) in order, see RevisionTexts. It stops at the first
+// error returned by fn and returns it.
+func (pad *EtherpadLite) ForEachRevision(ctx context.Context, padID string, fromRev, toRev int, fn func(rev int, text string) error) error {
+	it, err := pad.RevisionTexts(ctx, padID, fromRev, toRev)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.Next() {
+		if err := fn(it.Revision(), it.Text()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
@@ -0,0 +1,109 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRevisionTextsOrder(t *testing.T) {
+	pad := slowServer(t, 0)
+	var revs []int
+	err := pad.ForEachRevision(context.Background(), "pad", 3, 40, func(rev int, text string) error {
+		if expected := fmt.Sprintf("pad@%d", rev); text != expected {
+			t.Errorf("expected text %q for revision %d, got %q", expected, rev, text)
+		}
+		revs = append(revs, rev)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, rev := range revs {
+		if rev != 3+i {
+			t.Fatalf("expected revisions 3 to 40 in order, got %v", revs)
+		}
+	}
+	if len(revs) != 38 {
+		t.Errorf("expected 38 revisions, got %d", len(revs))
+	}
+	_, err = pad.RevisionTexts(context.Background(), "pad", 0, 1001)
+	if !errors.Is(err, ErrInvalidRevisionRange) {
+		t.Errorf("expected ErrInvalidRevisionRange, got %v", err)
+	}
+}
+
+func TestRevisionTextsNilContext(t *testing.T) {
+	pad := slowServer(t, 0)
+	it, err := pad.RevisionTexts(nil, "pad", 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 revisions, got %d", n)
+	}
+	err = pad.ForEachRevision(nil, "pad", 0, 0, func(rev int, text string) error {
+		return nil
+	})
+	if err != nil {
+		t.Errorf("ForEachRevision with a nil context: %v", err)
+	}
+}
+
+// BenchmarkRevisionTexts compares fetching 100 revisions with RevisionTexts
+// (limited to different numbers of concurrent requests by
+// MaxConcurrentRequests) against sequential getText calls, the server has a
+// latency of 1ms.
+func BenchmarkRevisionTexts(b *testing.B) {
+	const revisions = 100
+	ctx := context.Background()
+	b.Run("sequential", func(b *testing.B) {
+		pad := slowServer(b, benchmarkLatency)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for rev := 0; rev < revisions; rev++ {
+				if _, err := pad.GetTextContent(ctx, "pad", rev); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	for _, concurrency := range []int{1, 2, 4, DefaultConcurrency} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			pad := slowServer(b, benchmarkLatency)
+			pad.MaxConcurrentRequests = concurrency
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				err := pad.ForEachRevision(ctx, "pad", 0, revisions-1, func(rev int, text string) error {
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrValidUntilMilliseconds is returned by CreateSession if validUntil looks
+// like milliseconds since the Unix epoch instead of seconds.
+var ErrValidUntilMilliseconds = errors.New("validUntil looks like milliseconds, etherpad expects seconds since the Unix epoch")
+
+// ErrInvalidDuration is returned by CreateSessionFor if the duration is not
+// positive.
+var ErrInvalidDuration = errors.New("session duration must be positive")
+
+// millisecondsDigits is the number of digits of the current time in
+// milliseconds since the Unix epoch (seconds have 10 digits until the year
+// 2286).
+const millisecondsDigits = 13
+
+// checkValidUntil returns ErrValidUntilMilliseconds if validUntil is a number
+// (or a string of digits) with at least 13 digits. time.Time and
+// time.Duration are encoded according to EtherpadLite.TimeEncoding and are
+// not checked.
+func checkValidUntil(validUntil interface{}) error {
+	switch validUntil.(type) {
+	case time.Time, time.Duration:
+		return nil
+	}
+	if isMissing(validUntil) {
+		return nil
+	}
+	encoded, err := encodeParam(validUntil, UnixSeconds)
+	if err != nil {
+		return nil
+	}
+	digits := strings.TrimPrefix(encoded, "-")
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		digits = digits[:i]
+	}
+	if len(digits) < millisecondsDigits {
+		return nil
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: got %s", ErrValidUntilMilliseconds, encoded)
+}
+
+// Session is a session of an author in a group.
+type Session struct {
+	ID         string
+	GroupID    string
+	AuthorID   string
+	ValidUntil time.Time
+}
+
+// sessionInfo is the JSON representation of a session as returned by
+// getSessionInfo.
+type sessionInfo struct {
+	GroupID    string      `json:"groupID"`
+	AuthorID   string      `json:"authorID"`
+	ValidUntil json.Number `json:"validUntil"`
+}
+
+// toSession converts the info to a Session with the given ID.
+// validUntil is given in seconds since the Unix epoch.
+func (info *sessionInfo) toSession(id string) (*Session, error) {
+	session := &Session{ID: id, GroupID: info.GroupID, AuthorID: info.AuthorID}
+	if info.ValidUntil != "" {
+		seconds, err := AsInt64(info.ValidUntil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid validUntil of session %s: %w", id, err)
+		}
+		session.ValidUntil = time.Unix(seconds, 0)
+	}
+	return session, nil
+}
+
+// CreateSessionTyped creates a new session for the author in the group that
+// is valid until validUntil (sent as Unix seconds, independent of
+// EtherpadLite.TimeEncoding).
+func (pad *EtherpadLite) CreateSessionTyped(ctx context.Context, groupID, authorID string, validUntil time.Time) (*Session, error) {
+	resp, err := pad.callChecked(ctx, "createSession", map[string]interface{}{
+		"groupID":    groupID,
+		"authorID":   authorID,
+		"validUntil": validUntil.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	id, err := resp.GetString("sessionID")
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		ID:         id,
+		GroupID:    groupID,
+		AuthorID:   authorID,
+		ValidUntil: time.Unix(validUntil.Unix(), 0),
+	}, nil
+}
+
+// CreateSessionFor creates a new session for the author in the group that is
+// valid for the duration d from now on. d must be positive.
+func (pad *EtherpadLite) CreateSessionFor(ctx context.Context, groupID, authorID string, d time.Duration) (*Session, error) {
+	if d <= 0 {
+		return nil, fmt.Errorf("%w: got %s", ErrInvalidDuration, d)
+	}
+	return pad.CreateSessionTyped(ctx, groupID, authorID, pad.clock().Now().Add(d))
+}
+
+// GetSession returns the session with the given ID.
+func (pad *EtherpadLite) GetSession(ctx context.Context, sessionID string) (*Session, error) {
+	info, _, err := Do[sessionInfo](pad, ctx, "getSessionInfo", map[string]interface{}{"sessionID": sessionID})
+	if err != nil {
+		return nil, err
+	}
+	return info.toSession(sessionID)
+}
+
+// listSessions calls method (listSessionsOfGroup or listSessionsOfAuthor) and
+// decodes the sessions. Etherpad returns null for sessions that were deleted,
+// these entries are skipped.
+func (pad *EtherpadLite) listSessions(ctx context.Context, method string, params map[string]interface{}) (map[string]Session, error) {
+	resp, err := pad.callChecked(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+	var infos map[string]*sessionInfo
+	if err := resp.DecodeData(&infos); err != nil {
+		return nil, err
+	}
+	res := make(map[string]Session, len(infos))
+	for id, info := range infos {
+		if info == nil {
+			continue
+		}
+		session, err := info.toSession(id)
+		if err != nil {
+			return nil, err
+		}
+		res[id] = *session
+	}
+	return res, nil
+}
+
+// ListGroupSessions returns all sessions of a group, mapping the session ID to
+// the session.
+func (pad *EtherpadLite) ListGroupSessions(ctx context.Context, groupID string) (map[string]Session, error) {
+	return pad.listSessions(ctx, "listSessionsOfGroup", map[string]interface{}{"groupID": groupID})
+}
+
+// ListAuthorSessions returns all sessions of an author, mapping the session ID
+// to the session.
+func (pad *EtherpadLite) ListAuthorSessions(ctx context.Context, authorID string) (map[string]Session, error) {
+	return pad.listSessions(ctx, "listSessionsOfAuthor", map[string]interface{}{"authorID": authorID})
+}
@@ -0,0 +1,98 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/mock"
+)
+
+func TestListSessionsNullEntries(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	// etherpad returns null for sessions that were deleted
+	sessions := map[string]interface{}{
+		"s.1": map[string]interface{}{"groupID": "g.1", "authorID": "a.1", "validUntil": 1551675967},
+		"s.2": nil,
+	}
+	for _, method := range []string{"listSessionsOfGroup", "listSessionsOfAuthor"} {
+		server.Store.QueueResponse(method, mock.CannedResponse{Data: sessions})
+	}
+	list := map[string]func() (map[string]etherpadlite.Session, error){
+		"group":  func() (map[string]etherpadlite.Session, error) { return pad.ListGroupSessions(ctx, "g.1") },
+		"author": func() (map[string]etherpadlite.Session, error) { return pad.ListAuthorSessions(ctx, "a.1") },
+	}
+	for name, fn := range list {
+		res, err := fn()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(res) != 1 {
+			t.Errorf("%s: expected only session s.1, got %v", name, res)
+		}
+		session, has := res["s.1"]
+		if !has {
+			t.Fatalf("%s: session s.1 is missing", name)
+		}
+		expected := etherpadlite.Session{ID: "s.1", GroupID: "g.1", AuthorID: "a.1", ValidUntil: time.Unix(1551675967, 0)}
+		if session != expected {
+			t.Errorf("%s: expected %+v, got %+v", name, expected, session)
+		}
+	}
+}
+
+func TestListSessionsNullData(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	// groups without sessions return null instead of an empty object
+	server.Store.QueueResponse("listSessionsOfGroup", mock.CannedResponse{})
+	res, err := pad.ListGroupSessions(ctx, "g.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res == nil || len(res) != 0 {
+		t.Errorf("expected an empty map, got %#v", res)
+	}
+}
+
+func TestListSessionsDeleted(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	groupID := server.Store.AddGroup("group")
+	authorID := server.Store.AddAuthor("author", "Alice")
+	validUntil := time.Now().Add(time.Hour)
+	keep, err := server.Store.AddSession(groupID, authorID, validUntil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remove, err := server.Store.AddSession(groupID, authorID, validUntil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pad.DeleteSession(ctx, remove); err != nil {
+		t.Fatal(err)
+	}
+	res, err := pad.ListGroupSessions(ctx, groupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, has := res[keep]; !has || len(res) != 1 {
+		t.Errorf("expected only session %s, got %v", keep, res)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DeleteSessionsError is returned by the helpers that delete multiple
+// sessions if some of the sessions could not be deleted.
+type DeleteSessionsError struct {
+	// Failed maps the IDs of the sessions that could not be deleted to the
+	// error.
+	Failed map[string]error
+
+	// Err is set if the deletion was aborted, for example because the context
+	// was cancelled. Sessions that were not processed are not in Failed.
+	Err error
+}
+
+// Error returns the error as a string.
+func (e *DeleteSessionsError) Error() string {
+	return fmt.Sprintf("failed to delete %d session(s): %s", len(e.Failed), joinFailures(e.Err, e.Failed))
+}
+
+// Unwrap returns Err.
+func (e *DeleteSessionsError) Unwrap() error {
+	return e.Err
+}
+
+// deleteEach calls method (for example deleteSession) for all ids, passing
+// the ID as parameter param, using at most workers concurrent requests.
+// It returns the number of successful calls, the errors of the failed calls
+// and ctx.Err() if ctx was cancelled.
+func (pad *EtherpadLite) deleteEach(ctx context.Context, method, param string, ids []string, workers int) (int, map[string]error, error) {
+	var (
+		mutex   sync.Mutex
+		deleted int
+		failed  = make(map[string]error)
+	)
+	err := forEach(ctx, len(ids), workers, func(ctx context.Context, i int) error {
+		_, err := pad.callChecked(ctx, method, map[string]interface{}{param: ids[i]})
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil {
+			failed[ids[i]] = err
+		} else {
+			deleted++
+		}
+		return nil
+	})
+	return deleted, failed, err
+}
+
+// deleteSessions deletes the sessions with the given IDs using at most
+// workers concurrent requests. It returns the number of deleted sessions and
+// a *DeleteSessionsError if a deletion failed or ctx was cancelled.
+func (pad *EtherpadLite) deleteSessions(ctx context.Context, ids []string, workers int) (int, error) {
+	deleted, failed, err := pad.deleteEach(ctx, "deleteSession", "sessionID", ids, workers)
+	if err != nil || len(failed) > 0 {
+		return deleted, &DeleteSessionsError{Failed: failed, Err: err}
+	}
+	return deleted, nil
+}
+
+// CleanupOptions are the options for CleanupExpiredSessions.
+type CleanupOptions struct {
+	// DryRun only counts the expired sessions without deleting them.
+	DryRun bool
+
+	// Concurrency is the maximal number of concurrent delete requests,
+	// DefaultConcurrency if <= 0.
+	Concurrency int
+}
+
+// cleanupSessions deletes the sessions that expired before before.
+func (pad *EtherpadLite) cleanupSessions(ctx context.Context, sessions map[string]Session, before time.Time, opts []CleanupOptions) (int, error) {
+	var options CleanupOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	var expired []string
+	for id, session := range sessions {
+		if !session.ValidUntil.IsZero() && session.ValidUntil.Before(before) {
+			expired = append(expired, id)
+		}
+	}
+	if options.DryRun {
+		return len(expired), nil
+	}
+	return pad.deleteSessions(ctx, expired, options.Concurrency)
+}
+
+// CleanupExpiredSessions deletes all sessions of the group that expired
+// before the given time (usually time.Now()), etherpad never deletes expired
+// sessions itself.
+// It returns the number of deleted sessions (with DryRun the number of
+// expired sessions). If some sessions could not be deleted a
+// *DeleteSessionsError is returned.
+func (pad *EtherpadLite) CleanupExpiredSessions(ctx context.Context, groupID string, before time.Time, opts ...CleanupOptions) (int, error) {
+	sessions, err := pad.ListGroupSessions(ctx, groupID)
+	if err != nil {
+		return 0, err
+	}
+	return pad.cleanupSessions(ctx, sessions, before, opts)
+}
+
+// CleanupExpiredAuthorSessions is like CleanupExpiredSessions but deletes the
+// expired sessions of an author.
+func (pad *EtherpadLite) CleanupExpiredAuthorSessions(ctx context.Context, authorID string, before time.Time, opts ...CleanupOptions) (int, error) {
+	sessions, err := pad.ListAuthorSessions(ctx, authorID)
+	if err != nil {
+		return 0, err
+	}
+	return pad.cleanupSessions(ctx, sessions, before, opts)
+}
+
+// sessionIDs returns the IDs of the sessions.
+func sessionIDs(sessions map[string]Session) []string {
+	ids := make([]string, 0, len(sessions))
+	for id := range sessions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// DeleteAllSessionsOfAuthor deletes all sessions of the author, for example
+// to revoke the access of a user.
+// The number of deleted sessions is returned even if some sessions could not
+// be deleted, in this case (and if ctx is cancelled) a *DeleteSessionsError
+// is returned.
+func (pad *EtherpadLite) DeleteAllSessionsOfAuthor(ctx context.Context, authorID string) (int, error) {
+	sessions, err := pad.ListAuthorSessions(ctx, authorID)
+	if err != nil {
+		return 0, err
+	}
+	return pad.deleteSessions(ctx, sessionIDs(sessions), DefaultConcurrency)
+}
+
+// DeleteAllSessionsOfGroup deletes all sessions of the group, see
+// DeleteAllSessionsOfAuthor.
+func (pad *EtherpadLite) DeleteAllSessionsOfGroup(ctx context.Context, groupID string) (int, error) {
+	sessions, err := pad.ListGroupSessions(ctx, groupID)
+	if err != nil {
+		return 0, err
+	}
+	return pad.deleteSessions(ctx, sessionIDs(sessions), DefaultConcurrency)
+}
@@ -0,0 +1,78 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"time"
+)
+
+// Snapshot is a saved revision of a pad, see SnapshotPad.
+type Snapshot struct {
+	PadID string
+
+	// Revision is the number of the saved revision.
+	Revision int
+
+	// ReadOnlyID is the read-only ID of the pad.
+	ReadOnlyID string
+
+	// TimelineURL is the URL of the read-only timeslider positioned at the
+	// revision.
+	TimelineURL string
+
+	// Created is the time the snapshot was taken.
+	Created time.Time
+}
+
+// SnapshotPad saves the current revision of a pad (saveRevision) and returns
+// a reference to it, for example before automated edits.
+// The revision number is read with getRevisionsCount first and passed to
+// saveRevision, so the saved revision is exactly the one in the snapshot even
+// if the pad is edited in the meantime.
+func (pad *EtherpadLite) SnapshotPad(ctx context.Context, padID string) (*Snapshot, error) {
+	rev, err := pad.RevisionsCount(ctx, padID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := pad.callChecked(ctx, "saveRevision", map[string]interface{}{"padID": padID, "rev": rev}); err != nil {
+		return nil, err
+	}
+	readOnlyID, err := pad.ReadOnlyID(ctx, padID)
+	if err != nil {
+		return nil, err
+	}
+	timeline, err := pad.TimesliderURL(readOnlyID, rev)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{
+		PadID:       padID,
+		Revision:    rev,
+		ReadOnlyID:  readOnlyID,
+		TimelineURL: timeline,
+		Created:     pad.clock().Now(),
+	}, nil
+}
+
+// RestoreSnapshot restores the pad to the revision of the snapshot
+// (restoreRevision). This creates a new revision, the history is kept.
+func (pad *EtherpadLite) RestoreSnapshot(ctx context.Context, snapshot *Snapshot) error {
+	_, err := pad.callChecked(ctx, "restoreRevision", map[string]interface{}{
+		"padID": snapshot.PadID,
+		"rev":   snapshot.Revision,
+	})
+	return err
+}
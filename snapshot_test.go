@@ -0,0 +1,54 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+)
+
+func TestSnapshotPad(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	if err := server.Store.AddPad("pad", "first\n"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pad.SetText(ctx, "pad", "second\n"); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := pad.SnapshotPad(ctx, "pad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Revision != 1 || snapshot.ReadOnlyID == "" || snapshot.TimelineURL == "" {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+	// the revision is saved explicitly, not whatever is current when
+	// saveRevision arrives
+	if rev := lastParams(t, server, "saveRevision").Get("rev"); rev != strconv.Itoa(snapshot.Revision) {
+		t.Errorf("expected saveRevision with rev %d, got %q", snapshot.Revision, rev)
+	}
+
+	if _, err := pad.SetText(ctx, "pad", "third\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pad.RestoreSnapshot(ctx, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if text, _ := server.Store.Text("pad"); text != "second\n" {
+		t.Errorf("expected the text of the snapshot after restoring, got %q", text)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SyncOptions are the options for SyncPad.
+type SyncOptions struct {
+	// Overwrite replaces the content of the pad if it already exists on the
+	// destination. Otherwise SyncPad returns an error matching ErrPadExists.
+	Overwrite bool
+
+	// HTML copies the HTML of the pad (getHTML / setHTML) instead of the text,
+	// this keeps the formatting but not the authorship.
+	HTML bool
+
+	// Chat replays the chat history of the source pad on the destination pad
+	// (appendChatMessage). If the destination pad already has chat messages
+	// they are kept, the history is appended.
+	Chat bool
+
+	// MapAuthor maps an author ID of the source server to an author ID of the
+	// destination server, it is used for the chat messages.
+	// If nil, authors are created on the destination with
+	// createAuthorIfNotExistsFor, using the source author ID as mapper and the
+	// user name of the chat message as name.
+	MapAuthor func(ctx context.Context, authorID, name string) (string, error)
+
+	// PublicStatus copies the public status of the pad (only for group pads).
+	PublicStatus bool
+
+	// Password, if not empty, is set as password of the destination pad if
+	// the source pad is password protected. Etherpad doesn't return the
+	// password, so it can't be copied.
+	Password string
+}
+
+// SyncPad copies the pad with the given ID from src to dst (usually two
+// *EtherpadLite instances): The current text
+// (or HTML) and optionally the chat history, public status and password
+// protection.
+// The history of the pad is not copied, neither is the read-only ID: Etherpad
+// generates read-only IDs itself, the destination pad has a different one.
+func SyncPad(ctx context.Context, src, dst API, padID string, opts SyncOptions) error {
+	contentMethod, contentKey := "getText", "text"
+	if opts.HTML {
+		contentMethod, contentKey = "getHTML", "html"
+	}
+	resp, err := callAPI(ctx, src, contentMethod, map[string]interface{}{"padID": padID})
+	if err != nil {
+		return err
+	}
+	content, err := resp.GetString(contentKey)
+	if err != nil {
+		return err
+	}
+	created, err := dst.EnsurePad(ctx, padID, "")
+	if err != nil {
+		return err
+	}
+	if !created && !opts.Overwrite {
+		return fmt.Errorf("%w: %s", ErrPadExists, padID)
+	}
+	setMethod := "setText"
+	if opts.HTML {
+		setMethod = "setHTML"
+	}
+	if _, err := callAPI(ctx, dst, setMethod, map[string]interface{}{"padID": padID, contentKey: content}); err != nil {
+		return err
+	}
+	if opts.PublicStatus {
+		public, err := src.PublicStatus(ctx, padID)
+		if err != nil {
+			return err
+		}
+		if _, err := callAPI(ctx, dst, "setPublicStatus", map[string]interface{}{"padID": padID, "publicStatus": public}); err != nil {
+			return err
+		}
+	}
+	if opts.Password != "" {
+		protected, err := src.PasswordProtected(ctx, padID)
+		if err != nil {
+			return err
+		}
+		if protected {
+			if _, err := callAPI(ctx, dst, "setPassword", map[string]interface{}{"padID": padID, "password": opts.Password}); err != nil {
+				return err
+			}
+		}
+	}
+	if opts.Chat {
+		_, err := syncChat(ctx, src, dst, padID, &opts, 0)
+		return err
+	}
+	return nil
+}
+
+// syncChat appends the chat messages of the pad on src to the pad on dst,
+// starting at the message with index start. If the source has fewer than
+// start messages (the pad was recreated) all messages are appended.
+// It returns the index of the first message that was not appended, i.e. the
+// start for the next call.
+func syncChat(ctx context.Context, src, dst API, padID string, opts *SyncOptions, start int) (int, error) {
+	messages, err := src.FullChatHistory(ctx, padID)
+	if err != nil {
+		return start, err
+	}
+	if start > len(messages) {
+		start = 0
+	}
+	mapAuthor := opts.MapAuthor
+	if mapAuthor == nil {
+		mapAuthor = dst.EnsureAuthorID
+	}
+	authors := make(map[string]string)
+	for i := start; i < len(messages); i++ {
+		msg := messages[i]
+		authorID, mapped := authors[msg.AuthorID]
+		if !mapped {
+			if authorID, err = mapAuthor(ctx, msg.AuthorID, msg.UserName); err != nil {
+				return i, err
+			}
+			authors[msg.AuthorID] = authorID
+		}
+		params := map[string]interface{}{"padID": padID, "text": msg.Text, "authorID": authorID}
+		if !msg.Time.IsZero() {
+			params["time"] = msg.Time.UnixNano() / int64(time.Millisecond)
+		}
+		if _, err := callAPI(ctx, dst, "appendChatMessage", params); err != nil {
+			return i, err
+		}
+	}
+	return len(messages), nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// textMethods are the API methods whose text parameter is normalized if
+// EtherpadLite.NormalizeLineEndings is set.
+var textMethods = map[string]bool{
+	"setText":        true,
+	"appendText":     true,
+	"createPad":      true,
+	"createGroupPad": true,
+}
+
+// NormalizeText converts the line endings "\r\n" and "\r" to "\n" and removes
+// a leading UTF-8 byte order mark. Etherpad renders a "\r" as additional
+// empty line.
+func NormalizeText(text string) string {
+	text = strings.TrimPrefix(text, "\ufeff")
+	if !strings.Contains(text, "\r") {
+		return text
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+// DefaultChunkSize is the chunk size (in bytes) used by AppendTextChunked if
+// no chunk size is given.
+const DefaultChunkSize = 64 * 1024
+
+// ChunkError is returned by AppendTextChunked if appending a chunk failed.
+type ChunkError struct {
+	// Offset is the byte offset in the text up to which the text was
+	// appended, call AppendTextChunked with text[Offset:] to resume.
+	Offset int
+
+	Err error
+}
+
+// Error returns the error as a string.
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("appending chunk at byte offset %d failed: %v", e.Offset, e.Err)
+}
+
+// Unwrap returns Err.
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// nextChunk returns the length of the next chunk of text with at most size
+// bytes. It prefers to end the chunk after a newline and never splits a
+// UTF-8 sequence or a "\r\n" (normalizing the two chunks separately would
+// result in two newlines).
+func nextChunk(text string, size int) int {
+	if len(text) <= size {
+		return len(text)
+	}
+	if i := strings.LastIndexByte(text[:size], '\n'); i >= 0 {
+		return i + 1
+	}
+	n := size
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	if n == 0 {
+		// a chunk size smaller than a single rune, send the rune anyway
+		_, n = utf8.DecodeRuneInString(text)
+	}
+	if n < len(text) && text[n-1] == '\r' && text[n] == '\n' {
+		if n > 1 {
+			n--
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// AppendTextChunked appends a large text to a pad in chunks of at most
+// chunkSize bytes (DefaultChunkSize if <= 0), one appendText request per
+// chunk. Chunks end after a newline if possible and never split a UTF-8
+// sequence or a "\r\n", so the text can be normalized per chunk (see
+// NormalizeLineEndings).
+// If progress is given it is called after each chunk with the number of
+// bytes appended so far and the total length of text.
+// If a chunk fails a *ChunkError with the byte offset reached is returned.
+func (pad *EtherpadLite) AppendTextChunked(ctx context.Context, padID, text string, chunkSize int, progress ...func(offset, total int)) error {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	offset := 0
+	for offset < len(text) {
+		n := nextChunk(text[offset:], chunkSize)
+		if _, err := pad.callChecked(ctx, "appendText", map[string]interface{}{"padID": padID, "text": text[offset : offset+n]}); err != nil {
+			return &ChunkError{Offset: offset, Err: err}
+		}
+		offset += n
+		for _, fn := range progress {
+			fn(offset, len(text))
+		}
+	}
+	return nil
+}
+
+// TextAction is the action EnsureTextAction took.
+type TextAction string
+
+const (
+	// TextUnchanged means the pad already had the text.
+	TextUnchanged TextAction = "unchanged"
+
+	// TextUpdated means the text of the pad was replaced.
+	TextUpdated TextAction = "updated"
+
+	// TextCreated means the pad was created with the text.
+	TextCreated TextAction = "created"
+)
+
+// EnsureTextOptions are the options for EnsureText.
+type EnsureTextOptions struct {
+	// IgnoreTrailingNewline ignores differences in trailing newlines when
+	// comparing the texts, etherpad always ends the text with a newline.
+	IgnoreTrailingNewline bool
+
+	// EnsureMissing creates the pad with the text if it doesn't exist,
+	// otherwise an error matching ErrPadNotFound is returned.
+	EnsureMissing bool
+}
+
+// sameText compares the current text of a pad with the desired text.
+func (opts *EnsureTextOptions) sameText(current, text string) bool {
+	if opts.IgnoreTrailingNewline {
+		current = strings.TrimRight(current, "\n")
+		text = strings.TrimRight(text, "\n")
+	}
+	return current == text
+}
+
+// EnsureTextAction sets the text of a pad only if it differs from the current
+// text, so no revision is created if nothing changed. It returns the action
+// that was taken.
+// If line endings are normalized (see EtherpadLite.NormalizeLineEndings) the
+// normalized text is compared.
+func (pad *EtherpadLite) EnsureTextAction(ctx context.Context, padID, text string, opts ...EnsureTextOptions) (TextAction, error) {
+	var options EnsureTextOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if pad.normalizeLineEndings(ctx) {
+		text = NormalizeText(text)
+	}
+	action := TextUpdated
+	current, err := pad.GetTextContent(ctx, padID)
+	switch {
+	case err == nil:
+		if options.sameText(current, text) {
+			return TextUnchanged, nil
+		}
+	case options.EnsureMissing && errors.Is(err, ErrPadNotFound):
+		created, err := pad.EnsurePad(ctx, padID, text)
+		if err != nil {
+			return "", err
+		}
+		if created {
+			if text != "" {
+				return TextCreated, nil
+			}
+			// etherpad's default text was used, clear it
+			action = TextCreated
+		}
+	default:
+		return "", err
+	}
+	if _, err := pad.callChecked(ctx, "setText", map[string]interface{}{"padID": padID, "text": text}); err != nil {
+		return "", err
+	}
+	return action, nil
+}
+
+// EnsureText is like EnsureTextAction but only returns if the pad was changed
+// (created or updated).
+func (pad *EtherpadLite) EnsureText(ctx context.Context, padID, text string, opts ...EnsureTextOptions) (changed bool, err error) {
+	action, err := pad.EnsureTextAction(ctx, padID, text, opts...)
+	return err == nil && action != TextUnchanged, err
+}
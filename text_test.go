@@ -0,0 +1,150 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name, in, expected string
+	}{
+		{"unix", "a\nb\n", "a\nb\n"},
+		{"windows", "a\r\nb\r\n", "a\nb\n"},
+		{"old mac", "a\rb\r", "a\nb\n"},
+		{"mixed", "a\r\nb\rc\nd\r\r\ne", "a\nb\nc\nd\n\ne"},
+		{"blank lines", "\r\n\r\n", "\n\n"},
+		{"bom", "\ufeffa\r\nb", "a\nb"},
+		{"bom only at start", "a\ufeffb", "a\ufeffb"},
+		{"only bom", "\ufeff", ""},
+		{"empty", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := NormalizeText(test.in); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeLineEndingsWire(t *testing.T) {
+	const (
+		input      = "\ufeffline 1\r\nline 2\rline 3\n\r\n"
+		normalized = "line 1\nline 2\nline 3\n\n"
+	)
+	sent := func(pad *EtherpadLite, ctx context.Context, method, key string) []byte {
+		t.Helper()
+		params := map[string]interface{}{"padID": "pad", key: input}
+		if method == "createGroupPad" {
+			params = map[string]interface{}{"groupID": "g.1", "padName": "pad", key: input}
+		}
+		req, err := pad.BuildRequest(ctx, method, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []byte(req.URL.Query().Get(key))
+	}
+	ctx := context.Background()
+	pad := NewEtherpadLite("key")
+	for _, method := range []string{"setText", "appendText", "createPad", "createGroupPad"} {
+		if got := sent(pad, ctx, method, "text"); string(got) != input {
+			t.Errorf("%s: expected text to be unchanged by default, got % x", method, got)
+		}
+		if got := sent(pad, WithNormalizeLineEndings(ctx, true), method, "text"); string(got) != normalized {
+			t.Errorf("%s: expected bytes % x with the context option, got % x", method, []byte(normalized), got)
+		}
+	}
+	pad.NormalizeLineEndings = true
+	for _, method := range []string{"setText", "appendText", "createPad", "createGroupPad"} {
+		if got := sent(pad, ctx, method, "text"); string(got) != normalized {
+			t.Errorf("%s: expected bytes % x, got % x", method, []byte(normalized), got)
+		}
+		if got := sent(pad, WithNormalizeLineEndings(ctx, false), method, "text"); string(got) != input {
+			t.Errorf("%s: expected the context to disable normalization, got % x", method, got)
+		}
+	}
+	// other methods and parameters are never changed
+	if got := sent(pad, ctx, "setHTML", "html"); string(got) != input {
+		t.Errorf("setHTML: expected html to be unchanged, got % x", got)
+	}
+	if got := sent(pad, ctx, "sendClientsMessage", "msg"); string(got) != input {
+		t.Errorf("sendClientsMessage: expected msg to be unchanged, got % x", got)
+	}
+}
+
+func TestNextChunk(t *testing.T) {
+	tests := []struct {
+		text     string
+		size     int
+		expected int
+	}{
+		{"abc", 5, 3},
+		{"ab\ncd\nef", 7, 6},
+		{"abcdef", 4, 4},
+		{"aä", 2, 1},
+		{"ä", 1, 2},
+		// the boundary is exactly after the "\r"
+		{"ab\r\ncd", 3, 2},
+		{"\r\ncd", 1, 2},
+		{"ab\rcd", 3, 3},
+		{"ab\r\r\n", 4, 3},
+	}
+	for _, test := range tests {
+		if got := nextChunk(test.text, test.size); got != test.expected {
+			t.Errorf("nextChunk(%q, %d): expected %d, got %d", test.text, test.size, test.expected, got)
+		}
+	}
+}
+
+func TestAppendTextChunkedNormalize(t *testing.T) {
+	var (
+		mutex  sync.Mutex
+		chunks []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		chunks = append(chunks, r.URL.Query().Get("text"))
+		mutex.Unlock()
+		io.WriteString(w, `{"code": 0, "message": "ok", "data": null}`)
+	}))
+	defer server.Close()
+	pad := NewEtherpadLite("key")
+	pad.BaseURL = server.URL + "/api"
+	pad.Client = server.Client()
+	pad.NormalizeLineEndings = true
+	const text = "ab\r\ncd\r\n\r\nef\rgh"
+	for size := 1; size <= len(text); size++ {
+		mutex.Lock()
+		chunks = nil
+		mutex.Unlock()
+		if err := pad.AppendTextChunked(context.Background(), "pad", text, size); err != nil {
+			t.Fatal(err)
+		}
+		mutex.Lock()
+		got := strings.Join(chunks, "")
+		mutex.Unlock()
+		if expected := NormalizeText(text); got != expected {
+			t.Errorf("chunk size %d: expected %q, got %q (chunks %q)", size, expected, got, chunks)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/etherpadtest"
+)
+
+func TestTLSRootCAs(t *testing.T) {
+	server := etherpadtest.NewServer(t, etherpadtest.WithTLS())
+	if err := server.Store.AddPad("pad", "secure"); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	client := &http.Client{Transport: &http.Transport{}}
+	pad, err := etherpadlite.New(etherpadtest.DefaultAPIKey,
+		etherpadlite.WithBaseURL(server.BaseURL()),
+		etherpadlite.WithHTTPClient(client),
+		etherpadlite.WithRootCAs(server.CertPool()),
+		etherpadlite.WithRaiseErrors(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pad.Verify(ctx); err != nil {
+		t.Fatal(err)
+	}
+	text, err := pad.GetTextContent(ctx, "pad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "secure\n" {
+		t.Errorf("expected text %q, got %q", "secure\n", text)
+	}
+	// Transport.Clone may set up HTTP/2 on the original, but never the roots
+	if config := client.Transport.(*http.Transport).TLSClientConfig; config != nil && config.RootCAs != nil {
+		t.Error("WithRootCAs modified the transport of the given client")
+	}
+}
+
+func TestTLSUnknownAuthority(t *testing.T) {
+	server := etherpadtest.NewServer(t, etherpadtest.WithTLS())
+	ctx := context.Background()
+	// the system roots don't contain the test CA
+	pad, err := etherpadlite.New(etherpadtest.DefaultAPIKey,
+		etherpadlite.WithBaseURL(server.BaseURL()),
+		etherpadlite.WithRootCAs(x509.NewCertPool()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pad.Verify(ctx)
+	var authorityErr x509.UnknownAuthorityError
+	if !errors.As(err, &authorityErr) {
+		t.Errorf("expected x509.UnknownAuthorityError, got %v", err)
+	}
+	if len(server.Requests()) != 0 {
+		t.Error("request reached the server despite the invalid certificate")
+	}
+}
+
+func TestTLSInsecureSkipVerify(t *testing.T) {
+	server := etherpadtest.NewServer(t, etherpadtest.WithTLS())
+	pad, err := etherpadlite.New(etherpadtest.DefaultAPIKey,
+		etherpadlite.WithBaseURL(server.BaseURL()),
+		etherpadlite.WithInsecureSkipVerify())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pad.Verify(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
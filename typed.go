@@ -0,0 +1,404 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+// This file contains typed wrappers around the API methods. In contrast to the
+// methods returning a Response they always return an EtherpadError if the
+// return code is not EverythingOk, independent of RaiseEtherpadErrors.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// callChecked calls the API method and returns an EtherpadError if the return
+// code is not EverythingOk.
+func (pad *EtherpadLite) callChecked(ctx context.Context, method string, params map[string]interface{}) (*Response, error) {
+	resp, err := pad.sendRequest(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != EverythingOk {
+		return nil, NewEtherpadError(resp.Code, resp.Message)
+	}
+	return resp, nil
+}
+
+// revParams returns the parameters for a method taking a padID and an optional
+// revision. Only the first element of rev is used.
+func revParams(padID string, rev []int) map[string]interface{} {
+	params := map[string]interface{}{"padID": padID}
+	if len(rev) > 0 {
+		params["rev"] = rev[0]
+	}
+	return params
+}
+
+// GetTextContent returns the text of a pad.
+// The revision is optional, if given the text of this revision is returned.
+func (pad *EtherpadLite) GetTextContent(ctx context.Context, padID string, rev ...int) (string, error) {
+	resp, err := pad.callChecked(ctx, "getText", revParams(padID, rev))
+	if err != nil {
+		return "", err
+	}
+	return resp.GetString("text")
+}
+
+// GetHTMLContent returns the HTML of a pad.
+// The revision is optional, if given the HTML of this revision is returned.
+func (pad *EtherpadLite) GetHTMLContent(ctx context.Context, padID string, rev ...int) (string, error) {
+	resp, err := pad.callChecked(ctx, "getHTML", revParams(padID, rev))
+	if err != nil {
+		return "", err
+	}
+	return resp.GetString("html")
+}
+
+// ListAllPadIDs returns the IDs of all pads. If there are no pads the result
+// is an empty slice.
+func (pad *EtherpadLite) ListAllPadIDs(ctx context.Context) ([]string, error) {
+	resp, err := pad.callChecked(ctx, "listAllPads", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetStringSlice("padIDs")
+}
+
+// ListGroupPadIDs returns the IDs of all pads of a group, i.e. IDs of the form
+// "groupID$padName". If the group has no pads the result is an empty slice.
+func (pad *EtherpadLite) ListGroupPadIDs(ctx context.Context, groupID string) ([]string, error) {
+	resp, err := pad.callChecked(ctx, "listPads", map[string]interface{}{"groupID": groupID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetStringSlice("padIDs")
+}
+
+// ListGroupPadNames returns the names of all pads of a group, that is the IDs
+// returned by ListGroupPadIDs without the "groupID$" prefix.
+// Pad names may contain "$" themselves, only the first "$" separates the group.
+func (pad *EtherpadLite) ListGroupPadNames(ctx context.Context, groupID string) ([]string, error) {
+	ids, err := pad.ListGroupPadIDs(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range ids {
+		if sep := strings.Index(id, "$"); sep >= 0 {
+			ids[i] = id[sep+1:]
+		}
+	}
+	return ids, nil
+}
+
+// CreateGroupID creates a new group and returns its ID.
+func (pad *EtherpadLite) CreateGroupID(ctx context.Context) (string, error) {
+	resp, err := pad.callChecked(ctx, "createGroup", nil)
+	if err != nil {
+		return "", err
+	}
+	return resp.GetString("groupID")
+}
+
+// CreateGroupIDFor returns the ID of the group mapped to mapper, the group is
+// created if it doesn't exist yet.
+// If EtherpadLite.MapperCache is set the cache is used.
+func (pad *EtherpadLite) CreateGroupIDFor(ctx context.Context, mapper string) (string, error) {
+	if pad.MapperCache != nil {
+		if id, cached := pad.MapperCache.get(groupMapper, mapper); cached {
+			return id, nil
+		}
+	}
+	resp, err := pad.callChecked(ctx, "createGroupIfNotExistsFor", map[string]interface{}{"groupMapper": mapper})
+	if err != nil {
+		return "", err
+	}
+	id, err := resp.GetString("groupID")
+	if err == nil && pad.MapperCache != nil {
+		pad.MapperCache.put(groupMapper, mapper, id)
+	}
+	return id, err
+}
+
+// CreateAuthorID creates a new author and returns its ID.
+// name is optional, an empty name is not sent.
+func (pad *EtherpadLite) CreateAuthorID(ctx context.Context, name string) (string, error) {
+	params := make(map[string]interface{})
+	if name != "" {
+		params["name"] = name
+	}
+	resp, err := pad.callChecked(ctx, "createAuthor", params)
+	if err != nil {
+		return "", err
+	}
+	return resp.GetString("authorID")
+}
+
+// EnsureAuthorID returns the ID of the author mapped to mapper, the
+// author is created if it doesn't exist yet.
+// name is optional, an empty name is not sent.
+// If EtherpadLite.MapperCache is set the cache is used.
+func (pad *EtherpadLite) EnsureAuthorID(ctx context.Context, mapper, name string) (string, error) {
+	if pad.MapperCache != nil {
+		if id, cached := pad.MapperCache.get(authorMapper, mapper); cached {
+			return id, nil
+		}
+	}
+	params := map[string]interface{}{"authorMapper": mapper}
+	if name != "" {
+		params["name"] = name
+	}
+	resp, err := pad.callChecked(ctx, "createAuthorIfNotExistsFor", params)
+	if err != nil {
+		return "", err
+	}
+	id, err := resp.GetString("authorID")
+	if err == nil && pad.MapperCache != nil {
+		pad.MapperCache.put(authorMapper, mapper, id)
+	}
+	return id, err
+}
+
+// RevisionsCount returns the number of revisions of a pad.
+func (pad *EtherpadLite) RevisionsCount(ctx context.Context, padID string) (int, error) {
+	resp, err := pad.callChecked(ctx, "getRevisionsCount", map[string]interface{}{"padID": padID})
+	if err != nil {
+		return 0, err
+	}
+	return resp.getInt("revisions")
+}
+
+// SavedRevisionsCount returns the number of saved revisions of a pad.
+func (pad *EtherpadLite) SavedRevisionsCount(ctx context.Context, padID string) (int, error) {
+	resp, err := pad.callChecked(ctx, "getSavedRevisionsCount", map[string]interface{}{"padID": padID})
+	if err != nil {
+		return 0, err
+	}
+	return resp.getInt("savedRevisions")
+}
+
+// SavedRevisions returns the saved revisions of a pad. If there are no saved
+// revisions the result is an empty slice.
+func (pad *EtherpadLite) SavedRevisions(ctx context.Context, padID string) ([]int, error) {
+	resp, err := pad.callChecked(ctx, "listSavedRevisions", map[string]interface{}{"padID": padID})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := resp.field("savedRevisions"); err != nil {
+		return nil, err
+	}
+	var data struct {
+		SavedRevisions []int `json:"savedRevisions"`
+	}
+	if err := resp.DecodeData(&data); err != nil {
+		return nil, err
+	}
+	if data.SavedRevisions == nil {
+		return []int{}, nil
+	}
+	return data.SavedRevisions, nil
+}
+
+// AuthorsOfPad returns the IDs of all authors that contributed to a pad.
+func (pad *EtherpadLite) AuthorsOfPad(ctx context.Context, padID string) ([]string, error) {
+	resp, err := pad.callChecked(ctx, "listAuthorsOfPad", map[string]interface{}{"padID": padID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetStringSlice("authorIDs")
+}
+
+// AuthorName returns the name of an author. Older versions of etherpad return
+// the name directly as data, newer ones as the field "authorName", both are
+// supported. If the author has no name the empty string is returned.
+func (pad *EtherpadLite) AuthorName(ctx context.Context, authorID string) (string, error) {
+	resp, err := pad.callChecked(ctx, "getAuthorName", map[string]interface{}{"authorID": authorID})
+	if err != nil {
+		return "", err
+	}
+	if resp.Data == nil {
+		var name *string
+		if err := resp.DecodeData(&name); err != nil || name == nil {
+			return "", err
+		}
+		return *name, nil
+	}
+	value, err := resp.field("authorName")
+	if err != nil || value == nil {
+		return "", err
+	}
+	name, ok := value.(string)
+	if !ok {
+		return "", newWrongTypeError("authorName", "string", value)
+	}
+	return name, nil
+}
+
+// AuthorsOfPadWithNames returns all authors that contributed to a pad,
+// mapping the author ID to the name of the author.
+// The names are requested concurrently with at most DefaultConcurrency
+// requests at a time. If the name of an author can't be found (etherpad
+// returns an error for this author) the name is the empty string.
+func (pad *EtherpadLite) AuthorsOfPadWithNames(ctx context.Context, padID string) (map[string]string, error) {
+	ids, err := pad.AuthorsOfPad(ctx, padID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(ids))
+	err = forEach(ctx, len(ids), DefaultConcurrency, func(ctx context.Context, i int) error {
+		name, nameErr := pad.AuthorName(ctx, ids[i])
+		var padErr EtherpadError
+		var fieldErr *MissingFieldError
+		if nameErr != nil && !errors.As(nameErr, &padErr) && !errors.As(nameErr, &fieldErr) {
+			return nameErr
+		}
+		// for unknown authors the name is empty
+		names[i] = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(ids))
+	for i, id := range ids {
+		res[id] = names[i]
+	}
+	return res, nil
+}
+
+// LastEdited returns the time a pad was last edited (in UTC, with millisecond
+// precision).
+func (pad *EtherpadLite) LastEdited(ctx context.Context, padID string) (time.Time, error) {
+	resp, err := pad.callChecked(ctx, "getLastEdited", map[string]interface{}{"padID": padID})
+	if err != nil {
+		return time.Time{}, err
+	}
+	millis, err := resp.GetInt64("lastEdited")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return millisToTime(millis), nil
+}
+
+// ReadOnlyID returns the read-only ID of a pad.
+func (pad *EtherpadLite) ReadOnlyID(ctx context.Context, padID string) (string, error) {
+	resp, err := pad.callChecked(ctx, "getReadOnlyID", map[string]interface{}{"padID": padID})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetString("readOnlyID")
+}
+
+// PadIDFromReadOnly returns the ID of the pad with the given read-only ID.
+func (pad *EtherpadLite) PadIDFromReadOnly(ctx context.Context, readOnlyID string) (string, error) {
+	resp, err := pad.callChecked(ctx, "getPadID", map[string]interface{}{"readOnlyID": readOnlyID})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetString("padID")
+}
+
+// PublicStatus returns true if a (group) pad is public.
+func (pad *EtherpadLite) PublicStatus(ctx context.Context, padID string) (bool, error) {
+	resp, err := pad.callChecked(ctx, "getPublicStatus", map[string]interface{}{"padID": padID})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetBool("publicStatus")
+}
+
+// PasswordProtected returns true if a pad is protected by a password.
+func (pad *EtherpadLite) PasswordProtected(ctx context.Context, padID string) (bool, error) {
+	resp, err := pad.callChecked(ctx, "isPasswordProtected", map[string]interface{}{"padID": padID})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetBool("isPasswordProtected")
+}
+
+// AllGroupIDs returns the IDs of all groups. If there are no groups the result
+// is an empty slice.
+func (pad *EtherpadLite) AllGroupIDs(ctx context.Context) ([]string, error) {
+	resp, err := pad.callChecked(ctx, "listAllGroups", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetStringSlice("groupIDs")
+}
+
+// PadIDsOfAuthor returns the IDs of all pads the author contributed to. If
+// there are no such pads the result is an empty slice.
+func (pad *EtherpadLite) PadIDsOfAuthor(ctx context.Context, authorID string) ([]string, error) {
+	resp, err := pad.callChecked(ctx, "listPadsOfAuthor", map[string]interface{}{"authorID": authorID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetStringSlice("padIDs")
+}
+
+// PadDiff is the result of DiffHTML.
+type PadDiff struct {
+	// HTML is the pad as HTML with the changes between the revisions marked.
+	HTML string
+
+	// Authors are the IDs of the authors that made the changes.
+	Authors []string
+}
+
+// DiffHTML returns the changes made to a pad between startRev and endRev.
+// startRev must not be greater than endRev, this is checked before the
+// request is sent.
+func (pad *EtherpadLite) DiffHTML(ctx context.Context, padID string, startRev, endRev int) (*PadDiff, error) {
+	if startRev < 0 || startRev > endRev {
+		return nil, fmt.Errorf("invalid revision range for createDiffHTML: start revision %d, end revision %d", startRev, endRev)
+	}
+	resp, err := pad.callChecked(ctx, "createDiffHTML", map[string]interface{}{
+		"padID":    padID,
+		"startRev": startRev,
+		"endRev":   endRev,
+	})
+	if err != nil {
+		return nil, err
+	}
+	html, err := resp.GetString("html")
+	if err != nil {
+		return nil, err
+	}
+	authors, err := resp.GetStringSlice("authors")
+	if err != nil {
+		return nil, err
+	}
+	return &PadDiff{HTML: html, Authors: authors}, nil
+}
+
+// Stats contains statistics about the etherpad instance.
+type Stats struct {
+	TotalPads       int `json:"totalPads"`
+	TotalSessions   int `json:"totalSessions"`
+	TotalActivePads int `json:"totalActivePads"`
+}
+
+// StatsTyped returns statistics about the etherpad instance. It requires API
+// version 1.2.14 (newer than CurrentVersion), for older versions an error
+// wrapping ErrUnsupportedInVersion is returned. See GetStats.
+func (pad *EtherpadLite) StatsTyped(ctx context.Context) (*Stats, error) {
+	stats, _, err := Do[Stats](pad, ctx, "getStats", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
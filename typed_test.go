@@ -0,0 +1,151 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/FabianWe/etherpadlite-golang"
+	"github.com/FabianWe/etherpadlite-golang/mock"
+)
+
+func TestListAllPadIDs(t *testing.T) {
+	for _, n := range []int{0, 1, 25} {
+		t.Run(fmt.Sprintf("%d pads", n), func(t *testing.T) {
+			server, pad := newTestClient(t)
+			expected := make([]string, n)
+			for i := range expected {
+				expected[i] = fmt.Sprintf("pad-%02d", i)
+				if err := server.Store.AddPad(expected[i], "text"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ids, err := pad.ListAllPadIDs(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids == nil {
+				t.Fatal("expected a non-nil slice")
+			}
+			sort.Strings(ids)
+			if fmt.Sprint(ids) != fmt.Sprint(expected) {
+				t.Errorf("expected %v, got %v", expected, ids)
+			}
+		})
+	}
+}
+
+func TestListAllPadIDsMalformed(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	server.Store.QueueResponse("listAllPads", mock.CannedResponse{
+		Data: map[string]interface{}{"padIDs": nil},
+	})
+	ids, err := pad.ListAllPadIDs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids == nil || len(ids) != 0 {
+		t.Errorf("expected an empty non-nil slice for null, got %#v", ids)
+	}
+	server.Store.QueueResponse("listAllPads", mock.CannedResponse{
+		Data: map[string]interface{}{"padIDs": []interface{}{"foo", 42}},
+	})
+	_, err = pad.ListAllPadIDs(ctx)
+	var typeErr *etherpadlite.WrongTypeError
+	if !errors.As(err, &typeErr) || typeErr.Key != "padIDs[1]" {
+		t.Errorf("expected a WrongTypeError for padIDs[1], got %v", err)
+	}
+	server.Store.QueueResponse("listAllPads", mock.CannedResponse{
+		Data: map[string]interface{}{},
+	})
+	_, err = pad.ListAllPadIDs(ctx)
+	var missingErr *etherpadlite.MissingFieldError
+	if !errors.As(err, &missingErr) {
+		t.Errorf("expected a MissingFieldError, got %v", err)
+	}
+}
+
+func TestAllGroupIDs(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	ids, err := pad.AllGroupIDs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids == nil || len(ids) != 0 {
+		t.Errorf("expected an empty non-nil slice, got %#v", ids)
+	}
+	expected := []string{server.Store.AddGroup("a"), server.Store.AddGroup("b"), server.Store.AddGroup("c")}
+	sort.Strings(expected)
+	ids, err = pad.AllGroupIDs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(ids)
+	if fmt.Sprint(ids) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+	server.Store.QueueResponse("listAllGroups", mock.CannedResponse{
+		Data: map[string]interface{}{"groupIDs": []interface{}{"g.1", "g.2", nil}},
+	})
+	_, err = pad.AllGroupIDs(ctx)
+	var typeErr *etherpadlite.WrongTypeError
+	if !errors.As(err, &typeErr) || typeErr.Key != "groupIDs[2]" {
+		t.Errorf("expected a WrongTypeError for groupIDs[2], got %v", err)
+	}
+}
+
+func TestPadIDsOfAuthor(t *testing.T) {
+	server, pad := newTestClient(t)
+	ctx := context.Background()
+	authorID := server.Store.AddAuthor("author", "Alice")
+	ids, err := pad.PadIDsOfAuthor(ctx, authorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids == nil || len(ids) != 0 {
+		t.Errorf("expected an empty non-nil slice, got %#v", ids)
+	}
+	for _, padID := range []string{"b", "a"} {
+		if _, err := pad.CreatePadAs(ctx, padID, "text", authorID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// pads of other authors are not listed
+	if _, err := pad.CreatePad(ctx, "c", "text"); err != nil {
+		t.Fatal(err)
+	}
+	ids, err = pad.PadIDsOfAuthor(ctx, authorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(ids)
+	if fmt.Sprint(ids) != "[a b]" {
+		t.Errorf("expected [a b], got %v", ids)
+	}
+	server.Store.QueueResponse("listPadsOfAuthor", mock.CannedResponse{
+		Data: map[string]interface{}{"padIDs": []interface{}{true}},
+	})
+	_, err = pad.PadIDsOfAuthor(ctx, authorID)
+	var typeErr *etherpadlite.WrongTypeError
+	if !errors.As(err, &typeErr) || typeErr.Key != "padIDs[0]" {
+		t.Errorf("expected a WrongTypeError for padIDs[0], got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// PadURLOptions are the options etherpad accepts in the query of a pad URL.
+// The zero value doesn't add any query parameters.
+type PadURLOptions struct {
+	// UserName is the name of the user (userName).
+	UserName string
+
+	// UserColor is the color of the user, for example "#ff0000" (userColor).
+	UserColor string
+
+	// Lang is the language of the user interface, for example "de" (lang).
+	Lang string
+
+	// HideControls hides the toolbar (showControls=false).
+	HideControls bool
+
+	// HideChat hides the chat (showChat=false).
+	HideChat bool
+
+	// HideLineNumbers hides the line numbers (showLineNumbers=false).
+	HideLineNumbers bool
+
+	// MonospaceFont uses a monospace font (useMonospaceFont=true).
+	MonospaceFont bool
+
+	// NoColors disables the author colors (noColors=true).
+	NoColors bool
+
+	// AlwaysShowChat always shows the chat (alwaysShowChat=true).
+	AlwaysShowChat bool
+
+	// RTL enables right-to-left mode (rtl=true).
+	RTL bool
+}
+
+// query returns the options as URL query.
+func (o *PadURLOptions) query() url.Values {
+	query := url.Values{}
+	setString := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	setBool := func(key string, set bool, value string) {
+		if set {
+			query.Set(key, value)
+		}
+	}
+	setString("userName", o.UserName)
+	setString("userColor", o.UserColor)
+	setString("lang", o.Lang)
+	setBool("showControls", o.HideControls, "false")
+	setBool("showChat", o.HideChat, "false")
+	setBool("showLineNumbers", o.HideLineNumbers, "false")
+	setBool("useMonospaceFont", o.MonospaceFont, "true")
+	setBool("noColors", o.NoColors, "true")
+	setBool("alwaysShowChat", o.AlwaysShowChat, "true")
+	setBool("rtl", o.RTL, "true")
+	return query
+}
+
+// PadURL returns the URL of a pad in the etherpad frontend, derived from
+// BaseURL by removing the "/api" suffix. The pad ID is escaped correctly, for
+// example the "$" of group pads is kept while spaces are encoded.
+// options is optional, only the first element is used.
+func (pad *EtherpadLite) PadURL(padID string, options ...PadURLOptions) (string, error) {
+	if padID == "" {
+		return "", fmt.Errorf("can't build URL for empty pad ID")
+	}
+	u, err := pad.padPath(padID, "")
+	if err != nil {
+		return "", err
+	}
+	if len(options) > 0 {
+		u.RawQuery = options[0].query().Encode()
+	}
+	return u.String(), nil
+}
+
+// ReadOnlyURL returns the URL of the read-only version of a pad, see PadURL.
+// It calls getReadOnlyID to get the read-only ID.
+func (pad *EtherpadLite) ReadOnlyURL(ctx context.Context, padID string, options ...PadURLOptions) (string, error) {
+	readOnlyID, err := pad.ReadOnlyID(ctx, padID)
+	if err != nil {
+		return "", err
+	}
+	return pad.PadURL(readOnlyID, options...)
+}
+
+// TimesliderURL returns the URL of the timeslider of a pad (the pad ID may be
+// a read-only ID) positioned at the given revision.
+func (pad *EtherpadLite) TimesliderURL(padID string, rev int) (string, error) {
+	if padID == "" {
+		return "", fmt.Errorf("can't build URL for empty pad ID")
+	}
+	u, err := pad.padPath(padID, "/timeslider")
+	if err != nil {
+		return "", err
+	}
+	u.Fragment = strconv.Itoa(rev)
+	return u.String(), nil
+}
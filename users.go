@@ -0,0 +1,147 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PadUser is a user currently connected to a pad, as returned by padUsers.
+type PadUser struct {
+	// ID is the author ID of the user.
+	ID string
+
+	// Name is the name of the user, empty for anonymous users.
+	Name string
+
+	// ColorID is the color of the user. Etherpad returns either a color
+	// (for example "#ff0000") or the index of a color in its palette, the
+	// index is converted to a string.
+	ColorID string
+
+	// Timestamp is the time the user was last seen.
+	Timestamp time.Time
+
+	// Extra contains all fields not listed above, for example fields added by
+	// plugins.
+	Extra map[string]interface{}
+}
+
+// millisToTime converts milliseconds since the Unix epoch to a time.Time
+// in UTC.
+func millisToTime(millis int64) time.Time {
+	return time.Unix(millis/1000, (millis%1000)*int64(time.Millisecond)).UTC()
+}
+
+// rawString decodes raw as a string. Numbers are converted to strings and
+// null is the empty string.
+func rawString(raw json.RawMessage) (string, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || string(trimmed) == "null" {
+		return "", nil
+	}
+	if trimmed[0] == '"' {
+		var str string
+		err := json.Unmarshal(trimmed, &str)
+		return str, err
+	}
+	var number json.Number
+	if err := json.Unmarshal(trimmed, &number); err != nil {
+		return "", err
+	}
+	return number.String(), nil
+}
+
+// UnmarshalJSON decodes a user as returned by padUsers.
+func (u *PadUser) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var err error
+	if u.ID, err = rawString(fields["id"]); err != nil {
+		return fmt.Errorf("invalid id of pad user: %w", err)
+	}
+	if u.Name, err = rawString(fields["name"]); err != nil {
+		return fmt.Errorf("invalid name of pad user: %w", err)
+	}
+	if u.ColorID, err = rawString(fields["colorId"]); err != nil {
+		return fmt.Errorf("invalid colorId of pad user: %w", err)
+	}
+	timestamp, err := rawString(fields["timestamp"])
+	if err != nil {
+		return fmt.Errorf("invalid timestamp of pad user: %w", err)
+	}
+	if timestamp != "" {
+		millis, err := AsInt64(json.Number(timestamp))
+		if err != nil {
+			return fmt.Errorf("invalid timestamp of pad user: %w", err)
+		}
+		u.Timestamp = millisToTime(millis)
+	}
+	u.Extra = nil
+	for key, raw := range fields {
+		switch key {
+		case "id", "name", "colorId", "timestamp":
+			continue
+		}
+		var value interface{}
+		decoder := json.NewDecoder(strings.NewReader(string(raw)))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return err
+		}
+		if u.Extra == nil {
+			u.Extra = make(map[string]interface{})
+		}
+		u.Extra[key] = value
+	}
+	return nil
+}
+
+// PadUsersList returns the users currently connected to a pad.
+func (pad *EtherpadLite) PadUsersList(ctx context.Context, padID string) ([]PadUser, error) {
+	resp, err := pad.callChecked(ctx, "padUsers", map[string]interface{}{"padID": padID})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := resp.field("padUsers"); err != nil {
+		return nil, err
+	}
+	var data struct {
+		PadUsers []PadUser `json:"padUsers"`
+	}
+	if err := resp.DecodeData(&data); err != nil {
+		return nil, err
+	}
+	if data.PadUsers == nil {
+		return []PadUser{}, nil
+	}
+	return data.PadUsers, nil
+}
+
+// PadUsersCountInt returns the number of users currently connected to a pad.
+func (pad *EtherpadLite) PadUsersCountInt(ctx context.Context, padID string) (int, error) {
+	resp, err := pad.callChecked(ctx, "padUsersCount", map[string]interface{}{"padID": padID})
+	if err != nil {
+		return 0, err
+	}
+	return resp.getInt("padUsersCount")
+}
@@ -0,0 +1,206 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// MissingParameterError is returned if a required parameter of an API method
+// is nil or OptionalParam. In this case no request is sent.
+type MissingParameterError struct {
+	// Method is the name of the API method, for example "createPad".
+	Method string
+
+	// Parameter is the name of the missing parameter, for example "padID".
+	Parameter string
+}
+
+// Error returns the error as a string.
+func (e *MissingParameterError) Error() string {
+	return fmt.Sprintf("missing required parameter %s for %s", e.Parameter, e.Method)
+}
+
+// requiredParams maps the API methods to their required parameters, it is
+// built from apiMethods.
+var requiredParams = func() map[string][]string {
+	res := make(map[string][]string, len(apiMethods))
+	for _, m := range apiMethods {
+		if len(m.Required) > 0 {
+			res[m.Name] = m.Required
+		}
+	}
+	return res
+}()
+
+// isMissing returns true if value is nil or OptionalParam.
+func isMissing(value interface{}) bool {
+	return value == nil || value == OptionalParam
+}
+
+// checkRequiredParams returns a MissingParameterError if a required parameter
+// of method (see requiredParams) is missing in params.
+func checkRequiredParams(method string, params map[string]interface{}) error {
+	for _, name := range requiredParams[method] {
+		if value, has := params[name]; !has || isMissing(value) {
+			return &MissingParameterError{Method: method, Parameter: name}
+		}
+	}
+	return nil
+}
+
+// InvalidIDError is returned by the ID validators like ValidatePadID.
+type InvalidIDError struct {
+	// Kind is the kind of ID, for example "pad" or "group".
+	Kind string
+
+	// ID is the invalid ID.
+	ID string
+}
+
+// Error returns the error as a string.
+func (e *InvalidIDError) Error() string {
+	return fmt.Sprintf("invalid %s ID %q", e.Kind, e.ID)
+}
+
+var (
+	// padIDPattern is the pattern etherpad uses to validate pad IDs: An
+	// optional group prefix followed by a name of 1 to 50 characters.
+	// The name is checked separately because etherpad counts UTF-16 code
+	// units.
+	padIDPattern = regexp.MustCompile(`^(g\.[a-zA-Z0-9]{16}\$)?([^$]+)$`)
+
+	groupIDPattern   = regexp.MustCompile(`^g\.[a-zA-Z0-9]{16}$`)
+	authorIDPattern  = regexp.MustCompile(`^a\.[a-zA-Z0-9]{16}$`)
+	sessionIDPattern = regexp.MustCompile(`^s\.[a-zA-Z0-9]{16}$`)
+)
+
+// maxPadNameLength is the maximal length of a pad name (without the group
+// prefix) in UTF-16 code units.
+const maxPadNameLength = 50
+
+// ValidatePadID checks if padID is a valid pad ID as accepted by etherpad:
+// Either a pad name or a group pad ID of the form "g.xxxxxxxxxxxxxxxx$padName".
+// The pad name must not be empty, must not contain "$" and must not be longer
+// than 50 characters.
+func ValidatePadID(padID string) error {
+	match := padIDPattern.FindStringSubmatch(padID)
+	if match == nil || len(utf16.Encode([]rune(match[2]))) > maxPadNameLength {
+		return &InvalidIDError{Kind: "pad", ID: padID}
+	}
+	return nil
+}
+
+// ValidateGroupID checks if groupID is a valid group ID ("g." followed by 16
+// alphanumeric characters).
+func ValidateGroupID(groupID string) error {
+	if !groupIDPattern.MatchString(groupID) {
+		return &InvalidIDError{Kind: "group", ID: groupID}
+	}
+	return nil
+}
+
+// ValidateAuthorID checks if authorID is a valid author ID ("a." followed by
+// 16 alphanumeric characters).
+func ValidateAuthorID(authorID string) error {
+	if !authorIDPattern.MatchString(authorID) {
+		return &InvalidIDError{Kind: "author", ID: authorID}
+	}
+	return nil
+}
+
+// ValidateSessionID checks if sessionID is a valid session ID ("s." followed
+// by 16 alphanumeric characters).
+func ValidateSessionID(sessionID string) error {
+	if !sessionIDPattern.MatchString(sessionID) {
+		return &InvalidIDError{Kind: "session", ID: sessionID}
+	}
+	return nil
+}
+
+// idValidators maps parameter names to the validator for their values, used
+// if EtherpadLite.StrictIDs is true.
+var idValidators = map[string]func(string) error{
+	"padID":         ValidatePadID,
+	"padName":       ValidatePadID,
+	"sourceID":      ValidatePadID,
+	"destinationID": ValidatePadID,
+	"groupID":       ValidateGroupID,
+	"authorID":      ValidateAuthorID,
+	"authorId":      ValidateAuthorID,
+	"sessionID":     ValidateSessionID,
+}
+
+// checkIDs validates all string parameters with a validator in idValidators.
+func checkIDs(params map[string]interface{}) error {
+	for name, value := range params {
+		validator, hasValidator := idValidators[name]
+		id, isString := value.(string)
+		if hasValidator && isString {
+			if err := validator(id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// urlBreakingChars are characters that are not allowed in pad names created
+// by JoinGroupPadID because they break the pad URL.
+const urlBreakingChars = "/?#%\\"
+
+// SplitGroupPadID splits a group pad ID of the form "groupID$padName" into
+// the group ID and the pad name. Only the first "$" separates the group, the
+// group ID must be valid (see ValidateGroupID) and the pad name must not be
+// empty. Otherwise an *InvalidIDError is returned.
+func SplitGroupPadID(padID string) (groupID, padName string, err error) {
+	sep := strings.IndexByte(padID, '$')
+	if sep < 0 || sep == len(padID)-1 || ValidateGroupID(padID[:sep]) != nil {
+		return "", "", &InvalidIDError{Kind: "group pad", ID: padID}
+	}
+	return padID[:sep], padID[sep+1:], nil
+}
+
+// JoinGroupPadID returns the ID "groupID$padName" of the pad with the given
+// name in a group.
+// An *InvalidIDError is returned if the group ID is invalid (see
+// ValidateGroupID), if the name is empty, contains control characters, "$"
+// or characters that break the pad URL (/, ?, #, % and \) or if the resulting
+// pad ID is not accepted by ValidatePadID.
+func JoinGroupPadID(groupID, padName string) (string, error) {
+	if err := ValidateGroupID(groupID); err != nil {
+		return "", err
+	}
+	padID := groupID + "$" + padName
+	if validatePadName(padName) != nil || ValidatePadID(padID) != nil {
+		return "", &InvalidIDError{Kind: "pad name", ID: padName}
+	}
+	return padID, nil
+}
+
+// validatePadName checks a pad name (without group prefix) for the rules of
+// JoinGroupPadID.
+func validatePadName(padName string) error {
+	invalid := padName == "" || strings.ContainsAny(padName, urlBreakingChars+"$") ||
+		strings.IndexFunc(padName, unicode.IsControl) >= 0
+	if invalid || ValidatePadID(padName) != nil {
+		return &InvalidIDError{Kind: "pad name", ID: padName}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testGroupID = "g.ABCdef0123456789"
+
+func TestSplitGroupPadID(t *testing.T) {
+	tests := []struct {
+		padID   string
+		group   string
+		name    string
+		invalid bool
+	}{
+		{testGroupID + "$notes", testGroupID, "notes", false},
+		{testGroupID + "$a$b", testGroupID, "a$b", false},
+		{testGroupID + "$$", testGroupID, "$", false},
+		{testGroupID + "$Ünïcödé 名前", testGroupID, "Ünïcödé 名前", false},
+		{testGroupID + "$😀", testGroupID, "😀", false},
+		{testGroupID + "$with space", testGroupID, "with space", false},
+		{"notes", "", "", true},
+		{"", "", "", true},
+		{"$notes", "", "", true},
+		{testGroupID + "$", "", "", true},
+		{testGroupID, "", "", true},
+		{"g.short$notes", "", "", true},
+		{"g.ABCdef01234567890$notes", "", "", true},
+		{"G.ABCdef0123456789$notes", "", "", true},
+		{"g.ABCdef012345678ü$notes", "", "", true},
+		{"x" + testGroupID + "$notes", "", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.padID, func(t *testing.T) {
+			group, name, err := SplitGroupPadID(test.padID)
+			if test.invalid {
+				var idErr *InvalidIDError
+				if !errors.As(err, &idErr) || idErr.ID != test.padID {
+					t.Errorf("expected an InvalidIDError for %q, got %q %q %v", test.padID, group, name, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if group != test.group || name != test.name {
+				t.Errorf("expected %q and %q, got %q and %q", test.group, test.name, group, name)
+			}
+		})
+	}
+}
+
+func TestJoinGroupPadID(t *testing.T) {
+	tests := []struct {
+		group   string
+		name    string
+		invalid bool
+	}{
+		{testGroupID, "notes", false},
+		{testGroupID, "Ünïcödé 名前", false},
+		{testGroupID, "😀", false},
+		{testGroupID, "with space", false},
+		{testGroupID, "dots.and-dashes_", false},
+		{testGroupID, strings.Repeat("x", 50), false},
+		// etherpad counts UTF-16 code units, each emoji counts twice
+		{testGroupID, strings.Repeat("😀", 25), false},
+		{testGroupID, strings.Repeat("x", 51), true},
+		{testGroupID, strings.Repeat("😀", 26), true},
+		{testGroupID, "", true},
+		{testGroupID, "a$b", true},
+		{testGroupID, "$", true},
+		{testGroupID, "a/b", true},
+		{testGroupID, "a?b", true},
+		{testGroupID, "a#b", true},
+		{testGroupID, "100%", true},
+		{testGroupID, "a\\b", true},
+		{testGroupID, "line\nbreak", true},
+		{testGroupID, "tab\t", true},
+		{testGroupID, "nul\x00", true},
+		{"", "notes", true},
+		{"g.short", "notes", true},
+		{"notes", "notes", true},
+	}
+	for _, test := range tests {
+		t.Run(test.group+"/"+test.name, func(t *testing.T) {
+			padID, err := JoinGroupPadID(test.group, test.name)
+			if test.invalid {
+				var idErr *InvalidIDError
+				if !errors.As(err, &idErr) {
+					t.Errorf("expected an InvalidIDError, got %q %v", padID, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := test.group + "$" + test.name; padID != expected {
+				t.Errorf("expected %q, got %q", expected, padID)
+			}
+			// splitting the result returns the parts again
+			group, name, err := SplitGroupPadID(padID)
+			if err != nil || group != test.group || name != test.name {
+				t.Errorf("expected round trip to return %q and %q, got %q %q %v", test.group, test.name, group, name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedInVersion is returned (wrapped) if an API method is called
+// that doesn't exist in the API version configured in EtherpadLite.APIVersion.
+var ErrUnsupportedInVersion = errors.New("method not supported in this API version")
+
+// minAPIVersions maps API methods to the API version that introduced them.
+// Methods that exist since version 1 are not listed.
+var minAPIVersions = map[string]string{
+	"getAuthorName":          "1.1",
+	"padUsers":               "1.1",
+	"sendClientsMessage":     "1.1",
+	"listAllGroups":          "1.1",
+	"checkToken":             "1.2",
+	"listAllPads":            "1.2.1",
+	"createDiffHTML":         "1.2.7",
+	"getChatHistory":         "1.2.7",
+	"getChatHead":            "1.2.7",
+	"getAttributePool":       "1.2.8",
+	"getRevisionChangeset":   "1.2.8",
+	"copyPad":                "1.2.9",
+	"movePad":                "1.2.9",
+	"getPadID":               "1.2.10",
+	"getSavedRevisionsCount": "1.2.11",
+	"listSavedRevisions":     "1.2.11",
+	"saveRevision":           "1.2.11",
+	"restoreRevision":        "1.2.11",
+	"appendChatMessage":      "1.2.12",
+	"appendText":             "1.2.13",
+	"getStats":               "1.2.14",
+	"copyPadWithoutHistory":  "1.2.15",
+}
+
+// parseAPIVersion parses a version like "1.2.13".
+func parseAPIVersion(version string) ([]int, error) {
+	parts := strings.Split(version, ".")
+	res := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid API version %q", version)
+		}
+		res[i] = n
+	}
+	return res, nil
+}
+
+// compareAPIVersions compares two parsed versions, missing components count
+// as 0. It returns -1 if a < b, 0 if a == b and 1 if a > b.
+func compareAPIVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// checkAPIVersion returns an error wrapping ErrUnsupportedInVersion if method
+// was introduced after version.
+// Unknown methods and versions that can't be parsed are not rejected.
+func checkAPIVersion(method, version string) error {
+	required, known := minAPIVersions[method]
+	if !known {
+		return nil
+	}
+	have, err := parseAPIVersion(version)
+	if err != nil {
+		return nil
+	}
+	need, _ := parseAPIVersion(required)
+	if compareAPIVersions(have, need) < 0 {
+		return fmt.Errorf("%w: %s requires API version %s, configured version is %s",
+			ErrUnsupportedInVersion, method, required, version)
+	}
+	return nil
+}
+
+// Supports returns true if the API method exists in the configured API
+// version (or if the method is unknown to this package).
+func (pad *EtherpadLite) Supports(method string) bool {
+	return checkAPIVersion(method, pad.APIVersion) == nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2017 - 2019 Fabian Wenzelmann <fabianwen@posteo.eu>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etherpadlite
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultConcurrency is the number of concurrent requests used by helpers
+// that issue many requests if no concurrency is given.
+const DefaultConcurrency = 8
+
+// forEach calls fn for all i in [0, n) using at most workers goroutines
+// (DefaultConcurrency if workers <= 0).
+// If fn returns an error or ctx is done no new calls are started and the
+// first error (or ctx.Err()) is returned once all running calls finished.
+// The context passed to fn is cancelled once an error occurred.
+func forEach(ctx context.Context, n, workers int, fn func(ctx context.Context, i int) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if workers <= 0 {
+		workers = DefaultConcurrency
+	}
+	if workers > n {
+		workers = n
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	setErr := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	indices := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				if err := fn(ctx, i); err != nil {
+					setErr(err)
+				}
+			}
+		}()
+	}
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			setErr(ctx.Err())
+			break feed
+		case indices <- i:
+		}
+	}
+	close(indices)
+	wg.Wait()
+	return firstErr
+}